@@ -0,0 +1,301 @@
+// Package archive backs the school's data up to, and restores it from, a
+// portable JSON file: organizations, users (with their password hashes),
+// classes, class memberships and progress history.
+//
+// Everything else is left out: attendance, recitations, goals, khatmas,
+// certificates, webhook subscriptions, notification preferences, the audit log
+// and queued notifications and webhook deliveries. Restoring an archive does
+// not bring them back, so imports are refused by databases that hold any of
+// the records they would otherwise silently sit next to.
+//
+// An archive records the format version it was written in and a SHA-256
+// checksum of its data, so truncated or edited files are refused on import.
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/quran"
+)
+
+const (
+	// Format identifies backup archives.
+	Format = "quran-backup"
+	// Version is the archive format version written by this build. Imports
+	// accept this version and older ones. Version 1 archives predate
+	// organizations; their users and classes belong to the default one.
+	Version = 2
+)
+
+// maxReportedErrors caps how many validation problems are listed at once.
+const maxReportedErrors = 20
+
+var (
+	// ErrChecksumMismatch is returned for an archive whose data does not match its checksum.
+	ErrChecksumMismatch = errors.New("archive checksum does not match its data")
+	// ErrInvalidArchive is wrapped by the errors for archives that cannot be restored.
+	ErrInvalidArchive = errors.New("invalid archive")
+)
+
+// Archive is a backup: a header describing it and the data itself.
+type Archive struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Checksum      string    `json:"checksum"`
+	Data          Data      `json:"data"`
+}
+
+// Data is the content of an archive. IDs are those of the database the archive
+// was taken from; they only link records within the archive.
+type Data struct {
+	Organizations []Organization `json:"organizations"`
+	Users         []User         `json:"users"`
+	Classes       []Class        `json:"classes"`
+	ClassMembers  []ClassMember  `json:"class_members"`
+	Progress      []Progress     `json:"progress"`
+}
+
+// Organization is a mosque or school. Organizations are matched to those of
+// the database by slug on import.
+type Organization struct {
+	ID   int    `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// User is a user account, including soft-deleted ones. An OrganizationID of 0
+// is the default organization.
+type User struct {
+	ID             int        `json:"id"`
+	OrganizationID int        `json:"organization_id,omitempty"`
+	Username       string     `json:"username"`
+	Password       string     `json:"password"`
+	Role           string     `json:"role"`
+	Phone          *string    `json:"phone,omitempty"`
+	ProgressSurah  *int       `json:"progress_surah,omitempty"`
+	ProgressAyah   *int       `json:"progress_ayah,omitempty"`
+	ProgressPage   *int       `json:"progress_page,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Class is a class and its teacher. An OrganizationID of 0 is the default
+// organization.
+type Class struct {
+	ID             int    `json:"id"`
+	OrganizationID int    `json:"organization_id,omitempty"`
+	Name           string `json:"name"`
+	TeacherID      int    `json:"teacher_id"`
+}
+
+// ClassMember puts a student in a class.
+type ClassMember struct {
+	ClassID   int `json:"class_id"`
+	StudentID int `json:"student_id"`
+}
+
+// Progress is one entry of a student's progress history.
+type Progress struct {
+	StudentID int       `json:"student_id"`
+	Surah     int       `json:"surah"`
+	Ayah      int       `json:"ayah"`
+	Page      int       `json:"page"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// envelope is an archive as stored, with the data kept raw so the checksum can
+// be checked before it is decoded.
+type envelope struct {
+	Format        string          `json:"format"`
+	Version       int             `json:"version"`
+	SchemaVersion int             `json:"schema_version"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Checksum      string          `json:"checksum"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Write encodes an archive, filling in its format, version and checksum.
+func Write(w io.Writer, archive *Archive) error {
+	data, err := json.Marshal(archive.Data)
+	if err != nil {
+		return err
+	}
+	archive.Format = Format
+	archive.Version = Version
+	archive.Checksum = checksum(data)
+
+	return json.NewEncoder(w).Encode(envelope{
+		Format:        archive.Format,
+		Version:       archive.Version,
+		SchemaVersion: archive.SchemaVersion,
+		CreatedAt:     archive.CreatedAt,
+		Checksum:      archive.Checksum,
+		Data:          data,
+	})
+}
+
+// Read decodes an archive and verifies its checksum. Whitespace in the data
+// does not count, so a reformatted archive still verifies.
+func Read(r io.Reader) (*Archive, error) {
+	var env envelope
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if env.Format != Format {
+		return nil, fmt.Errorf("%w: not a %s file", ErrInvalidArchive, Format)
+	}
+	if env.Version < 1 || env.Version > Version {
+		return nil, fmt.Errorf("%w: format version %d is not supported (this build reads up to %d)", ErrInvalidArchive, env.Version, Version)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, env.Data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if checksum(compact.Bytes()) != env.Checksum {
+		return nil, ErrChecksumMismatch
+	}
+
+	archive := &Archive{
+		Format:        env.Format,
+		Version:       env.Version,
+		SchemaVersion: env.SchemaVersion,
+		CreatedAt:     env.CreatedAt,
+		Checksum:      env.Checksum,
+	}
+	if err := json.Unmarshal(compact.Bytes(), &archive.Data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return archive, nil
+}
+
+// Validate checks that the data can be restored: IDs, slugs and the usernames
+// of active users within an organization are unique and every reference
+// points at a record in the archive.
+func (d *Data) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	organizations := map[int]bool{0: true}
+	slugs := map[string]bool{}
+	for i, organization := range d.Organizations {
+		if organization.ID == 0 || organizations[organization.ID] {
+			fail("organizations[%d]: duplicate id %d", i, organization.ID)
+		}
+		organizations[organization.ID] = true
+		if organization.Slug == "" {
+			fail("organizations[%d]: slug is empty", i)
+		} else if slugs[organization.Slug] {
+			fail("organizations[%d]: duplicate slug %q", i, organization.Slug)
+		}
+		slugs[organization.Slug] = true
+		if organization.Name == "" {
+			fail("organizations[%d]: name is empty", i)
+		}
+	}
+
+	type orgUsername struct {
+		organizationID int
+		username       string
+	}
+	users := map[int]bool{}
+	userOrganizations := map[int]int{}
+	usernames := map[orgUsername]bool{}
+	for i, user := range d.Users {
+		if users[user.ID] {
+			fail("users[%d]: duplicate id %d", i, user.ID)
+		}
+		users[user.ID] = true
+		userOrganizations[user.ID] = user.OrganizationID
+		if !organizations[user.OrganizationID] {
+			fail("users[%d]: organization %d is not in the archive", i, user.OrganizationID)
+		}
+		key := orgUsername{user.OrganizationID, user.Username}
+		if user.Username == "" {
+			fail("users[%d]: username is empty", i)
+		} else if user.DeletedAt == nil && usernames[key] {
+			fail("users[%d]: duplicate username %q", i, user.Username)
+		}
+		if user.DeletedAt == nil {
+			usernames[key] = true
+		}
+		if user.Password == "" {
+			fail("users[%d]: password hash is empty", i)
+		}
+		switch user.Role {
+		case "developer", "admin", "user", "teacher", "student":
+		default:
+			fail("users[%d]: unknown role %q", i, user.Role)
+		}
+	}
+
+	classes := map[int]bool{}
+	classOrganizations := map[int]int{}
+	for i, class := range d.Classes {
+		if classes[class.ID] {
+			fail("classes[%d]: duplicate id %d", i, class.ID)
+		}
+		classes[class.ID] = true
+		classOrganizations[class.ID] = class.OrganizationID
+		if class.Name == "" {
+			fail("classes[%d]: name is empty", i)
+		}
+		if !users[class.TeacherID] {
+			fail("classes[%d]: teacher %d is not in the archive", i, class.TeacherID)
+		} else if userOrganizations[class.TeacherID] != class.OrganizationID {
+			fail("classes[%d]: teacher %d is in another organization", i, class.TeacherID)
+		}
+	}
+
+	members := map[ClassMember]bool{}
+	for i, member := range d.ClassMembers {
+		if !classes[member.ClassID] {
+			fail("class_members[%d]: class %d is not in the archive", i, member.ClassID)
+		}
+		if !users[member.StudentID] {
+			fail("class_members[%d]: user %d is not in the archive", i, member.StudentID)
+		} else if classes[member.ClassID] && userOrganizations[member.StudentID] != classOrganizations[member.ClassID] {
+			fail("class_members[%d]: user %d is in another organization than class %d", i, member.StudentID, member.ClassID)
+		}
+		if members[member] {
+			fail("class_members[%d]: user %d is already in class %d", i, member.StudentID, member.ClassID)
+		}
+		members[member] = true
+	}
+
+	for i, entry := range d.Progress {
+		if !users[entry.StudentID] {
+			fail("progress[%d]: user %d is not in the archive", i, entry.StudentID)
+		}
+		if entry.Surah < 1 || entry.Surah > quran.SurahCount {
+			fail("progress[%d]: surah %d is out of range", i, entry.Surah)
+		}
+		if entry.Page < 1 || entry.Page > quran.Pages {
+			fail("progress[%d]: page %d is out of range", i, entry.Page)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) > maxReportedErrors {
+		more := len(errs) - maxReportedErrors
+		errs = append(errs[:maxReportedErrors], fmt.Errorf("and %d more problems", more))
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidArchive, errors.Join(errs...))
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,111 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testData() Data {
+	return Data{
+		Organizations: []Organization{{ID: 2, Slug: "al-noor", Name: "Al-Noor"}},
+		Users: []User{
+			{ID: 1, OrganizationID: 2, Username: "teacher", Password: "$2a$10$hash", Role: "teacher"},
+			{ID: 7, OrganizationID: 2, Username: "student", Password: "$2a$10$hash", Role: "student"},
+			{ID: 9, Username: "student", Password: "$2a$10$hash", Role: "student"},
+		},
+		Classes:      []Class{{ID: 3, OrganizationID: 2, Name: "الفجر", TeacherID: 1}},
+		ClassMembers: []ClassMember{{ClassID: 3, StudentID: 7}},
+		Progress:     []Progress{{StudentID: 7, Surah: 2, Ayah: 5, Page: 2, CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	written := &Archive{SchemaVersion: 6, CreatedAt: time.Now().UTC(), Data: testData()}
+	if err := Write(&buf, written); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if read.Checksum != written.Checksum || read.Version != Version || read.SchemaVersion != 6 {
+		t.Errorf("header = %+v, want the written one", read)
+	}
+	if got := read.Data.Summarize(); got != (Summary{Organizations: 1, Users: 3, Classes: 1, ClassMembers: 1, Progress: 1}) {
+		t.Errorf("summary = %+v", got)
+	}
+
+	// Reformatting the file keeps the checksum valid.
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(&indented); err != nil {
+		t.Errorf("Read of the indented archive: %v", err)
+	}
+
+	// Changing the data does not.
+	tampered := strings.Replace(buf.String(), `"page":2`, `"page":3`, 1)
+	if _, err := Read(strings.NewReader(tampered)); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Read of a tampered archive = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestReadRejectsUnknownVersions(t *testing.T) {
+	for _, header := range []string{
+		`{"format":"something-else","version":1,"data":{}}`,
+		`{"format":"quran-backup","version":99,"data":{}}`,
+	} {
+		if _, err := Read(strings.NewReader(header)); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("Read(%s) = %v, want ErrInvalidArchive", header, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := testData()
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// A deleted user's username may be taken again.
+	deletedAt := time.Now()
+	reused := testData()
+	reused.Users = append(reused.Users, User{ID: 10, OrganizationID: 2, Username: "teacher", Password: "$2a$10$hash", Role: "teacher", DeletedAt: &deletedAt})
+	if err := reused.Validate(); err != nil {
+		t.Fatalf("Validate with a reused username: %v", err)
+	}
+
+	tests := map[string]func(d *Data){
+		"duplicate slug": func(d *Data) {
+			d.Organizations = append(d.Organizations, Organization{ID: 4, Slug: "al-noor", Name: "Other"})
+		},
+		"missing organization": func(d *Data) { d.Users[0].OrganizationID = 5 },
+		"duplicate user id":    func(d *Data) { d.Users[1].ID = 1 },
+		"duplicate username":   func(d *Data) { d.Users[1].Username = "teacher" },
+		"unknown role":         func(d *Data) { d.Users[0].Role = "owner" },
+		"missing teacher":      func(d *Data) { d.Classes[0].TeacherID = 99 },
+		"foreign teacher":      func(d *Data) { d.Classes[0].TeacherID = 9 },
+		"missing class":        func(d *Data) { d.ClassMembers[0].ClassID = 99 },
+		"missing member":       func(d *Data) { d.ClassMembers[0].StudentID = 99 },
+		"foreign member":       func(d *Data) { d.ClassMembers[0].StudentID = 9 },
+		"duplicate member":     func(d *Data) { d.ClassMembers = append(d.ClassMembers, d.ClassMembers[0]) },
+		"missing student":      func(d *Data) { d.Progress[0].StudentID = 99 },
+		"page out of range":    func(d *Data) { d.Progress[0].Page = 605 },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := testData()
+			mutate(&data)
+			if err := data.Validate(); !errors.Is(err, ErrInvalidArchive) {
+				t.Errorf("Validate = %v, want ErrInvalidArchive", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// seedUsername is the account db.sql creates in every database, in the
+// default organization. A database holding only this account still counts as
+// empty.
+const seedUsername = "developer"
+
+// defaultOrganizationID is the organization db.sql creates. Users and classes
+// of archives that predate organizations are restored into it.
+const defaultOrganizationID = 1
+
+// insertBatchSize is how many memberships or progress entries are sent to the
+// database in one round trip.
+const insertBatchSize = 500
+
+// ErrDatabaseNotEmpty is returned when restoring into a database that already
+// holds data.
+var ErrDatabaseNotEmpty = errors.New("the database is not empty")
+
+// leftOutTables hold school data an archive does not carry, with the name
+// their records are reported under.
+var leftOutTables = []struct{ table, name string }{
+	{"attendance", "attendance records"},
+	{"recitations", "recitations"},
+	{"goals", "goals"},
+	{"khatmas", "khatmas"},
+	{"certificates", "certificates"},
+	{"webhook_subscriptions", "webhook subscriptions"},
+	{"notification_preferences", "notification preferences"},
+}
+
+// LeftOut names the school data Export does not write to an archive.
+func LeftOut() []string {
+	names := make([]string, len(leftOutTables))
+	for i, t := range leftOutTables {
+		names[i] = t.name
+	}
+	return names
+}
+
+// Summary counts the records in an archive.
+type Summary struct {
+	Organizations int
+	Users         int
+	Classes       int
+	ClassMembers  int
+	Progress      int
+}
+
+// Summarize counts the records in the data.
+func (d *Data) Summarize() Summary {
+	return Summary{Organizations: len(d.Organizations), Users: len(d.Users), Classes: len(d.Classes), ClassMembers: len(d.ClassMembers), Progress: len(d.Progress)}
+}
+
+// Export reads everything an archive holds from a consistent snapshot of the
+// database. The data named by LeftOut is not read.
+func Export(ctx context.Context, db *pgxpool.Pool, schemaVersion int) (*Archive, error) {
+	archive := &Archive{SchemaVersion: schemaVersion, CreatedAt: time.Now().UTC()}
+	data := &archive.Data
+	txOptions := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+
+	err := db.BeginTxFunc(ctx, txOptions, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "SELECT id, slug, name FROM organizations ORDER BY id")
+		if err != nil {
+			return err
+		}
+		data.Organizations = []Organization{}
+		for rows.Next() {
+			var o Organization
+			if err := rows.Scan(&o.ID, &o.Slug, &o.Name); err != nil {
+				rows.Close()
+				return err
+			}
+			data.Organizations = append(data.Organizations, o)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, "SELECT id, organization_id, username, password, role, phone, progress_surah, progress_ayah, progress_page, deleted_at FROM users ORDER BY id")
+		if err != nil {
+			return err
+		}
+		data.Users = []User{}
+		for rows.Next() {
+			var u User
+			if err := rows.Scan(&u.ID, &u.OrganizationID, &u.Username, &u.Password, &u.Role, &u.Phone, &u.ProgressSurah, &u.ProgressAyah, &u.ProgressPage, &u.DeletedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			data.Users = append(data.Users, u)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, "SELECT id, organization_id, name, teacher_id FROM classes ORDER BY id")
+		if err != nil {
+			return err
+		}
+		data.Classes = []Class{}
+		for rows.Next() {
+			var c Class
+			if err := rows.Scan(&c.ID, &c.OrganizationID, &c.Name, &c.TeacherID); err != nil {
+				rows.Close()
+				return err
+			}
+			data.Classes = append(data.Classes, c)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, "SELECT class_id, student_id FROM class_members ORDER BY class_id, student_id")
+		if err != nil {
+			return err
+		}
+		data.ClassMembers = []ClassMember{}
+		for rows.Next() {
+			var m ClassMember
+			if err := rows.Scan(&m.ClassID, &m.StudentID); err != nil {
+				rows.Close()
+				return err
+			}
+			data.ClassMembers = append(data.ClassMembers, m)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, "SELECT student_id, surah, ayah, page, created_at FROM progress ORDER BY id")
+		if err != nil {
+			return err
+		}
+		data.Progress = []Progress{}
+		for rows.Next() {
+			var p Progress
+			if err := rows.Scan(&p.StudentID, &p.Surah, &p.Ayah, &p.Page, &p.CreatedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			data.Progress = append(data.Progress, p)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Import restores an archive into an empty database in a single transaction.
+// Organizations are matched by slug and created when missing; other records
+// get new IDs and every reference is remapped to them. If the archive has its
+// own developer account in the default organization it replaces the seeded one.
+func Import(ctx context.Context, db *pgxpool.Pool, archive *Archive) error {
+	data := &archive.Data
+	if err := data.Validate(); err != nil {
+		return err
+	}
+
+	return db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := checkEmpty(ctx, tx); err != nil {
+			return err
+		}
+
+		organizationIDs := map[int]int{0: defaultOrganizationID}
+		for i, organization := range data.Organizations {
+			query := `
+				INSERT INTO organizations (slug, name) VALUES ($1, $2)
+				ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name
+				RETURNING id
+			`
+			var id int
+			if err := tx.QueryRow(ctx, query, organization.Slug, organization.Name).Scan(&id); err != nil {
+				return fmt.Errorf("organizations[%d]: %w", i, err)
+			}
+			organizationIDs[organization.ID] = id
+		}
+
+		for _, user := range data.Users {
+			if user.Username == seedUsername && organizationIDs[user.OrganizationID] == defaultOrganizationID {
+				if _, err := tx.Exec(ctx, "DELETE FROM users WHERE organization_id = $1 AND username = $2", defaultOrganizationID, seedUsername); err != nil {
+					return err
+				}
+			}
+		}
+
+		userIDs := make(map[int]int, len(data.Users))
+		for i, user := range data.Users {
+			query := `
+				INSERT INTO users (organization_id, username, password, role, phone, progress_surah, progress_ayah, progress_page, deleted_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				RETURNING id
+			`
+			var id int
+			err := tx.QueryRow(ctx, query, organizationIDs[user.OrganizationID], user.Username, user.Password, user.Role, user.Phone,
+				user.ProgressSurah, user.ProgressAyah, user.ProgressPage, user.DeletedAt).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("users[%d]: %w", i, err)
+			}
+			userIDs[user.ID] = id
+		}
+
+		classIDs := make(map[int]int, len(data.Classes))
+		for i, class := range data.Classes {
+			var id int
+			err := tx.QueryRow(ctx, "INSERT INTO classes (organization_id, name, teacher_id) VALUES ($1, $2, $3) RETURNING id",
+				organizationIDs[class.OrganizationID], class.Name, userIDs[class.TeacherID]).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("classes[%d]: %w", i, err)
+			}
+			classIDs[class.ID] = id
+		}
+
+		batch := &pgx.Batch{}
+		for _, member := range data.ClassMembers {
+			batch.Queue("INSERT INTO class_members (class_id, student_id) VALUES ($1, $2)", classIDs[member.ClassID], userIDs[member.StudentID])
+			if batch.Len() == insertBatchSize {
+				if err := sendBatch(ctx, tx, batch); err != nil {
+					return err
+				}
+				batch = &pgx.Batch{}
+			}
+		}
+		for _, entry := range data.Progress {
+			batch.Queue("INSERT INTO progress (organization_id, student_id, surah, ayah, page, created_at) SELECT organization_id, id, $2, $3, $4, $5 FROM users WHERE id = $1",
+				userIDs[entry.StudentID], entry.Surah, entry.Ayah, entry.Page, entry.CreatedAt)
+			if batch.Len() == insertBatchSize {
+				if err := sendBatch(ctx, tx, batch); err != nil {
+					return err
+				}
+				batch = &pgx.Batch{}
+			}
+		}
+		return sendBatch(ctx, tx, batch)
+	})
+}
+
+// Check reports whether Import would accept the archive, without writing anything.
+func Check(ctx context.Context, db *pgxpool.Pool, archive *Archive) error {
+	if err := archive.Data.Validate(); err != nil {
+		return err
+	}
+	return db.BeginTxFunc(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		return checkEmpty(ctx, tx)
+	})
+}
+
+// checkEmpty refuses databases holding anything but the seeded developer
+// account, including the data an archive leaves out. Organizations without
+// users do not count.
+func checkEmpty(ctx context.Context, tx pgx.Tx) error {
+	type counted struct{ query, name string }
+	tables := []counted{
+		{"SELECT COUNT(*) FROM users WHERE NOT (organization_id = $2 AND username = $1)", "users"},
+		{"SELECT COUNT(*) FROM classes", "classes"},
+		{"SELECT COUNT(*) FROM class_members", "class memberships"},
+		{"SELECT COUNT(*) FROM progress", "progress entries"},
+	}
+	for _, t := range leftOutTables {
+		tables = append(tables, counted{"SELECT COUNT(*) FROM " + t.table, t.name})
+	}
+
+	counts := make([]int, len(tables))
+	columns := make([]string, len(tables))
+	dest := make([]interface{}, len(tables))
+	for i, t := range tables {
+		columns[i] = "(" + t.query + ")"
+		dest[i] = &counts[i]
+	}
+	if err := tx.QueryRow(ctx, "SELECT "+strings.Join(columns, ", "), seedUsername, defaultOrganizationID).Scan(dest...); err != nil {
+		return err
+	}
+
+	var held []string
+	for i, t := range tables {
+		if counts[i] > 0 {
+			held = append(held, fmt.Sprintf("%d %s", counts[i], t.name))
+		}
+	}
+	if len(held) > 0 {
+		return fmt.Errorf("%w: it has %s", ErrDatabaseNotEmpty, strings.Join(held, ", "))
+	}
+	return nil
+}
+
+func sendBatch(ctx context.Context, tx pgx.Tx, batch *pgx.Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+	results := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return fmt.Errorf("restoring memberships and progress: %w", err)
+		}
+	}
+	return results.Close()
+}
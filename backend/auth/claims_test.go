@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestClaimsValid(t *testing.T) {
+	valid := func() *Claims { return NewClaims(7, 2, "teacher", time.Hour) }
+
+	tests := []struct {
+		name   string
+		mutate func(c *Claims)
+		want   error
+	}{
+		{name: "complete", mutate: func(c *Claims) {}},
+		{name: "missing user ID", mutate: func(c *Claims) { c.UserID = 0 }, want: ErrMissingUserID},
+		{name: "missing organization", mutate: func(c *Claims) { c.OrganizationID = 0 }, want: ErrMissingOrganization},
+		{name: "missing role", mutate: func(c *Claims) { c.Role = "" }, want: ErrMissingRole},
+		{name: "missing session ID", mutate: func(c *Claims) { c.SessionID = "" }, want: ErrMissingSessionID},
+		{name: "missing issued-at", mutate: func(c *Claims) { c.IssuedAt = nil }, want: ErrMissingIssuedAt},
+		{name: "missing expiry", mutate: func(c *Claims) { c.ExpiresAt = nil }, want: ErrMissingExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.mutate(claims)
+			if err := claims.Valid(); !errors.Is(err, tt.want) {
+				t.Fatalf("Valid() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimsValidExpired(t *testing.T) {
+	claims := NewClaims(7, 2, "teacher", -time.Minute)
+	var validationErr *jwt.ValidationError
+	if err := claims.Valid(); !errors.As(err, &validationErr) || validationErr.Errors&jwt.ValidationErrorExpired == 0 {
+		t.Fatalf("Valid() = %v, want an expiry error", err)
+	}
+}
+
+func TestClaimsCurrentUser(t *testing.T) {
+	claims := NewClaims(7, 2, "teacher", time.Hour)
+	user := claims.CurrentUser()
+	if user.ID != 7 || user.OrganizationID != 2 || user.Role != "teacher" || user.SessionID != claims.SessionID || !user.IssuedAt.Equal(claims.IssuedAt.Time) {
+		t.Fatalf("CurrentUser() = %+v, does not match claims %+v", user, claims)
+	}
+	if !user.HasRole("admin", "teacher") || user.HasRole("student") {
+		t.Fatalf("HasRole gave unexpected results for role %q", user.Role)
+	}
+}
@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing or verification.
+const minRSAKeyBits = 2048
+
+// KeySetConfig describes where the token keys come from.
+type KeySetConfig struct {
+	// Secret is the HS256 secret, used only when no SigningKeyFile is set.
+	Secret string
+	// SigningKeyFile is a PEM encoded RSA or Ed25519 private key (PKCS#8, or
+	// PKCS#1 for RSA) used to sign new tokens.
+	SigningKeyFile string
+	// SigningKeyID is the kid of the signing key; it defaults to the file name
+	// without its extension.
+	SigningKeyID string
+	// VerificationKeyFiles are PEM encoded public keys that are still accepted,
+	// e.g. the previous signing key during a rotation. Each key's kid is its
+	// file name without the extension.
+	VerificationKeyFiles []string
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// KeySet signs tokens with a single active key and verifies them against every
+// key that is still trusted, selected by the token's kid header.
+type KeySet struct {
+	signingID     string
+	signingMethod jwt.SigningMethod
+	signingKey    interface{}
+	verification  map[string]verificationKey
+	secret        []byte
+}
+
+// NewKeySet loads the keys described by cfg. Without a signing key file it
+// falls back to HS256 with the shared secret, and publishes no keys.
+func NewKeySet(cfg KeySetConfig) (*KeySet, error) {
+	if cfg.SigningKeyFile == "" {
+		if cfg.Secret == "" {
+			return nil, errors.New("either a JWT signing key file or a JWT secret is required")
+		}
+		return &KeySet{signingMethod: jwt.SigningMethodHS256, signingKey: []byte(cfg.Secret), secret: []byte(cfg.Secret)}, nil
+	}
+
+	private, err := readPrivateKey(cfg.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	method, public, err := methodFor(private)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", cfg.SigningKeyFile, err)
+	}
+
+	ks := &KeySet{
+		signingID:     cfg.SigningKeyID,
+		signingMethod: method,
+		signingKey:    private,
+		verification:  map[string]verificationKey{},
+	}
+	if ks.signingID == "" {
+		ks.signingID = keyIDFromPath(cfg.SigningKeyFile)
+	}
+	ks.verification[ks.signingID] = verificationKey{method: method, key: public}
+
+	for _, path := range cfg.VerificationKeyFiles {
+		public, err := readPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		method, _, err := methodFor(public)
+		if err != nil {
+			return nil, fmt.Errorf("verification key %s: %w", path, err)
+		}
+		kid := keyIDFromPath(path)
+		if _, exists := ks.verification[kid]; exists {
+			return nil, fmt.Errorf("duplicate JWT key ID %q", kid)
+		}
+		ks.verification[kid] = verificationKey{method: method, key: public}
+	}
+
+	return ks, nil
+}
+
+// Sign issues a token for the claims, signed with the active key and tagged with its kid.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signingMethod, claims)
+	if ks.signingID != "" {
+		token.Header["kid"] = ks.signingID
+	}
+	return token.SignedString(ks.signingKey)
+}
+
+// Keyfunc returns the key that must have signed the token, rejecting tokens
+// whose algorithm does not match the key registered for their kid.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if ks.secret != nil {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return ks.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+	}
+	return key.key, nil
+}
+
+// JWK is a single public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every trusted public key. It is empty when tokens are signed
+// with the shared HS256 secret, which must never be published.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for kid, key := range ks.verification {
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// methodFor returns the signing method matching the key, and its public half.
+func methodFor(key interface{}) (jwt.SigningMethod, crypto.PublicKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return methodFor(&k.PublicKey)
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		return jwt.SigningMethodRS256, k, nil
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, k.Public(), nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, k, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", key)
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWT key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("JWT key %s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+func readPrivateKey(path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("JWT signing key %s is not a PKCS#8 or PKCS#1 private key", path)
+}
+
+func readPublicKey(path string) (interface{}, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("JWT verification key %s is not a PKIX or PKCS#1 public key", path)
+}
+
+func keyIDFromPath(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,427 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// keyFiles writes PEM encoded test keys into a temporary directory.
+type keyFiles struct {
+	t   *testing.T
+	dir string
+}
+
+func newKeyFiles(t *testing.T) *keyFiles {
+	t.Helper()
+	return &keyFiles{t: t, dir: t.TempDir()}
+}
+
+func (f *keyFiles) write(name, blockType string, der []byte) string {
+	f.t.Helper()
+	path := filepath.Join(f.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		f.t.Fatal(err)
+	}
+	return path
+}
+
+// private writes key as PKCS#8 and returns its path.
+func (f *keyFiles) private(name string, key interface{}) string {
+	f.t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return f.write(name, "PRIVATE KEY", der)
+}
+
+// public writes key as PKIX and returns its path.
+func (f *keyFiles) public(name string, key interface{}) string {
+	f.t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return f.write(name, "PUBLIC KEY", der)
+}
+
+func rsaKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func ed25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func mustKeySet(t *testing.T, cfg KeySetConfig) *KeySet {
+	t.Helper()
+	ks, err := NewKeySet(cfg)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return ks
+}
+
+func mustSign(t *testing.T, ks *KeySet) string {
+	t.Helper()
+	token, err := ks.Sign(NewClaims(7, 2, "teacher", time.Hour))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+func parse(ks *KeySet, token string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(token, &Claims{}, ks.Keyfunc)
+}
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	files := newKeyFiles(t)
+	rsaPrivate := rsaKey(t, 2048)
+	pkcs1 := files.write("legacy.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPrivate))
+
+	tests := []struct {
+		name    string
+		cfg     KeySetConfig
+		wantAlg string
+		wantKid string
+	}{
+		{
+			name:    "HS256 secret",
+			cfg:     KeySetConfig{Secret: "s3cret"},
+			wantAlg: "HS256",
+		},
+		{
+			name:    "RS256 PKCS#8",
+			cfg:     KeySetConfig{SigningKeyFile: files.private("rsa-2026.pem", rsaPrivate)},
+			wantAlg: "RS256",
+			wantKid: "rsa-2026",
+		},
+		{
+			name:    "RS256 PKCS#1",
+			cfg:     KeySetConfig{SigningKeyFile: pkcs1},
+			wantAlg: "RS256",
+			wantKid: "legacy",
+		},
+		{
+			name:    "EdDSA",
+			cfg:     KeySetConfig{SigningKeyFile: files.private("ed-2026.pem", ed25519Key(t))},
+			wantAlg: "EdDSA",
+			wantKid: "ed-2026",
+		},
+		{
+			name:    "explicit key ID",
+			cfg:     KeySetConfig{SigningKeyFile: files.private("ed.pem", ed25519Key(t)), SigningKeyID: "primary"},
+			wantAlg: "EdDSA",
+			wantKid: "primary",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := mustKeySet(t, tt.cfg)
+
+			token, err := parse(ks, mustSign(t, ks))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !token.Valid {
+				t.Fatal("token is not valid")
+			}
+			if alg := token.Method.Alg(); alg != tt.wantAlg {
+				t.Errorf("alg = %q, want %q", alg, tt.wantAlg)
+			}
+			kid, _ := token.Header["kid"].(string)
+			if kid != tt.wantKid {
+				t.Errorf("kid = %q, want %q", kid, tt.wantKid)
+			}
+			if claims := token.Claims.(*Claims); claims.UserID != 7 || claims.OrganizationID != 2 {
+				t.Errorf("claims = %+v, want user 7 in organization 2", claims)
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	files := newKeyFiles(t)
+	oldKey, newKey := ed25519Key(t), rsaKey(t, 2048)
+	oldPrivate := files.private("2025.pem", oldKey)
+	oldPublic := files.public("2025.pub", oldKey.Public())
+	newPrivate := files.private("2026.pem", newKey)
+
+	before := mustKeySet(t, KeySetConfig{SigningKeyFile: oldPrivate})
+	during := mustKeySet(t, KeySetConfig{SigningKeyFile: newPrivate, VerificationKeyFiles: []string{oldPublic}})
+	after := mustKeySet(t, KeySetConfig{SigningKeyFile: newPrivate})
+
+	oldToken, newToken := mustSign(t, before), mustSign(t, during)
+
+	tests := []struct {
+		name    string
+		ks      *KeySet
+		token   string
+		wantErr string
+	}{
+		{name: "new key signs", ks: during, token: newToken},
+		{name: "old token still verifies during rotation", ks: during, token: oldToken},
+		{name: "new token verifies once the old key is retired", ks: after, token: newToken},
+		{name: "old token rejected once the old key is retired", ks: after, token: oldToken, wantErr: `unknown key ID "2025"`},
+		{name: "new token unknown before rotation", ks: before, token: newToken, wantErr: `unknown key ID "2026"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.ks, tt.token)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parse: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetRejectsForeignTokens(t *testing.T) {
+	files := newKeyFiles(t)
+	rsaPrivate := rsaKey(t, 2048)
+	rsaPublicPEM := files.public("rsa.pub", rsaPrivate.Public())
+	publicBytes, err := os.ReadFile(rsaPublicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPrivate := ed25519Key(t)
+
+	asymmetric := mustKeySet(t, KeySetConfig{
+		SigningKeyFile:       files.private("rsa.pem", rsaPrivate),
+		VerificationKeyFiles: []string{files.public("ed.pub", edPrivate.Public())},
+	})
+	symmetric := mustKeySet(t, KeySetConfig{Secret: "s3cret"})
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, NewClaims(7, 2, "admin", time.Hour))
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		ks    *KeySet
+		token string
+	}{
+		{
+			// The classic algorithm confusion attack: HMAC keyed with the published public key.
+			name:  "HS256 keyed with the RSA public key",
+			ks:    asymmetric,
+			token: sign(jwt.SigningMethodHS256, "rsa", publicBytes),
+		},
+		{
+			name:  "HS256 without a kid",
+			ks:    asymmetric,
+			token: sign(jwt.SigningMethodHS256, "", []byte("s3cret")),
+		},
+		{
+			name:  "EdDSA token claiming the RSA kid",
+			ks:    asymmetric,
+			token: sign(jwt.SigningMethodEdDSA, "rsa", edPrivate),
+		},
+		{
+			name:  "RS256 token claiming the Ed25519 kid",
+			ks:    asymmetric,
+			token: sign(jwt.SigningMethodRS256, "ed", rsaPrivate),
+		},
+		{
+			name:  "unsigned token",
+			ks:    asymmetric,
+			token: sign(jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType),
+		},
+		{
+			name:  "RS256 token against an HS256 key set",
+			ks:    symmetric,
+			token: sign(jwt.SigningMethodRS256, "rsa", rsaPrivate),
+		},
+		{
+			name:  "HS256 token with the wrong secret",
+			ks:    symmetric,
+			token: sign(jwt.SigningMethodHS256, "", []byte("guessed")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if token, err := parse(tt.ks, tt.token); err == nil {
+				t.Fatalf("token accepted with alg %s", token.Method.Alg())
+			}
+		})
+	}
+}
+
+func TestNewKeySetErrors(t *testing.T) {
+	files := newKeyFiles(t)
+	rsaPrivate := rsaKey(t, 2048)
+	signing := files.private("current.pem", rsaPrivate)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(files.dir, "plain.txt")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     KeySetConfig
+		wantErr string
+	}{
+		{
+			name:    "no key material",
+			cfg:     KeySetConfig{},
+			wantErr: "either a JWT signing key file or a JWT secret is required",
+		},
+		{
+			name:    "signing key below the minimum RSA size",
+			cfg:     KeySetConfig{SigningKeyFile: files.private("small.pem", rsaKey(t, 1024))},
+			wantErr: "at least 2048 bits",
+		},
+		{
+			name: "verification key below the minimum RSA size",
+			cfg: KeySetConfig{
+				SigningKeyFile:       signing,
+				VerificationKeyFiles: []string{files.public("small.pub", rsaKey(t, 1024).Public())},
+			},
+			wantErr: "at least 2048 bits",
+		},
+		{
+			name:    "unsupported key type",
+			cfg:     KeySetConfig{SigningKeyFile: files.private("ec.pem", ecKey)},
+			wantErr: "unsupported key type",
+		},
+		{
+			name: "verification key reusing the signing kid",
+			cfg: KeySetConfig{
+				SigningKeyFile:       signing,
+				VerificationKeyFiles: []string{files.public("current.pub", rsaPrivate.Public())},
+			},
+			wantErr: `duplicate JWT key ID "current"`,
+		},
+		{
+			name: "two verification keys with the same kid",
+			cfg: KeySetConfig{
+				SigningKeyFile: signing,
+				VerificationKeyFiles: []string{
+					files.public("old.pub", ed25519Key(t).Public()),
+					files.public("old.pem", ed25519Key(t).Public()),
+				},
+			},
+			wantErr: `duplicate JWT key ID "old"`,
+		},
+		{
+			name:    "missing signing key file",
+			cfg:     KeySetConfig{SigningKeyFile: filepath.Join(files.dir, "missing.pem")},
+			wantErr: "reading JWT key",
+		},
+		{
+			name:    "signing key not PEM encoded",
+			cfg:     KeySetConfig{SigningKeyFile: notPEM},
+			wantErr: "is not PEM encoded",
+		},
+		{
+			name:    "public key given as the signing key",
+			cfg:     KeySetConfig{SigningKeyFile: files.public("public.pem", rsaPrivate.Public())},
+			wantErr: "is not a PKCS#8 or PKCS#1 private key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeySet(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewKeySet error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	files := newKeyFiles(t)
+	rsaPrivate := rsaKey(t, 2048)
+	edPrivate := ed25519Key(t)
+
+	ks := mustKeySet(t, KeySetConfig{
+		SigningKeyFile:       files.private("b-rsa.pem", rsaPrivate),
+		VerificationKeyFiles: []string{files.public("a-ed.pub", edPrivate.Public())},
+	})
+
+	decode := func(s string) []byte {
+		t.Helper()
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", s, err)
+		}
+		return b
+	}
+
+	keys := ks.JWKS().Keys
+	if len(keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(keys))
+	}
+
+	ed := keys[0]
+	if ed.Kid != "a-ed" || ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != "EdDSA" || ed.Use != "sig" {
+		t.Errorf("Ed25519 JWK = %+v", ed)
+	}
+	if got := decode(ed.X); string(got) != string(edPrivate.Public().(ed25519.PublicKey)) {
+		t.Error("Ed25519 JWK x does not match the public key")
+	}
+	if ed.N != "" || ed.E != "" {
+		t.Errorf("Ed25519 JWK carries RSA members: %+v", ed)
+	}
+
+	rs := keys[1]
+	if rs.Kid != "b-rsa" || rs.Kty != "RSA" || rs.Alg != "RS256" || rs.Use != "sig" {
+		t.Errorf("RSA JWK = %+v", rs)
+	}
+	if n := new(big.Int).SetBytes(decode(rs.N)); n.Cmp(rsaPrivate.N) != 0 {
+		t.Error("RSA JWK n does not match the modulus")
+	}
+	if e := new(big.Int).SetBytes(decode(rs.E)); e.Int64() != int64(rsaPrivate.E) {
+		t.Errorf("RSA JWK e = %d, want %d", e.Int64(), rsaPrivate.E)
+	}
+	if rs.Crv != "" || rs.X != "" {
+		t.Errorf("RSA JWK carries OKP members: %+v", rs)
+	}
+
+	if keys := mustKeySet(t, KeySetConfig{Secret: "s3cret"}).JWKS().Keys; keys == nil || len(keys) != 0 {
+		t.Errorf("HS256 JWKS keys = %v, want an empty list", keys)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kolind-am/quran-project/backend/archive"
+	"github.com/kolind-am/quran-project/backend/config"
+	"github.com/kolind-am/quran-project/backend/database"
+	"github.com/kolind-am/quran-project/backend/logging"
+)
+
+const usage = `Usage:
+  backend                        run the API server
+  backend export [-o FILE]       write a backup archive (default: standard output)
+  backend import [-dry-run] FILE restore a backup archive into an empty database
+`
+
+// runCommand runs a maintenance subcommand and returns the process exit code.
+// Logs go to standard error so an archive can be written to standard output.
+func runCommand(name string, args []string) int {
+	var run func(ctx context.Context, args []string) error
+	switch name {
+	case "export":
+		run = exportCommand
+	case "import":
+		run = importCommand
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	logger := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	slog.SetDefault(logger)
+
+	database.Connect(cfg.DatabaseURL, cfg.DBMaxConns, cfg.DBMinConns)
+	defer database.Close()
+
+	ctx := logging.WithContext(context.Background(), logger)
+	if err := run(ctx, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		logger.Error(name+" failed", slog.Any("error", err))
+		return 1
+	}
+	return 0
+}
+
+// exportCommand writes a backup archive of the database.
+func exportCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.String("o", "-", "archive file to write, or - for standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	backup, err := archive.Export(ctx, database.DB, database.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		if err := archive.Write(os.Stdout, backup); err != nil {
+			return err
+		}
+	} else if err := writeFileAtomically(*output, func(w io.Writer) error { return archive.Write(w, backup) }); err != nil {
+		return err
+	}
+
+	summary := backup.Data.Summarize()
+	logging.FromContext(ctx).Info("export complete",
+		slog.String("checksum", backup.Checksum),
+		slog.Int("organizations", summary.Organizations),
+		slog.Int("users", summary.Users),
+		slog.Int("classes", summary.Classes),
+		slog.Int("class_members", summary.ClassMembers),
+		slog.Int("progress", summary.Progress),
+	)
+	logging.FromContext(ctx).Warn("the archive leaves data out", slog.String("left_out", strings.Join(archive.LeftOut(), ", ")))
+	return nil
+}
+
+// importCommand restores a backup archive into an empty database.
+func importCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only verify the archive and that the database is empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("import takes exactly one archive file (- for standard input)")
+	}
+
+	in := os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	backup, err := archive.Read(in)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.FromContext(ctx)
+	if backup.SchemaVersion != database.SchemaVersion {
+		logger.Warn("archive was taken from a different schema version",
+			slog.Int("archive_schema_version", backup.SchemaVersion),
+			slog.Int("schema_version", database.SchemaVersion),
+		)
+	}
+
+	summary := backup.Data.Summarize()
+	attrs := []any{
+		slog.Time("created_at", backup.CreatedAt),
+		slog.Int("organizations", summary.Organizations),
+		slog.Int("users", summary.Users),
+		slog.Int("classes", summary.Classes),
+		slog.Int("class_members", summary.ClassMembers),
+		slog.Int("progress", summary.Progress),
+	}
+	if *dryRun {
+		if err := archive.Check(ctx, database.DB, backup); err != nil {
+			return err
+		}
+		logger.Info("archive can be restored", attrs...)
+		return nil
+	}
+	if err := archive.Import(ctx, database.DB, backup); err != nil {
+		return err
+	}
+	logger.Info("import complete", attrs...)
+	return nil
+}
+
+// writeFileAtomically writes to a temporary file next to path and renames it
+// into place once complete, so a failed export never leaves a partial archive.
+func writeFileAtomically(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/ratelimit"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ServerPort != "3002" || cfg.LogLevel != "info" || cfg.RateLimitLogin != (ratelimit.Budget{Limit: 10, Window: time.Minute}) {
+					t.Errorf("defaults = port %s, log level %s, login budget %+v", cfg.ServerPort, cfg.LogLevel, cfg.RateLimitLogin)
+				}
+				if cfg.DatabaseURL != devDatabaseURL || !cfg.UsesDevelopmentSecret() {
+					t.Error("development fallbacks were not applied")
+				}
+			},
+		},
+		{
+			name: "file overrides defaults",
+			file: "port: \"8080\"\nlog_level: debug\nrate_limit_login: 5/10m\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ServerPort != "8080" || cfg.LogLevel != "debug" || cfg.RateLimitLogin != (ratelimit.Budget{Limit: 5, Window: 10 * time.Minute}) {
+					t.Errorf("from file = port %s, log level %s, login budget %+v", cfg.ServerPort, cfg.LogLevel, cfg.RateLimitLogin)
+				}
+			},
+		},
+		{
+			name: "environment overrides file",
+			file: "port: \"8080\"\nlog_level: debug\n",
+			env: map[string]string{
+				"PORT":            "9090",
+				"JWT_EXPIRY":      "1h",
+				"CORS_ORIGINS":    "https://a.example, https://*.b.example",
+				"PROXY_HEADER":    "X-Real-IP",
+				"TRUSTED_PROXIES": "10.0.0.0/8, 192.168.1.5",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ServerPort != "9090" || cfg.LogLevel != "debug" || cfg.JWTExpiry != time.Hour {
+					t.Errorf("from env = port %s, log level %s, jwt expiry %s", cfg.ServerPort, cfg.LogLevel, cfg.JWTExpiry)
+				}
+				if len(cfg.CORSOrigins) != 2 || cfg.CORSOrigins[1] != "https://*.b.example" {
+					t.Errorf("cors origins = %v", cfg.CORSOrigins)
+				}
+				if cfg.ProxyHeader != "X-Real-IP" || len(cfg.TrustedProxies) != 2 || cfg.TrustedProxies[1] != "192.168.1.5" {
+					t.Errorf("proxy header %q, trusted proxies %v", cfg.ProxyHeader, cfg.TrustedProxies)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			if tt.file != "" {
+				t.Setenv("CONFIG_FILE", writeConfigFile(t, tt.file))
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{name: "unknown file field", file: "prot: \"8080\"\n", want: "field prot not found"},
+		{name: "bad env duration", env: map[string]string{"JWT_EXPIRY": "soon"}, want: "JWT_EXPIRY"},
+		{name: "bad env budget", env: map[string]string{"RATE_LIMIT_READ": "lots"}, want: "RATE_LIMIT_READ"},
+		{name: "production without secrets", env: map[string]string{"ENV": "production"}, want: "database_url is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			if tt.file != "" {
+				t.Setenv("CONFIG_FILE", writeConfigFile(t, tt.file))
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load = %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		cfg := defaults()
+		cfg.applyDevelopmentFallbacks()
+		return cfg
+	}
+	production := func(cfg *Config) {
+		cfg.Env = EnvProduction
+		cfg.DatabaseURL = "postgres://quran@db/quran"
+		cfg.JWTSecret = strings.Repeat("s", minProductionSecretLength)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(cfg *Config)
+		want   string
+	}{
+		{name: "valid development", mutate: func(*Config) {}},
+		{name: "valid production", mutate: production},
+		{name: "unknown env", mutate: func(cfg *Config) { cfg.Env = "staging" }, want: "env must be"},
+		{name: "bad port", mutate: func(cfg *Config) { cfg.ServerPort = "70000" }, want: "port must be"},
+		{name: "log level typo", mutate: func(cfg *Config) { cfg.LogLevel = "inof" }, want: "log_level"},
+		{name: "log level in capitals", mutate: func(cfg *Config) { cfg.LogLevel = "DEBUG" }},
+		{name: "log format typo", mutate: func(cfg *Config) { cfg.LogFormat = "jsno" }, want: "log_format"},
+		{name: "negative rate limit", mutate: func(cfg *Config) { cfg.RateLimitWrite.Limit = -1 }, want: "rate_limit_write"},
+		{name: "rate limit without window", mutate: func(cfg *Config) { cfg.RateLimitRead.Window = 0 }, want: "rate_limit_read window"},
+		{name: "rate limit window too long", mutate: func(cfg *Config) { cfg.RateLimitLogin.Window = 48 * time.Hour }, want: "rate_limit_login window"},
+		{name: "disabled rate limit", mutate: func(cfg *Config) { cfg.RateLimitRead = ratelimit.Budget{} }},
+		{name: "wildcard origin", mutate: func(cfg *Config) { cfg.CORSOrigins = []string{"*"} }, want: "must not allow every origin"},
+		{
+			name:   "trusted proxies",
+			mutate: func(cfg *Config) { cfg.ProxyHeader = "X-Real-IP"; cfg.TrustedProxies = []string{"10.0.0.0/8", "::1"} },
+		},
+		{name: "proxy header without trusted proxies", mutate: func(cfg *Config) { cfg.ProxyHeader = "X-Real-IP" }, want: "proxy_header requires trusted_proxies"},
+		{name: "proxy header with a colon", mutate: func(cfg *Config) { cfg.ProxyHeader = "X-Real-IP:"; cfg.TrustedProxies = []string{"10.0.0.1"} }, want: "proxy_header must be a header name"},
+		{name: "malformed trusted proxy", mutate: func(cfg *Config) { cfg.TrustedProxies = []string{"10.0.0.0/33"} }, want: "must be an IP address or CIDR range"},
+		{name: "trusting every address", mutate: func(cfg *Config) { cfg.TrustedProxies = []string{"0.0.0.0/0"} }, want: "must not trust every address"},
+		{name: "unknown notification driver", mutate: func(cfg *Config) { cfg.NotificationSMSDriver = "pigeon" }, want: "notification_sms_driver"},
+		{
+			name:   "development secret in production",
+			mutate: func(cfg *Config) { production(cfg); cfg.JWTSecret = devJWTSecret },
+			want:   "non-default value in production",
+		},
+		{
+			name:   "short secret in production",
+			mutate: func(cfg *Config) { production(cfg); cfg.JWTSecret = "short" },
+			want:   "at least 32 characters",
+		},
+		{
+			name:   "development database in production",
+			mutate: func(cfg *Config) { production(cfg); cfg.DatabaseURL = devDatabaseURL },
+			want:   "database_url must be set explicitly",
+		},
+		{
+			name:   "http origin in production",
+			mutate: func(cfg *Config) { production(cfg); cfg.CORSOrigins = []string{"http://quran.example"} },
+			want:   "must use https in production",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate = %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,17 +2,20 @@ package database
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// SchemaVersion is the db.sql schema version this build expects.
+const SchemaVersion = 10
+
 // DB holds the database connection pool.
 var DB *pgxpool.Pool
 
-// Connect initializes the database connection pool.
-func Connect(databaseURL string) {
+// Connect initializes the database connection pool with the given size limits.
+func Connect(databaseURL string, maxConns, minConns int32) {
 	var err error
 	// Disable prepared statement caching to avoid issues with connection poolers like pgbouncer
 	config, err := pgxpool.ParseConfig(databaseURL)
@@ -20,18 +23,32 @@ func Connect(databaseURL string) {
 		log.Fatalf("Unable to parse database URL: %v\n", err)
 	}
 	config.ConnConfig.PreferSimpleProtocol = true
+	config.MaxConns = maxConns
+	config.MinConns = minConns
 
 	DB, err = pgxpool.ConnectConfig(context.Background(), config)
 	if err != nil {
 		log.Fatalf("Unable to connect to database: %v\n", err)
 	}
-	fmt.Println("Successfully connected to the database")
+	slog.Info("successfully connected to the database")
 }
 
 // Close closes the database connection pool.
 func Close() {
 	if DB != nil {
 		DB.Close()
-		fmt.Println("Database connection pool closed.")
+		slog.Info("database connection pool closed")
 	}
 }
+
+// Ping checks that a connection can be acquired from the pool and used.
+func Ping(ctx context.Context) error {
+	return DB.Ping(ctx)
+}
+
+// CurrentSchemaVersion returns the newest schema version applied to the database.
+func CurrentSchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := DB.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
@@ -10,13 +10,6 @@ CREATE TABLE IF NOT EXISTS users (
     progress_page INTEGER
 );
 
--- Insert the developer user if they don't exist, or update their password if they do.
--- This "upsert" command ensures the password is correct without causing errors on re-runs.
-INSERT INTO users (username, password, role) VALUES
-('developer', '$2a$10$Vk5cjiLPNYzNT3UZPfpqJuzHFz0EWC1bHrsao61LiCeTCWy18XdLq', 'developer')
-ON CONFLICT (username) DO UPDATE SET
-password = EXCLUDED.password;
-
 -- Create the classes table if it doesn't already exist
 CREATE TABLE IF NOT EXISTS classes (
     id SERIAL PRIMARY KEY,
@@ -32,3 +25,268 @@ CREATE TABLE IF NOT EXISTS class_members (
 );
 
 -- Create the progress table if it doesn't already exist
+CREATE TABLE IF NOT EXISTS progress (
+    id SERIAL PRIMARY KEY,
+    student_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    surah INTEGER NOT NULL,
+    ayah INTEGER NOT NULL,
+    page INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Older databases created progress without a timestamp
+ALTER TABLE progress ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS idx_progress_student_created_at ON progress (student_id, created_at);
+
+-- Soft deletion: users are flagged with deleted_at instead of being removed, so the
+-- ON DELETE CASCADE rules above only fire once the purge job removes the row for good.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Audit trail of every mutating action. actor_id deliberately has no foreign key so
+-- events survive the actor being purged.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER,
+    actor_role TEXT,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER,
+    changes JSONB NOT NULL DEFAULT '{}'::jsonb,
+    ip TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_id);
+
+-- Schema versions applied to this database. Every change to this file appends a
+-- new version below; the server reports not-ready until it sees the version it expects.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+INSERT INTO schema_migrations (version) VALUES (1) ON CONFLICT DO NOTHING;
+
+-- Fixed-window request counters shared by every instance when RATE_LIMIT_STORE=postgres
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end TIMESTAMPTZ NOT NULL,
+    count INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limits_window_end ON rate_limits (window_end);
+INSERT INTO schema_migrations (version) VALUES (2) ON CONFLICT DO NOTHING;
+
+-- Daily attendance per class member
+CREATE TABLE IF NOT EXISTS attendance (
+    id SERIAL PRIMARY KEY,
+    class_id INTEGER NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    student_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('present', 'absent', 'late', 'excused')),
+    marked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (class_id, student_id, date)
+);
+CREATE INDEX IF NOT EXISTS idx_attendance_class_date ON attendance (class_id, date);
+
+-- Graded recitations heard by a teacher, with the grade out of 100
+CREATE TABLE IF NOT EXISTS recitations (
+    id SERIAL PRIMARY KEY,
+    student_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    class_id INTEGER REFERENCES classes(id) ON DELETE SET NULL,
+    teacher_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    surah INTEGER NOT NULL CHECK (surah BETWEEN 1 AND 114),
+    ayah_from INTEGER NOT NULL,
+    ayah_to INTEGER NOT NULL,
+    grade INTEGER NOT NULL CHECK (grade BETWEEN 0 AND 100),
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_recitations_student_created_at ON recitations (student_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_recitations_class_created_at ON recitations (class_id, created_at);
+INSERT INTO schema_migrations (version) VALUES (3) ON CONFLICT DO NOTHING;
+
+-- Goals set for a single student or a whole class. Memorization goals cover a page
+-- range; reading and revision goals can instead ask for a number of pages per day or week.
+CREATE TABLE IF NOT EXISTS goals (
+    id SERIAL PRIMARY KEY,
+    type TEXT NOT NULL CHECK (type IN ('memorization', 'reading', 'revision')),
+    title TEXT NOT NULL,
+    student_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    class_id INTEGER REFERENCES classes(id) ON DELETE CASCADE,
+    start_page INTEGER CHECK (start_page BETWEEN 1 AND 604),
+    end_page INTEGER CHECK (end_page BETWEEN 1 AND 604),
+    target_pages INTEGER CHECK (target_pages > 0),
+    period TEXT CHECK (period IN ('day', 'week')),
+    start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    deadline DATE NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((student_id IS NULL) <> (class_id IS NULL)),
+    CHECK (
+        (start_page IS NOT NULL AND end_page IS NOT NULL AND start_page <= end_page AND target_pages IS NULL AND period IS NULL)
+        OR (start_page IS NULL AND end_page IS NULL AND target_pages IS NOT NULL AND period IS NOT NULL)
+    ),
+    CHECK (start_date <= deadline)
+);
+CREATE INDEX IF NOT EXISTS idx_goals_student ON goals (student_id);
+CREATE INDEX IF NOT EXISTS idx_goals_class ON goals (class_id);
+INSERT INTO schema_migrations (version) VALUES (4) ON CONFLICT DO NOTHING;
+
+-- Khatmas: complete readings of the mushaf. One opens when a student records page 1
+-- and completes when they reach the last page; restarting at page 1 abandons it.
+CREATE TABLE IF NOT EXISTS khatmas (
+    id SERIAL PRIMARY KEY,
+    student_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed', 'abandoned')),
+    last_page INTEGER NOT NULL DEFAULT 1,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_khatmas_open_student ON khatmas (student_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_khatmas_student_started_at ON khatmas (student_id, started_at);
+INSERT INTO schema_migrations (version) VALUES (5) ON CONFLICT DO NOTHING;
+
+-- Certificates handed out for a completed juz or khatma. Names are copied at issue
+-- time so a certificate still verifies after the student or teacher is renamed or purged.
+CREATE TABLE IF NOT EXISTS certificates (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    student_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    student_name TEXT NOT NULL,
+    teacher_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    teacher_name TEXT NOT NULL,
+    achievement TEXT NOT NULL CHECK (achievement IN ('juz', 'khatma')),
+    juz INTEGER CHECK (juz BETWEEN 1 AND 30),
+    khatma_id INTEGER REFERENCES khatmas(id) ON DELETE SET NULL,
+    template TEXT NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((achievement = 'juz') = (juz IS NOT NULL))
+);
+CREATE INDEX IF NOT EXISTS idx_certificates_student ON certificates (student_id);
+INSERT INTO schema_migrations (version) VALUES (6) ON CONFLICT DO NOTHING;
+
+-- Outbound webhooks. Domain events are written to outbox_events in the same
+-- transaction as the change they describe; the dispatcher fans each one out to a
+-- delivery per matching subscription and retries failed deliveries with backoff.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_undispatched ON outbox_events (id) WHERE dispatched_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events (created_at);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    description TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    last_status_code INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subscription_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at DESC);
+INSERT INTO schema_migrations (version) VALUES (7) ON CONFLICT DO NOTHING;
+
+-- Notifications. Each user picks the channels and language they are notified
+-- in; messages are rendered when queued and sent by the notification dispatcher.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    language TEXT NOT NULL DEFAULT 'ar' CHECK (language IN ('ar', 'en')),
+    channels TEXT[] NOT NULL DEFAULT '{sms}',
+    email TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel TEXT NOT NULL CHECK (channel IN ('sms', 'email', 'whatsapp')),
+    recipient TEXT NOT NULL,
+    template TEXT NOT NULL,
+    language TEXT NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications (created_at DESC);
+INSERT INTO schema_migrations (version) VALUES (8) ON CONFLICT DO NOTHING;
+
+-- Organizations. Several mosques or schools share one deployment; users, classes
+-- and progress belong to one organization, and usernames are only unique within
+-- it. Existing data moves to the default organization, which also holds the
+-- developer account.
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+INSERT INTO organizations (id, slug, name) VALUES (1, 'default', 'Default') ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('organizations', 'id'), (SELECT MAX(id) FROM organizations));
+
+-- The defaults only backfill existing rows; new rows must name their organization.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE users ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_organization_active_username ON users (organization_id, username) WHERE deleted_at IS NULL;
+
+ALTER TABLE classes ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE classes ALTER COLUMN organization_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_classes_organization ON classes (organization_id);
+
+ALTER TABLE progress ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE progress ALTER COLUMN organization_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_progress_organization_created_at ON progress (organization_id, created_at);
+
+-- Admin-facing logs are per organization too. Audit events of background jobs
+-- and outbox events raised outside an organization have none.
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS organization_id INTEGER DEFAULT 1;
+ALTER TABLE audit_events ALTER COLUMN organization_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_audit_events_organization ON audit_events (organization_id, created_at DESC);
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS organization_id INTEGER DEFAULT 1 REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE outbox_events ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE webhook_subscriptions ALTER COLUMN organization_id DROP DEFAULT;
+
+INSERT INTO schema_migrations (version) VALUES (9) ON CONFLICT DO NOTHING;
+
+-- Soft-deleted users release their username, so it can be given to a new
+-- account; restoring them fails while it is taken.
+DROP INDEX IF EXISTS idx_users_organization_username;
+INSERT INTO schema_migrations (version) VALUES (10) ON CONFLICT DO NOTHING;
+
+-- Insert the developer user if they don't exist, or update their password if they do.
+-- This "upsert" command ensures the password is correct without causing errors on re-runs.
+INSERT INTO users (organization_id, username, password, role) VALUES
+(1, 'developer', '$2a$10$Vk5cjiLPNYzNT3UZPfpqJuzHFz0EWC1bHrsao61LiCeTCWy18XdLq', 'developer')
+ON CONFLICT (organization_id, username) WHERE deleted_at IS NULL DO UPDATE SET
+password = EXCLUDED.password;
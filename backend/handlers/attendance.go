@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// AttendanceHandler holds the attendance service.
+type AttendanceHandler struct {
+	service services.AttendanceService
+}
+
+// NewAttendanceHandler creates a new AttendanceHandler.
+func NewAttendanceHandler(service services.AttendanceService) *AttendanceHandler {
+	return &AttendanceHandler{service: service}
+}
+
+// MarkAttendance handles the request to mark a class's attendance for a day.
+// The body carries an optional date (YYYY-MM-DD, today by default) and the
+// records to save.
+func (h *AttendanceHandler) MarkAttendance(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+
+	var sheet models.AttendanceSheet
+	if err := c.BodyParser(&sheet); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+	sheet.ClassID = classID
+
+	if err := h.service.MarkAttendance(c.UserContext(), user.ID, user.Role, &sheet); err != nil {
+		return attendanceError(c, err, "failed to mark attendance")
+	}
+	return c.JSON(sheet)
+}
+
+// GetAttendance handles the request for a class's attendance on the day given
+// by the date query parameter, today by default.
+func (h *AttendanceHandler) GetAttendance(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+
+	sheet, err := h.service.GetAttendance(c.UserContext(), user.ID, user.Role, classID, c.Query("date"))
+	if err != nil {
+		return attendanceError(c, err, "failed to get attendance")
+	}
+	return c.JSON(sheet)
+}
+
+// attendanceError maps attendance service errors to responses; anything
+// unexpected is reported with the given message.
+func attendanceError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidAttendance):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to this class"})
+	case errors.Is(err, repository.ErrClassNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "class not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
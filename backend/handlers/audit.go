@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// AuditHandler holds the audit service.
+type AuditHandler struct {
+	service services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(service services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetEvents handles the request to list audit events. It accepts the optional
+// filters actor_id, action, entity_type, entity_id, from and to (RFC 3339),
+// plus limit and offset for paging.
+func (h *AuditHandler) GetEvents(c *fiber.Ctx) error {
+	filter := models.AuditFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		Limit:      c.QueryInt("limit", defaultAuditLimit),
+		Offset:     c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid actor_id"})
+		}
+		filter.ActorID = &id
+	}
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid entity_id"})
+		}
+		filter.EntityID = &id
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid from timestamp"})
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid to timestamp"})
+		}
+		filter.To = &to
+	}
+
+	events, err := h.service.GetEvents(c.UserContext(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get audit events"})
+	}
+	return c.JSON(events)
+}
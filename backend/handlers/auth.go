@@ -1,67 +1,74 @@
 package handlers
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/metrics"
 	"github.com/kolind-am/quran-project/backend/models"
 	"github.com/kolind-am/quran-project/backend/repository"
 	"golang.org/x/crypto/bcrypt"
 )
 
-const (
-	bcryptCost = 10
-	jwtExpiry  = 72 * time.Hour
-)
-
 // AuthHandler holds dependencies for authentication.
 type AuthHandler struct {
 	userRepo  repository.UserRepository
-	jwtSecret string
+	keys      *auth.KeySet
+	jwtExpiry time.Duration
 }
 
 // NewAuthHandler creates a new AuthHandler.
-func NewAuthHandler(userRepo repository.UserRepository, jwtSecret string) *AuthHandler {
-	return &AuthHandler{userRepo: userRepo, jwtSecret: jwtSecret}
+func NewAuthHandler(userRepo repository.UserRepository, keys *auth.KeySet, jwtExpiry time.Duration) *AuthHandler {
+	return &AuthHandler{userRepo: userRepo, keys: keys, jwtExpiry: jwtExpiry}
 }
 
-// Login handles user authentication.
+// Login handles user authentication. Users log in to the organization named
+// by the subdomain or X-Organization header, or the default one without it.
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	var req models.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
 	}
 
-	log.Printf("Login attempt for user: %s", req.Username)
+	ctx := c.UserContext()
+	organizationID, ok := repository.TenantFromContext(ctx)
+	if !ok {
+		organizationID = repository.DefaultOrganizationID
+		ctx = repository.WithTenant(ctx, organizationID)
+	}
+	logger := logging.FromContext(ctx).With(slog.Int("organization_id", organizationID))
 
-	user, err := h.userRepo.FindUserByUsername(c.Context(), req.Username)
+	user, err := h.userRepo.FindUserByUsername(ctx, req.Username)
 	if err != nil {
-		log.Printf("User lookup failed for '%s': %v", req.Username, err)
+		logger.Info("login failed", slog.String("reason", "user lookup failed"), slog.Any("error", err))
+		metrics.LoginAttempts.WithLabelValues("failure", "unknown_user").Inc()
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid credentials"})
 	}
-	log.Printf("User '%s' found in database.", req.Username)
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		log.Printf("Password comparison failed for user '%s': %v", req.Username, err)
+		logger.Info("login failed", slog.String("reason", "password mismatch"), slog.Int("user_id", user.ID))
+		metrics.LoginAttempts.WithLabelValues("failure", "bad_password").Inc()
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid credentials"})
 	}
-	log.Printf("Password for user '%s' is correct.", req.Username)
 
-	claims := jwt.MapClaims{
-		"id":   user.ID,
-		"role": user.Role,
-		"exp":  time.Now().Add(jwtExpiry).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString([]byte(h.jwtSecret))
+	t, err := h.keys.Sign(auth.NewClaims(user.ID, organizationID, user.Role, h.jwtExpiry))
 	if err != nil {
-		log.Printf("Token generation failed for user '%s': %v", req.Username, err)
+		logger.Error("token generation failed", slog.Int("user_id", user.ID), slog.Any("error", err))
+		metrics.LoginAttempts.WithLabelValues("failure", "token_error").Inc()
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not log in"})
 	}
-	log.Printf("Token generated successfully for user '%s'.", req.Username)
+	logger.Info("login succeeded", slog.Int("user_id", user.ID), slog.String("role", user.Role))
+	metrics.LoginAttempts.WithLabelValues("success", "").Inc()
 
 	return c.JSON(fiber.Map{"token": t})
 }
+
+// JWKS serves the public keys that verify our tokens, so other services can
+// check them without holding any signing secret.
+func (h *AuthHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.keys.JWKS())
+}
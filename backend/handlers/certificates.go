@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// CertificateHandler holds the certificate service.
+type CertificateHandler struct {
+	service services.CertificateService
+}
+
+// NewCertificateHandler creates a new CertificateHandler.
+func NewCertificateHandler(service services.CertificateService) *CertificateHandler {
+	return &CertificateHandler{service: service}
+}
+
+// IssueCertificate handles the request to issue a certificate to a student.
+func (h *CertificateHandler) IssueCertificate(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CertificateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+
+	certificate, err := h.service.IssueCertificate(c.UserContext(), user.ID, user.Role, &req)
+	if err != nil {
+		return certificateError(c, err, "failed to issue certificate")
+	}
+	return c.Status(fiber.StatusCreated).JSON(certificate)
+}
+
+// VerifyCertificate handles the public request to check a verification code.
+func (h *CertificateHandler) VerifyCertificate(c *fiber.Ctx) error {
+	verification, err := h.service.VerifyCertificate(c.UserContext(), c.Params("code"))
+	if err != nil {
+		return certificateError(c, err, "failed to verify certificate")
+	}
+	return c.JSON(verification)
+}
+
+// DownloadCertificate handles the request for a certificate as a PDF.
+func (h *CertificateHandler) DownloadCertificate(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	certificate, err := h.service.RenderCertificate(c.UserContext(), user.ID, user.Role, c.Params("code"), &buf)
+	if err != nil {
+		return certificateError(c, err, "failed to render certificate")
+	}
+
+	c.Attachment("certificate-" + certificate.Code + ".pdf")
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	return c.Send(buf.Bytes())
+}
+
+// GetStudentCertificates handles the request for a student's certificates.
+func (h *CertificateHandler) GetStudentCertificates(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	studentID := user.ID
+	if param := c.Params("studentId"); param != "" {
+		studentID, err = strconv.Atoi(param)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid student ID"})
+		}
+	}
+
+	certificates, err := h.service.GetStudentCertificates(c.UserContext(), user.ID, user.Role, studentID)
+	if err != nil {
+		return certificateError(c, err, "failed to get certificates")
+	}
+	return c.JSON(certificates)
+}
+
+func certificateError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCertificate):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to this certificate"})
+	case errors.Is(err, repository.ErrCertificateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "certificate not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
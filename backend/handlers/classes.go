@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// ClassHandler holds the class service.
+type ClassHandler struct {
+	service services.ClassService
+}
+
+// NewClassHandler creates a new ClassHandler.
+func NewClassHandler(service services.ClassService) *ClassHandler {
+	return &ClassHandler{service: service}
+}
+
+// AddMember handles the request to enroll a student, given as
+// {"student_id": ...}, in a class.
+func (h *ClassHandler) AddMember(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+	var body struct {
+		StudentID int `json:"student_id"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+
+	if err := h.service.AddMember(c.UserContext(), user.ID, user.Role, classID, body.StudentID); err != nil {
+		return classError(c, err, "failed to add class member")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RemoveMember handles the request to take a student out of a class.
+func (h *ClassHandler) RemoveMember(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+	studentID, err := strconv.Atoi(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid student ID"})
+	}
+
+	if err := h.service.RemoveMember(c.UserContext(), user.ID, user.Role, classID, studentID); err != nil {
+		return classError(c, err, "failed to remove class member")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// classError maps class service errors to responses; anything unexpected is
+// reported with the given message.
+func classError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to this class"})
+	case errors.Is(err, repository.ErrClassNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "class not found"})
+	case errors.Is(err, repository.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "student not found"})
+	case errors.Is(err, repository.ErrClassMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "student is not in the class"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/auth"
+)
+
+// errNoCurrentUser is returned by handlers reached without an authenticated user,
+// which only happens if a route is registered outside the protected group.
+var errNoCurrentUser = fiber.NewError(fiber.StatusUnauthorized, "authentication required")
+
+// currentUser returns the authenticated user stored by middleware.Protected.
+func currentUser(c *fiber.Ctx) (auth.CurrentUser, error) {
+	user, ok := auth.FromContext(c.UserContext())
+	if !ok {
+		return auth.CurrentUser{}, errNoCurrentUser
+	}
+	return user, nil
+}
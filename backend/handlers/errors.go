@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/logging"
+)
+
+// ErrorHandler turns errors returned from handlers into the JSON error shape
+// used across the API. Unexpected errors are logged and hidden from the client.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{"error": fiberErr.Message})
+	}
+
+	logging.FromContext(c.UserContext()).Error("unhandled error", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+}
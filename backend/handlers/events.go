@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/realtime"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// heartbeatInterval is how often an idle event stream sends a comment so that
+// proxies keep the connection open.
+const heartbeatInterval = 25 * time.Second
+
+// EventsHandler holds the live service.
+type EventsHandler struct {
+	service services.LiveService
+}
+
+// NewEventsHandler creates a new EventsHandler.
+func NewEventsHandler(service services.LiveService) *EventsHandler {
+	return &EventsHandler{service: service}
+}
+
+// Stream handles the request for a server-sent event stream of class events.
+// The classes to follow are given as repeated class_id parameters; teachers
+// and students follow all of their classes when none are given. The stream
+// ends when the client disconnects, falls too far behind or the server shuts
+// down, and clients are expected to reconnect.
+func (h *EventsHandler) Stream(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var classIDs []int
+	for _, value := range c.Context().QueryArgs().PeekMulti("class_id") {
+		id, err := strconv.Atoi(string(value))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+		}
+		classIDs = append(classIDs, id)
+	}
+
+	sub, err := h.service.Subscribe(c.UserContext(), user.ID, user.Role, classIDs)
+	switch {
+	case errors.Is(err, services.ErrInvalidSubscription):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to this class"})
+	case errors.Is(err, repository.ErrClassNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "class not found"})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to subscribe to events"})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		// A write error means the client went away.
+		if realtime.WriteHeartbeat(w) != nil || w.Flush() != nil {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if realtime.WriteSSE(w, event) != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if realtime.WriteHeartbeat(w) != nil {
+					return
+				}
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/middleware"
+	"github.com/kolind-am/quran-project/backend/ratelimit"
+	"github.com/kolind-am/quran-project/backend/realtime"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// activeUsers treats every user as an active teacher.
+type activeUsers struct{}
+
+func (activeUsers) FindActiveRole(context.Context, int) (string, error) { return "teacher", nil }
+
+// teacherClasses answers class lookups for teacher 20, who teaches class 3 in
+// organization 1. Lookups outside that organization fail, so a stream that
+// skipped the tenant middleware cannot subscribe.
+type teacherClasses struct {
+	repository.ClassRepository
+}
+
+func (teacherClasses) FindTeacherClassIDs(ctx context.Context, teacherID int) ([]int, error) {
+	if organizationID, ok := repository.TenantFromContext(ctx); !ok || organizationID != 1 {
+		return nil, errors.New("class lookup is not scoped to organization 1")
+	}
+	if teacherID != 20 {
+		return []int{}, nil
+	}
+	return []int{3}, nil
+}
+
+// newEventsApp serves /api/events behind the same middleware chain as the
+// real routes, on a real listener so that the stream can be read while open.
+func newEventsApp(t *testing.T, live services.LiveService, keys *auth.KeySet) string {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	budget := ratelimit.Budget{Limit: 100, Window: time.Minute}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler, DisableStartupMessage: true})
+	app.Use(middleware.RequestID())
+	app.Use(middleware.RequestLogger(logger))
+	app.Use(middleware.AccessLog())
+	app.Use(middleware.Metrics())
+	protected := app.Group("/api",
+		middleware.Protected(keys, activeUsers{}),
+		middleware.Tenant(nil, ""),
+		middleware.Actor(),
+		middleware.RateLimitByMethod(ratelimit.NewMemoryStore(), budget, budget),
+	)
+	protected.Get("/events", NewEventsHandler(live).Stream)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return "http://" + ln.Addr().String()
+}
+
+func TestEventsStream(t *testing.T) {
+	keys, err := auth.NewKeySet(auth.KeySetConfig{Secret: "test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := realtime.NewMemoryBroker()
+	defer broker.Close()
+	live := services.NewLiveService(broker, teacherClasses{})
+	baseURL := newEventsApp(t, live, keys)
+
+	token, err := keys.Sign(auth.NewClaims(20, 1, "teacher", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get(fiber.HeaderContentType); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+
+	// The subscription exists once the headers are out; an event for another
+	// class must not reach the stream, the teacher's own class's must.
+	live.PublishClassEvent(context.Background(), 4, services.LiveAttendanceMarked, map[string]string{"date": "2025-03-01"})
+	live.PublishClassEvent(context.Background(), 3, services.LiveAttendanceMarked, map[string]string{"date": "2025-03-02"})
+
+	events := make(chan realtime.Event, 1)
+	failures := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				failures <- err
+				return
+			}
+			if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+				var event realtime.Event
+				if err := json.Unmarshal([]byte(data), &event); err != nil {
+					failures <- err
+					return
+				}
+				events <- event
+				return
+			}
+		}
+	}()
+
+	select {
+	case event := <-events:
+		if event.ClassID != 3 || event.Type != services.LiveAttendanceMarked {
+			t.Errorf("event = %+v, want attendance.marked for class 3", event)
+		}
+		if !strings.Contains(string(event.Data), "2025-03-02") {
+			t.Errorf("event data = %s", event.Data)
+		}
+	case err := <-failures:
+		t.Fatalf("stream ended before the event arrived: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event arrived on the stream")
+	}
+}
+
+func TestEventsStreamRejectsForeignTokens(t *testing.T) {
+	keys, err := auth.NewKeySet(auth.KeySetConfig{Secret: "test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := auth.NewKeySet(auth.KeySetConfig{Secret: "another-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := realtime.NewMemoryBroker()
+	defer broker.Close()
+	baseURL := newEventsApp(t, services.NewLiveService(broker, teacherClasses{}), keys)
+
+	token, err := other.Sign(auth.NewClaims(20, 1, "teacher", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
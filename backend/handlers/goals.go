@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// GoalHandler holds the goal service.
+type GoalHandler struct {
+	service services.GoalService
+}
+
+// NewGoalHandler creates a new GoalHandler.
+func NewGoalHandler(service services.GoalService) *GoalHandler {
+	return &GoalHandler{service: service}
+}
+
+// CreateGoal handles the request to create a goal for a student or a class.
+func (h *GoalHandler) CreateGoal(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var goal models.Goal
+	if err := c.BodyParser(&goal); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+
+	if err := h.service.CreateGoal(c.UserContext(), user.ID, user.Role, &goal); err != nil {
+		return goalError(c, err, "failed to create goal")
+	}
+	return c.Status(fiber.StatusCreated).JSON(goal)
+}
+
+// GetGoal handles the request for a goal and its progress.
+func (h *GoalHandler) GetGoal(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Params("goalId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid goal ID"})
+	}
+
+	report, err := h.service.GetGoal(c.UserContext(), user.ID, user.Role, id)
+	if err != nil {
+		return goalError(c, err, "failed to get goal")
+	}
+	return c.JSON(report)
+}
+
+// DeleteGoal handles the request to delete a goal.
+func (h *GoalHandler) DeleteGoal(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Params("goalId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid goal ID"})
+	}
+
+	if err := h.service.DeleteGoal(c.UserContext(), user.ID, user.Role, id); err != nil {
+		return goalError(c, err, "failed to delete goal")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetMyGoals handles the request for the authenticated student's goals.
+func (h *GoalHandler) GetMyGoals(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	reports, err := h.service.GetStudentGoals(c.UserContext(), user.ID, user.Role, user.ID)
+	if err != nil {
+		return goalError(c, err, "failed to get goals")
+	}
+	return c.JSON(reports)
+}
+
+// GetStudentGoals handles the request for a student's goals.
+func (h *GoalHandler) GetStudentGoals(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	studentID, err := strconv.Atoi(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid student ID"})
+	}
+
+	reports, err := h.service.GetStudentGoals(c.UserContext(), user.ID, user.Role, studentID)
+	if err != nil {
+		return goalError(c, err, "failed to get goals")
+	}
+	return c.JSON(reports)
+}
+
+// GetClassGoals handles the request for a class's goals.
+func (h *GoalHandler) GetClassGoals(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+
+	reports, err := h.service.GetClassGoals(c.UserContext(), user.ID, user.Role, classID)
+	if err != nil {
+		return goalError(c, err, "failed to get goals")
+	}
+	return c.JSON(reports)
+}
+
+// goalError maps goal service errors to responses; anything unexpected is
+// reported with the given message.
+func goalError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidGoal):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to this goal"})
+	case errors.Is(err, repository.ErrGoalNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "goal not found"})
+	case errors.Is(err, repository.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "student not found"})
+	case errors.Is(err, repository.ErrClassNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "class not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
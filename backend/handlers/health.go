@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/database"
+)
+
+// readinessTimeout bounds the dependency checks run by Readiness.
+const readinessTimeout = 2 * time.Second
+
+// HealthHandler serves the liveness and readiness probes.
+type HealthHandler struct {
+	ready atomic.Bool
+}
+
+// NewHealthHandler creates a new HealthHandler. It reports not-ready until
+// SetReady(true) is called.
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+// SetReady marks the server as ready or not to receive traffic, e.g. false
+// once shutdown has begun.
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Liveness reports that the process is up and serving requests.
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// Readiness reports whether the server can take traffic: it is not shutting
+// down, the database answers a ping and its schema is at the expected version.
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	if !h.ready.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "shutting_down"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), readinessTimeout)
+	defer cancel()
+
+	ok := true
+	checks := fiber.Map{}
+
+	start := time.Now()
+	if err := database.Ping(ctx); err != nil {
+		ok = false
+		checks["database"] = fiber.Map{"status": "error", "error": err.Error()}
+	} else {
+		checks["database"] = fiber.Map{"status": "ok", "latency_ms": float64(time.Since(start).Microseconds()) / 1000}
+	}
+
+	version, err := database.CurrentSchemaVersion(ctx)
+	switch {
+	case err != nil:
+		ok = false
+		checks["migrations"] = fiber.Map{"status": "error", "error": err.Error()}
+	case version < database.SchemaVersion:
+		ok = false
+		checks["migrations"] = fiber.Map{"status": "outdated", "current": version, "expected": database.SchemaVersion}
+	default:
+		checks["migrations"] = fiber.Map{"status": "ok", "current": version, "expected": database.SchemaVersion}
+	}
+
+	if !ok {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "error", "checks": checks})
+	}
+	return c.JSON(fiber.Map{"status": "ok", "checks": checks})
+}
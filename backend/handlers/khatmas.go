@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// KhatmaHandler holds the khatma service.
+type KhatmaHandler struct {
+	service services.KhatmaService
+}
+
+// NewKhatmaHandler creates a new KhatmaHandler.
+func NewKhatmaHandler(service services.KhatmaService) *KhatmaHandler {
+	return &KhatmaHandler{service: service}
+}
+
+// GetMyKhatmas handles the request for the authenticated student's khatmas.
+func (h *KhatmaHandler) GetMyKhatmas(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	khatmas, err := h.service.GetStudentKhatmas(c.UserContext(), user.ID, user.Role, user.ID)
+	if err != nil {
+		return khatmaError(c, err)
+	}
+	return c.JSON(khatmas)
+}
+
+// GetStudentKhatmas handles the request for a student's khatmas.
+func (h *KhatmaHandler) GetStudentKhatmas(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	studentID, err := strconv.Atoi(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid student ID"})
+	}
+
+	khatmas, err := h.service.GetStudentKhatmas(c.UserContext(), user.ID, user.Role, studentID)
+	if err != nil {
+		return khatmaError(c, err)
+	}
+	return c.JSON(khatmas)
+}
+
+// GetClassKhatmas handles the request for the khatmas completed in a class.
+func (h *KhatmaHandler) GetClassKhatmas(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+
+	khatmas, err := h.service.GetClassKhatmas(c.UserContext(), user.ID, user.Role, classID)
+	if err != nil {
+		return khatmaError(c, err)
+	}
+	return c.JSON(khatmas)
+}
+
+func khatmaError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrForbidden) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to these khatmas"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get khatmas"})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 500
+)
+
+// NotificationHandler holds the notification service.
+type NotificationHandler struct {
+	service services.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler.
+func NewNotificationHandler(service services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetMyPreferences handles the request for the authenticated user's
+// notification preferences.
+func (h *NotificationHandler) GetMyPreferences(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	return h.getPreferences(c, user.ID)
+}
+
+// UpdateMyPreferences handles the request to change the authenticated user's
+// notification preferences.
+func (h *NotificationHandler) UpdateMyPreferences(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	return h.updatePreferences(c, user.ID)
+}
+
+// GetPreferences handles the request for a user's notification preferences.
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+	return h.getPreferences(c, id)
+}
+
+// UpdatePreferences handles the request to change a user's notification preferences.
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+	return h.updatePreferences(c, id)
+}
+
+// GetNotifications handles the request to list queued notifications, newest
+// first. It accepts an optional status filter plus limit and offset for paging.
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	status := c.Query("status")
+	if status != "" && status != "pending" && status != "sent" && status != "failed" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be pending, sent or failed"})
+	}
+	limit := c.QueryInt("limit", defaultNotificationLimit)
+	if limit <= 0 || limit > maxNotificationLimit {
+		limit = defaultNotificationLimit
+	}
+	offset := max(c.QueryInt("offset", 0), 0)
+
+	notifications, err := h.service.GetNotifications(c.UserContext(), status, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get notifications"})
+	}
+	return c.JSON(notifications)
+}
+
+func (h *NotificationHandler) getPreferences(c *fiber.Ctx, userID int) error {
+	preferences, err := h.service.GetPreferences(c.UserContext(), userID)
+	if err != nil {
+		return notificationError(c, err, "failed to get notification preferences")
+	}
+	return c.JSON(preferences)
+}
+
+func (h *NotificationHandler) updatePreferences(c *fiber.Ctx, userID int) error {
+	var preferences models.NotificationPreferences
+	if err := c.BodyParser(&preferences); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+	preferences.UserID = userID
+
+	if err := h.service.UpdatePreferences(c.UserContext(), &preferences); err != nil {
+		return notificationError(c, err, "failed to update notification preferences")
+	}
+	return c.JSON(preferences)
+}
+
+// notificationError maps notification service errors to responses; anything
+// unexpected is reported with the given message.
+func notificationError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPreferences):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repository.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// OrganizationHandler holds the organization service.
+type OrganizationHandler struct {
+	service services.OrganizationService
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler.
+func NewOrganizationHandler(service services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{service: service}
+}
+
+// GetOrganizations handles the request to list the hosted organizations.
+func (h *OrganizationHandler) GetOrganizations(c *fiber.Ctx) error {
+	organizations, err := h.service.GetOrganizations(c.UserContext())
+	if err != nil {
+		return organizationError(c, err, "failed to get organizations")
+	}
+	return c.JSON(organizations)
+}
+
+// CreateOrganization handles the request to host a new organization.
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	var organization models.Organization
+	if err := c.BodyParser(&organization); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+
+	if err := h.service.CreateOrganization(c.UserContext(), &organization); err != nil {
+		return organizationError(c, err, "failed to create organization")
+	}
+	return c.Status(fiber.StatusCreated).JSON(organization)
+}
+
+// organizationError maps organization service errors to responses; anything
+// unexpected is reported with the given message.
+func organizationError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidOrganization):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repository.ErrOrganizationExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "organization already exists"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// RecitationHandler holds the recitation service.
+type RecitationHandler struct {
+	service services.RecitationService
+}
+
+// NewRecitationHandler creates a new RecitationHandler.
+func NewRecitationHandler(service services.RecitationService) *RecitationHandler {
+	return &RecitationHandler{service: service}
+}
+
+// RecordRecitation handles the request to grade a class member's recitation.
+// The body carries the student_id, surah, ayah_from, ayah_to, grade and
+// optional notes.
+func (h *RecitationHandler) RecordRecitation(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+
+	var recitation models.Recitation
+	if err := c.BodyParser(&recitation); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+	recitation.ClassID = &classID
+
+	if err := h.service.RecordRecitation(c.UserContext(), user.ID, user.Role, &recitation); err != nil {
+		return recitationError(c, err, "failed to record recitation")
+	}
+	return c.Status(fiber.StatusCreated).JSON(recitation)
+}
+
+// recitationError maps recitation service errors to responses; anything
+// unexpected is reported with the given message.
+func recitationError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidRecitation):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to this class"})
+	case errors.Is(err, repository.ErrClassNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "class not found"})
+	case errors.Is(err, repository.ErrClassMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "student is not in the class"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
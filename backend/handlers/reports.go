@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/pdf"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+	"github.com/kolind-am/quran-project/backend/spreadsheet"
+)
+
+// reportFormatPDF is the printable report format, next to the spreadsheet formats.
+const reportFormatPDF = "pdf"
+
+// defaultReportDays is how many days, ending today, a report covers by default.
+const defaultReportDays = 7
+
+// ReportHandler holds the report service.
+type ReportHandler struct {
+	service services.ReportService
+}
+
+// NewReportHandler creates a new ReportHandler.
+func NewReportHandler(service services.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+type reportFunc func(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (*services.Report, error)
+
+// GetClassProgressReport handles the request for a class's progress report.
+func (h *ReportHandler) GetClassProgressReport(c *fiber.Ctx) error {
+	return h.serve(c, h.service.ClassProgressReport)
+}
+
+// GetAttendanceReport handles the request for a class's attendance report.
+func (h *ReportHandler) GetAttendanceReport(c *fiber.Ctx) error {
+	return h.serve(c, h.service.AttendanceReport)
+}
+
+// GetRecitationReport handles the request for a class's recitation grades.
+func (h *ReportHandler) GetRecitationReport(c *fiber.Ctx) error {
+	return h.serve(c, h.service.RecitationReport)
+}
+
+// serve renders a report in the requested format (csv, xlsx or pdf) over the
+// inclusive period given by from and to (YYYY-MM-DD), which defaults to the last
+// week. The body is streamed as rows are read, so a failure part way through can
+// only be logged and shows up as a truncated file.
+func (h *ReportHandler) serve(c *fiber.Ctx, build reportFunc) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	classID, err := strconv.Atoi(c.Params("classId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid class ID"})
+	}
+
+	format := c.Query("format", spreadsheet.CSV)
+	if format != spreadsheet.CSV && format != spreadsheet.XLSX && format != reportFormatPDF {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be csv, xlsx or pdf"})
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, err := queryDate(c, "to", today)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be a date in YYYY-MM-DD format"})
+	}
+	from, err := queryDate(c, "from", to.AddDate(0, 0, 1-defaultReportDays))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be a date in YYYY-MM-DD format"})
+	}
+
+	report, err := build(c.UserContext(), user.ID, user.Role, classID, from, to)
+	switch {
+	case errors.Is(err, services.ErrInvalidReport):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you do not have access to this class"})
+	case errors.Is(err, repository.ErrClassNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "class not found"})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to build report"})
+	}
+
+	contentType := "application/pdf"
+	if format != reportFormatPDF {
+		contentType = spreadsheet.ContentType(format)
+	}
+	c.Attachment(report.Name + "." + format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	// The stream outlives the handler, so it keeps the request's values (logger,
+	// current user) but not its cancellation.
+	ctx := context.WithoutCancel(c.UserContext())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := writeReport(ctx, w, format, report); err != nil {
+			logging.FromContext(ctx).Error("writing report failed", slog.String("report", report.Name), slog.Any("error", err))
+		}
+	})
+	return nil
+}
+
+func writeReport(ctx context.Context, w *bufio.Writer, format string, report *services.Report) error {
+	var rows spreadsheet.RowWriter
+	var err error
+	if format == reportFormatPDF {
+		rows, err = pdf.NewTable(w, pdf.TableOptions{
+			Title:       report.Title,
+			Subtitle:    report.Subtitle,
+			Columns:     report.Columns,
+			Widths:      report.Widths,
+			RightToLeft: true,
+			Landscape:   len(report.Columns) > 6,
+			GeneratedAt: time.Now(),
+		})
+	} else {
+		rows, err = spreadsheet.NewWriter(w, format)
+		if err == nil {
+			err = rows.WriteRow(report.Columns)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := report.Rows(ctx, rows.WriteRow); err != nil {
+		rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// queryDate parses a YYYY-MM-DD query parameter, returning fallback when it is absent.
+func queryDate(c *fiber.Ctx, key string, fallback time.Time) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
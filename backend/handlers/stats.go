@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// StatsHandler holds the stats service.
+type StatsHandler struct {
+	service services.StatsService
+}
+
+// NewStatsHandler creates a new StatsHandler.
+func NewStatsHandler(service services.StatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+// GetAdminStats handles the request for the school-wide overview.
+func (h *StatsHandler) GetAdminStats(c *fiber.Ctx) error {
+	stats, err := h.service.GetAdminStats(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get stats"})
+	}
+	return c.JSON(stats)
+}
@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/kolind-am/quran-project/backend/models"
 	"github.com/kolind-am/quran-project/backend/services"
 )
 
+const (
+	defaultProgressLimit = 100
+	maxProgressLimit     = 1000
+)
+
 // StudentHandler holds the student service.
 type StudentHandler struct {
 	service services.StudentService
@@ -18,13 +25,117 @@ func NewStudentHandler(service services.StudentService) *StudentHandler {
 
 // GetMyData handles the request to get the authenticated student's data.
 func (h *StudentHandler) GetMyData(c *fiber.Ctx) error {
-	user := c.Locals("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
-	id := int(claims["id"].(float64))
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
 
-	studentData, err := h.service.GetStudentData(c.Context(), id)
+	studentData, err := h.service.GetStudentData(c.UserContext(), user.ID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get student data"})
 	}
 	return c.JSON(studentData)
 }
+
+// GetMyProgress handles the request for the authenticated student's progress timeline.
+func (h *StudentHandler) GetMyProgress(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	return h.progress(c, user.ID)
+}
+
+// GetMyProgressAnalytics handles the request for the authenticated student's progress analytics.
+func (h *StudentHandler) GetMyProgressAnalytics(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	return h.analytics(c, user.ID)
+}
+
+// GetProgress handles the request for a student's progress timeline. Teachers
+// may only view students in their classes.
+func (h *StudentHandler) GetProgress(c *fiber.Ctx) error {
+	studentID, err := h.authorizeStudent(c)
+	if err != nil {
+		return err
+	}
+	return h.progress(c, studentID)
+}
+
+// GetProgressAnalytics handles the request for a student's progress analytics.
+// Teachers may only view students in their classes.
+func (h *StudentHandler) GetProgressAnalytics(c *fiber.Ctx) error {
+	studentID, err := h.authorizeStudent(c)
+	if err != nil {
+		return err
+	}
+	return h.analytics(c, studentID)
+}
+
+// authorizeStudent parses the studentId parameter and checks the current user
+// may view that student.
+func (h *StudentHandler) authorizeStudent(c *fiber.Ctx) (int, error) {
+	user, err := currentUser(c)
+	if err != nil {
+		return 0, err
+	}
+	studentID, err := c.ParamsInt("studentId")
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid student ID")
+	}
+
+	allowed, err := h.service.CanViewStudent(c.UserContext(), user.ID, user.Role, studentID)
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusInternalServerError, "failed to check access")
+	}
+	if !allowed {
+		return 0, fiber.NewError(fiber.StatusForbidden, "you do not have access to this student")
+	}
+	return studentID, nil
+}
+
+// progress lists a student's progress entries. It accepts the optional filters
+// from and to (RFC 3339), plus limit and offset for paging.
+func (h *StudentHandler) progress(c *fiber.Ctx, studentID int) error {
+	filter := models.ProgressFilter{
+		Limit:  c.QueryInt("limit", defaultProgressLimit),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit <= 0 || filter.Limit > maxProgressLimit {
+		filter.Limit = defaultProgressLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid from timestamp"})
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid to timestamp"})
+		}
+		filter.To = &to
+	}
+
+	entries, err := h.service.GetProgress(c.UserContext(), studentID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get progress"})
+	}
+	return c.JSON(entries)
+}
+
+func (h *StudentHandler) analytics(c *fiber.Ctx, studentID int) error {
+	analytics, err := h.service.GetProgressAnalytics(c.UserContext(), studentID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get progress analytics"})
+	}
+	return c.JSON(analytics)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+const (
+	defaultStalledDays = 7
+	maxStalledDays     = 365
+)
+
+// TeacherHandler holds the teacher service.
+type TeacherHandler struct {
+	service services.TeacherService
+}
+
+// NewTeacherHandler creates a new TeacherHandler.
+func NewTeacherHandler(service services.TeacherService) *TeacherHandler {
+	return &TeacherHandler{service: service}
+}
+
+// GetMyDashboard handles the request for the authenticated teacher's dashboard.
+// The optional stalled_days query parameter (default 7) sets how long without
+// progress makes a student stalled.
+func (h *TeacherHandler) GetMyDashboard(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	stalledDays := c.QueryInt("stalled_days", defaultStalledDays)
+	if stalledDays <= 0 || stalledDays > maxStalledDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "stalled_days must be between 1 and 365"})
+	}
+
+	dashboard, err := h.service.GetDashboard(c.UserContext(), user.ID, stalledDays)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get dashboard"})
+	}
+	return c.JSON(dashboard)
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
-	"log"
+	"bytes"
+	"errors"
+	"log/slog"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/logging"
 	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
 	"github.com/kolind-am/quran-project/backend/services"
+	"github.com/kolind-am/quran-project/backend/spreadsheet"
 )
 
 // UserHandler holds the user service.
@@ -20,11 +25,20 @@ func NewUserHandler(service services.UserService) *UserHandler {
 }
 
 // GetUsers handles the request to get users.
+// Passing deleted=true lists soft-deleted users instead.
 func (h *UserHandler) GetUsers(c *fiber.Ctx) error {
+	if c.QueryBool("deleted") {
+		users, err := h.service.GetDeletedUsers(c.UserContext())
+		if err != nil {
+			return userError(c, err, "failed to get deleted users")
+		}
+		return c.JSON(users)
+	}
+
 	role := c.Query("role")
-	users, err := h.service.GetUsers(c.Context(), role)
+	users, err := h.service.GetUsers(c.UserContext(), role)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get users"})
+		return userError(c, err, "failed to get users")
 	}
 	return c.JSON(users)
 }
@@ -36,8 +50,8 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
 	}
 
-	if err := h.service.CreateUser(c.Context(), &user); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create user"})
+	if err := h.service.CreateUser(c.UserContext(), &user); err != nil {
+		return userError(c, err, "failed to create user")
 	}
 
 	user.Password = "" // Don't send password back
@@ -55,11 +69,11 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	if err := c.BodyParser(&user); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
 	}
-	log.Printf("Received request to update user with ID %d. Body: %+v", id, user)
+	logging.FromContext(c.UserContext()).Debug("updating user", slog.Int("target_user_id", id))
 
-	updatedUser, err := h.service.UpdateUser(c.Context(), id, &user)
+	updatedUser, err := h.service.UpdateUser(c.UserContext(), id, &user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update user"})
+		return userError(c, err, "failed to update user")
 	}
 
 	return c.JSON(updatedUser)
@@ -72,9 +86,111 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
 	}
 
-	if err := h.service.DeleteUser(c.Context(), id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete user"})
+	if err := h.service.DeleteUser(c.UserContext(), id); err != nil {
+		return userError(c, err, "failed to delete user")
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// GetDeletionPreflight handles the request to preview what deleting a user affects.
+func (h *UserHandler) GetDeletionPreflight(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	preflight, err := h.service.GetDeletionPreflight(c.UserContext(), id)
+	if err != nil {
+		return userError(c, err, "failed to get deletion preflight")
+	}
+
+	return c.JSON(preflight)
+}
+
+// RestoreUser handles the request to restore a soft-deleted user.
+func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	user, err := h.service.RestoreUser(c.UserContext(), id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "deleted user not found"})
+	}
+	if err != nil {
+		return userError(c, err, "failed to restore user")
+	}
+
+	return c.JSON(user)
+}
+
+// ImportUsers handles a roster upload (multipart field "file", CSV or XLSX).
+// By default it only validates the roster and reports row-level errors; with
+// dry_run=false a valid roster is committed and the response is a file, in the
+// upload's format, listing each new user with their initial password.
+func (h *UserHandler) ImportUsers(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "a roster file is required in the \"file\" field"})
+	}
+	format, err := spreadsheet.FormatFromFilename(fileHeader.Filename)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot read the uploaded file"})
+	}
+	defer file.Close()
+
+	rows, err := spreadsheet.Read(file, format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	dryRun := c.QueryBool("dry_run", true)
+	result, err := h.service.ImportUsers(c.UserContext(), rows, dryRun)
+	if errors.Is(err, services.ErrInvalidImport) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return userError(c, err, "failed to import users")
+	}
+	if dryRun {
+		return c.JSON(result)
+	}
+	if !result.Valid {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(result)
+	}
+
+	credentials := [][]string{{"username", "password", "role", "class"}}
+	for _, imported := range result.Users {
+		credentials = append(credentials, []string{imported.User.Username, imported.User.Password, imported.User.Role, imported.Class})
+	}
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(&buf, format, credentials); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to write the import result"})
+	}
+
+	c.Attachment("imported-users." + format)
+	c.Set(fiber.HeaderContentType, spreadsheet.ContentType(format))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusCreated).Send(buf.Bytes())
+}
+
+// userError maps user service errors to responses; anything unexpected is
+// reported with the given message.
+func userError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "insufficient permissions"})
+	case errors.Is(err, repository.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	case errors.Is(err, repository.ErrUsernameTaken):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "username already taken"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// WebhookHandler holds the webhook service.
+type WebhookHandler struct {
+	service services.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(service services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhook handles the request to subscribe a URL to events. The response
+// is the only one to include the subscription's signing secret.
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var subscription models.WebhookSubscription
+	if err := c.BodyParser(&subscription); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	}
+
+	if err := h.service.CreateWebhook(c.UserContext(), user.ID, &subscription); err != nil {
+		return webhookError(c, err, "failed to create webhook")
+	}
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+// GetWebhooks handles the request to list webhook subscriptions.
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	subscriptions, err := h.service.GetWebhooks(c.UserContext())
+	if err != nil {
+		return webhookError(c, err, "failed to get webhooks")
+	}
+	return c.JSON(subscriptions)
+}
+
+// DeleteWebhook handles the request to delete a webhook subscription.
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("webhookId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid webhook ID"})
+	}
+
+	if err := h.service.DeleteWebhook(c.UserContext(), id); err != nil {
+		return webhookError(c, err, "failed to delete webhook")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetDeliveries handles the request for a subscription's delivery log, newest
+// first, paged with limit and offset.
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("webhookId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid webhook ID"})
+	}
+	limit := c.QueryInt("limit", defaultDeliveryLimit)
+	if limit <= 0 || limit > maxDeliveryLimit {
+		limit = defaultDeliveryLimit
+	}
+	offset := max(c.QueryInt("offset", 0), 0)
+
+	deliveries, err := h.service.GetDeliveries(c.UserContext(), id, limit, offset)
+	if err != nil {
+		return webhookError(c, err, "failed to get deliveries")
+	}
+	return c.JSON(deliveries)
+}
+
+// webhookError maps webhook service errors to responses; anything unexpected
+// is reported with the given message.
+func webhookError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhook):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repository.ErrWebhookNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
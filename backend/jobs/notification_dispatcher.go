@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// NotificationDispatcher periodically sends queued notifications and prunes
+// those older than the retention period.
+type NotificationDispatcher struct {
+	service   services.NotificationService
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewNotificationDispatcher creates a new NotificationDispatcher.
+func NewNotificationDispatcher(service services.NotificationService, retention, interval time.Duration) *NotificationDispatcher {
+	return &NotificationDispatcher{service: service, retention: retention, interval: interval}
+}
+
+// Run sends notifications on every tick until the context is cancelled.
+func (d *NotificationDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		d.dispatch(ctx)
+		if time.Since(lastPrune) >= pruneInterval {
+			d.prune(ctx)
+			lastPrune = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *NotificationDispatcher) dispatch(ctx context.Context) {
+	if _, err := d.service.Dispatch(ctx); err != nil && ctx.Err() == nil {
+		logging.FromContext(ctx).Error("sending notifications failed", slog.Any("error", err))
+	}
+}
+
+func (d *NotificationDispatcher) prune(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	pruned, err := d.service.PruneNotifications(ctx, d.retention)
+	if err != nil {
+		logger.Error("pruning notifications failed", slog.Any("error", err))
+		return
+	}
+	if pruned > 0 {
+		logger.Info("pruned notifications", slog.Int64("count", pruned), slog.Duration("retention", d.retention))
+	}
+}
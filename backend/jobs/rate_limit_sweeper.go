@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/ratelimit"
+)
+
+// RateLimitSweeper periodically drops expired rate limit windows.
+type RateLimitSweeper struct {
+	store    ratelimit.Store
+	interval time.Duration
+}
+
+// NewRateLimitSweeper creates a new RateLimitSweeper.
+func NewRateLimitSweeper(store ratelimit.Store, interval time.Duration) *RateLimitSweeper {
+	return &RateLimitSweeper{store: store, interval: interval}
+}
+
+// Run sweeps on every tick until the context is cancelled.
+func (s *RateLimitSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.store.Sweep(ctx, now); err != nil {
+				logging.FromContext(ctx).Error("sweeping rate limits failed", slog.Any("error", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+)
+
+// Job is a background task that runs until its context is cancelled.
+type Job interface {
+	Run(ctx context.Context)
+}
+
+// Runner starts background jobs and stops them together on shutdown.
+type Runner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRunner creates a Runner whose jobs inherit values (such as the logger) from ctx.
+func NewRunner(ctx context.Context) *Runner {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Runner{ctx: ctx, cancel: cancel}
+}
+
+// Start runs the job in its own goroutine.
+func (r *Runner) Start(job Job) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		job.Run(r.ctx)
+	}()
+}
+
+// Stop cancels every job and waits for them to return, or for ctx to expire.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
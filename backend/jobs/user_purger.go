@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// UserPurger periodically removes users that have been soft-deleted for longer
+// than the retention period.
+type UserPurger struct {
+	service   services.UserService
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewUserPurger creates a new UserPurger.
+func NewUserPurger(service services.UserService, retention, interval time.Duration) *UserPurger {
+	return &UserPurger{service: service, retention: retention, interval: interval}
+}
+
+// Run purges expired users on every tick until the context is cancelled.
+func (p *UserPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *UserPurger) purge(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	purged, err := p.service.PurgeDeletedUsers(ctx, p.retention)
+	if err != nil {
+		logger.Error("purging deleted users failed", slog.Any("error", err))
+		return
+	}
+	if purged > 0 {
+		logger.Info("purged deleted users", slog.Int64("count", purged), slog.Duration("retention", p.retention))
+	}
+}
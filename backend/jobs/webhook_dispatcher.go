@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// pruneInterval is how often the dispatchers remove records past their retention.
+const pruneInterval = time.Hour
+
+// WebhookDispatcher periodically delivers outbox events to webhook subscribers
+// and prunes events older than the retention period.
+type WebhookDispatcher struct {
+	service   services.WebhookService
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewWebhookDispatcher creates a new WebhookDispatcher.
+func NewWebhookDispatcher(service services.WebhookService, retention, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{service: service, retention: retention, interval: interval}
+}
+
+// Run dispatches events on every tick until the context is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		d.dispatch(ctx)
+		if time.Since(lastPrune) >= pruneInterval {
+			d.prune(ctx)
+			lastPrune = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	if _, err := d.service.Dispatch(ctx); err != nil && ctx.Err() == nil {
+		logging.FromContext(ctx).Error("dispatching webhooks failed", slog.Any("error", err))
+	}
+}
+
+func (d *WebhookDispatcher) prune(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	pruned, err := d.service.PruneEvents(ctx, d.retention)
+	if err != nil {
+		logger.Error("pruning webhook events failed", slog.Any("error", err))
+		return
+	}
+	if pruned > 0 {
+		logger.Info("pruned webhook events", slog.Int64("count", pruned), slog.Duration("retention", d.retention))
+	}
+}
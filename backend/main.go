@@ -1,51 +1,189 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/kolind-am/quran-project/backend/auth"
 	"github.com/kolind-am/quran-project/backend/config"
 	"github.com/kolind-am/quran-project/backend/database"
+	"github.com/kolind-am/quran-project/backend/handlers"
+	"github.com/kolind-am/quran-project/backend/jobs"
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/middleware"
+	"github.com/kolind-am/quran-project/backend/notify"
+	"github.com/kolind-am/quran-project/backend/pdf"
+	"github.com/kolind-am/quran-project/backend/ratelimit"
+	"github.com/kolind-am/quran-project/backend/realtime"
+	"github.com/kolind-am/quran-project/backend/repository"
 	"github.com/kolind-am/quran-project/backend/routes"
 )
 
 func main() {
-	// Load configuration from environment variables
-	cfg := config.Load()
+	// Maintenance subcommands such as export and import run instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	// Load configuration from the optional config file and environment variables
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Set up structured logging; anything using the standard logger goes through it too
+	logger := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	slog.SetDefault(logger)
+	if cfg.UsesDevelopmentSecret() {
+		logger.Warn("using the built-in development JWT secret; set JWT_SECRET before deploying")
+	}
+
+	// Load the keys used to sign and verify tokens
+	keys, err := auth.NewKeySet(auth.KeySetConfig{
+		Secret:               cfg.JWTSecret,
+		SigningKeyFile:       cfg.JWTSigningKeyFile,
+		SigningKeyID:         cfg.JWTSigningKeyID,
+		VerificationKeyFiles: cfg.JWTVerificationKeyFiles,
+	})
+	if err != nil {
+		logger.Error("loading JWT keys failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Load the certificate templates
+	certificateTemplates, err := pdf.LoadCertificateTemplates(cfg.CertificateTemplatesFile)
+	if err != nil {
+		logger.Error("loading certificate templates failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Set up the notification channels
+	notificationChannels, err := notify.NewChannels(notify.Config{
+		SMSDriver:      cfg.NotificationSMSDriver,
+		SMSURL:         cfg.NotificationSMSURL,
+		SMSToken:       cfg.NotificationSMSToken,
+		EmailDriver:    cfg.NotificationEmailDriver,
+		SMTPAddr:       cfg.SMTPAddr,
+		SMTPUsername:   cfg.SMTPUsername,
+		SMTPPassword:   cfg.SMTPPassword,
+		EmailFrom:      cfg.NotificationEmailFrom,
+		WhatsAppDriver: cfg.NotificationWhatsAppDriver,
+		WhatsAppURL:    cfg.NotificationWhatsAppURL,
+		WhatsAppToken:  cfg.NotificationWhatsAppToken,
+		FileDir:        cfg.NotificationFileDir,
+		GatewayTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		logger.Error("setting up notification channels failed", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	// Connect to the database
-	database.Connect(cfg.DatabaseURL)
-	defer database.Close()
+	database.Connect(cfg.DatabaseURL, cfg.DBMaxConns, cfg.DBMinConns)
 
 	// Create a new Fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handlers.ErrorHandler,
+		// Forwarding headers (the client IP, X-Forwarded-Host and -Proto) are
+		// only honoured on requests from the configured proxies.
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
+	})
+
+	// Setup CORS and security headers
+	app.Use(middleware.CORS(cfg.CORSOrigins))
+	securityHeaders := middleware.SecurityHeadersConfig{ContentSecurityPolicy: cfg.ContentSecurityPolicy}
+	if cfg.IsProduction() {
+		securityHeaders.HSTSMaxAge = cfg.HSTSMaxAge
+	}
+	app.Use(middleware.SecurityHeaders(securityHeaders))
+
+	// Setup rate limiting
+	var limiter ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "postgres" {
+		limiter = ratelimit.NewPostgresStore(database.DB)
+	}
+
+	// Setup the broker carrying live class events
+	var broker realtime.Broker = realtime.NewMemoryBroker()
+	var postgresBroker *realtime.PostgresBroker
+	if cfg.RealtimeBroker == "postgres" {
+		postgresBroker = realtime.NewPostgresBroker(database.DB)
+		broker = postgresBroker
+	}
 
-	// Setup CORS
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "https://quran.ghars.site",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH",
-		AllowCredentials: true,
-	}))
+	// Build the services once; the routes and the background jobs share them
+	svc := routes.NewServices(cfg, certificateTemplates, notificationChannels, broker)
 
 	// Setup routes
-	routes.SetupRoutes(app, cfg.JWTSecret)
+	healthHandler := handlers.NewHealthHandler()
+	routes.SetupRoutes(app, cfg, keys, svc, limiter, logger, healthHandler)
+	healthHandler.SetReady(true)
 
-	// Start the server and implement graceful shutdown
+	// Start background jobs. They work across every organization.
+	runner := jobs.NewRunner(repository.WithAllTenants(logging.WithContext(context.Background(), logger)))
+	runner.Start(jobs.NewUserPurger(svc.User, cfg.UserRetention, time.Hour))
+	runner.Start(jobs.NewRateLimitSweeper(limiter, time.Minute))
+	runner.Start(jobs.NewWebhookDispatcher(svc.Webhook, cfg.WebhookRetention, cfg.WebhookDispatchInterval))
+	runner.Start(jobs.NewNotificationDispatcher(svc.Notification, cfg.NotificationRetention, cfg.NotificationDispatchInterval))
+	if postgresBroker != nil {
+		runner.Start(postgresBroker)
+	}
+
+	// Start the server
+	listenErr := make(chan error, 1)
 	go func() {
-		if err := app.Listen(":" + cfg.ServerPort); err != nil {
-			log.Panic(err)
-		}
+		listenErr <- app.Listen(":" + cfg.ServerPort)
 	}()
 
-	// Wait for an interrupt signal to gracefully shut down the server
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	<-c
+	// Wait for SIGINT/SIGTERM, or for the listener to fail
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	exitCode := 0
+	select {
+	case <-signals.Done():
+		logger.Info("shutdown signal received")
+	case err := <-listenErr:
+		logger.Error("server stopped unexpectedly", slog.Any("error", err))
+		exitCode = 1
+	}
+
+	shutdown(logger, cfg, app, healthHandler, broker, runner)
+	os.Exit(exitCode)
+}
+
+// shutdown stops the server in order: readiness is withdrawn first so load
+// balancers stop routing new traffic, in-flight requests are then drained,
+// background jobs are stopped and finally the database pool is closed.
+func shutdown(logger *slog.Logger, cfg *config.Config, app *fiber.App, healthHandler *handlers.HealthHandler, broker realtime.Broker, runner *jobs.Runner) {
+	logger.Info("gracefully shutting down", slog.Duration("timeout", cfg.ShutdownTimeout))
+	healthHandler.SetReady(false)
+	if cfg.ShutdownReadinessDelay > 0 {
+		time.Sleep(cfg.ShutdownReadinessDelay)
+	}
+
+	// Event streams never finish on their own; end them so draining can complete
+	broker.Close()
+
+	if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		logger.Error("draining requests failed", slog.Any("error", err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := runner.Stop(ctx); err != nil {
+		logger.Error("stopping background jobs failed", slog.Any("error", err))
+	}
 
-	log.Println("Gracefully shutting down...")
-	_ = app.Shutdown()
+	database.Close()
+	logger.Info("shutdown complete")
 }
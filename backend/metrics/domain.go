@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// domainQueryTimeout bounds the database queries run on each scrape.
+const domainQueryTimeout = 5 * time.Second
+
+// DomainCollector exposes school-level gauges queried from the database on scrape.
+type DomainCollector struct {
+	repo repository.StatsRepository
+
+	activeStudents        *prometheus.Desc
+	progressEntriesToday  *prometheus.Desc
+	progressEntriesPerDay *prometheus.Desc
+}
+
+// NewDomainCollector creates a collector backed by the stats repository.
+func NewDomainCollector(repo repository.StatsRepository) *DomainCollector {
+	return &DomainCollector{
+		repo: repo,
+		activeStudents: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_students"),
+			"Students that recorded progress in the last 30 days.", nil, nil),
+		progressEntriesToday: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "progress_entries_today"),
+			"Progress entries recorded since midnight (server time).", nil, nil),
+		progressEntriesPerDay: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "progress_entries_per_day"),
+			"Average progress entries per day over the last 7 days.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *DomainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeStudents
+	ch <- c.progressEntriesToday
+	ch <- c.progressEntriesPerDay
+}
+
+// Collect implements prometheus.Collector. Gauges whose query fails are
+// skipped. The gauges count every organization.
+func (c *DomainCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(repository.WithAllTenants(context.Background()), domainQueryTimeout)
+	defer cancel()
+
+	if count, err := c.repo.CountActiveStudents(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.activeStudents, prometheus.GaugeValue, float64(count))
+	} else {
+		slog.Warn("collecting active students metric failed", slog.Any("error", err))
+	}
+
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if count, err := c.repo.CountProgressEntriesSince(ctx, midnight); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.progressEntriesToday, prometheus.GaugeValue, float64(count))
+	} else {
+		slog.Warn("collecting progress entries metric failed", slog.Any("error", err))
+	}
+
+	if count, err := c.repo.CountProgressEntriesSince(ctx, now.Add(-7*24*time.Hour)); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.progressEntriesPerDay, prometheus.GaugeValue, float64(count)/7)
+	} else {
+		slog.Warn("collecting progress entries metric failed", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/logging"
+)
+
+// AccessLog writes one structured log line per request with its status,
+// latency and route template. It uses the request-scoped logger, so it must run
+// after RequestLogger; the user ID is included once Actor has run.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// A streamed body is never read here: Body() would drain the stream,
+		// holding the whole response back from the client. Its size is the
+		// declared length, or -1 when unknown.
+		resp := c.Response()
+		size := resp.Header.ContentLength()
+		if !resp.IsBodyStream() {
+			size = len(resp.Body())
+		}
+
+		status := resp.StatusCode()
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		logging.FromContext(c.UserContext()).LogAttrs(c.UserContext(), level, "request",
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+			slog.Int("bytes", size),
+		)
+		return nil
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestAccessLogStreamsBodies checks that a streamed response reaches the
+// client while it is still being written, through the logging chain.
+func TestAccessLogStreamsBodies(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestID(), RequestLogger(slog.New(slog.NewTextHandler(io.Discard, nil))), AccessLog())
+
+	release := make(chan struct{})
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			fmt.Fprintln(w, "first")
+			_ = w.Flush()
+			select {
+			case <-release:
+			case <-time.After(5 * time.Second):
+			}
+			fmt.Fprintln(w, "second")
+			_ = w.Flush()
+		})
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	defer func() { _ = app.Shutdown() }()
+	defer close(release)
+
+	lines := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/stream")
+		if err != nil {
+			lines <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		lines <- line
+	}()
+
+	select {
+	case line := <-lines:
+		if line != "first\n" {
+			t.Fatalf("first line = %q, want %q", line, "first\n")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the first chunk did not arrive while the stream was open")
+	}
+}
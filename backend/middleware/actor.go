@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// Actor records the authenticated user and client IP on the request's user
+// context so the services can attribute audit events, and tags the request
+// logger with the user ID. It must run after Protected.
+func Actor() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := models.Actor{IP: c.IP()}
+		if user, ok := auth.FromContext(c.UserContext()); ok {
+			actor.ID = user.ID
+			actor.Role = user.Role
+		}
+		ctx := services.WithActor(c.UserContext(), actor)
+		ctx = logging.WithContext(ctx, logging.FromContext(ctx).With(slog.Int("user_id", actor.ID)))
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
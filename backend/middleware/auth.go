@@ -1,20 +1,66 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
-	jwtware "github.com/gofiber/jwt/v3"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+var (
+	errMissingJWT   = errors.New("missing or malformed JWT")
+	errInactiveUser = errors.New("the token's user is deleted")
+	errRoleChanged  = errors.New("the token's role is no longer the user's")
 )
 
-// Protected returns a JWT middleware that protects routes.
-func Protected(jwtSecret string) fiber.Handler {
-	return jwtware.New(jwtware.Config{
-		SigningKey:   []byte(jwtSecret),
-		ErrorHandler: jwtError,
-	})
+// ActiveUsers looks up the current role of the users tokens are issued to.
+type ActiveUsers interface {
+	FindActiveRole(ctx context.Context, id int) (string, error)
+}
+
+// Protected returns a JWT middleware that protects routes. It verifies the
+// bearer token against the key set, validates its claims, rejects tokens of
+// users who were deleted (or moved out of the token's organization) or given
+// another role since and stores the resulting auth.CurrentUser on the
+// request's user context.
+func Protected(keys *auth.KeySet, users ActiveUsers) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		raw, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || raw == "" {
+			return jwtError(c, errMissingJWT)
+		}
+
+		var claims auth.Claims
+		if _, err := jwt.ParseWithClaims(raw, &claims, keys.Keyfunc); err != nil {
+			return jwtError(c, err)
+		}
+
+		role, err := users.FindActiveRole(repository.WithTenant(c.UserContext(), claims.OrganizationID), claims.UserID)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return jwtError(c, errInactiveUser)
+		}
+		if err != nil {
+			logging.FromContext(c.UserContext()).Error("checking the token's user failed", slog.Int("user_id", claims.UserID), slog.Any("error", err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not verify the session"})
+		}
+		if role != claims.Role {
+			return jwtError(c, errRoleChanged)
+		}
+
+		c.SetUserContext(auth.WithCurrentUser(c.UserContext(), claims.CurrentUser()))
+		return c.Next()
+	}
 }
 
 func jwtError(c *fiber.Ctx, err error) error {
-	if err.Error() == "Missing or malformed JWT" {
+	var validationErr *jwt.ValidationError
+	if errors.Is(err, errMissingJWT) || (errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorMalformed != 0) {
 		return c.Status(fiber.StatusBadRequest).
 			JSON(fiber.Map{"status": "error", "message": "Missing or malformed JWT", "data": nil})
 	}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// userRoles holds the current role of each active user, by ID.
+type userRoles map[int]string
+
+func (roles userRoles) FindActiveRole(_ context.Context, id int) (string, error) {
+	role, ok := roles[id]
+	if !ok {
+		return "", repository.ErrUserNotFound
+	}
+	return role, nil
+}
+
+func newProtectedApp(t *testing.T, keys *auth.KeySet) *fiber.App {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/me", Protected(keys, userRoles{3: "student", 4: "student"}), func(c *fiber.Ctx) error {
+		user, ok := auth.FromContext(c.UserContext())
+		if !ok {
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		return c.SendString(strconv.Itoa(user.ID) + ":" + user.Role)
+	})
+	return app
+}
+
+func TestProtected(t *testing.T) {
+	keys, err := auth.NewKeySet(auth.KeySetConfig{Secret: "test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeys, err := auth.NewKeySet(auth.KeySetConfig{Secret: "other-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(keys *auth.KeySet, claims jwt.Claims) string {
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "missing header", header: "", wantStatus: fiber.StatusBadRequest},
+		{name: "not a bearer token", header: "Basic abc", wantStatus: fiber.StatusBadRequest},
+		{name: "malformed token", header: "Bearer not-a-jwt", wantStatus: fiber.StatusBadRequest},
+		{name: "wrong key", header: "Bearer " + sign(otherKeys, auth.NewClaims(3, 1, "student", time.Hour)), wantStatus: fiber.StatusUnauthorized},
+		{name: "expired", header: "Bearer " + sign(keys, auth.NewClaims(3, 1, "student", -time.Minute)), wantStatus: fiber.StatusUnauthorized},
+		{
+			name: "legacy claims without session",
+			header: "Bearer " + sign(keys, jwt.MapClaims{
+				"id": 3, "role": "student", "exp": now.Add(time.Hour).Unix(),
+			}),
+			wantStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name: "malformed user ID",
+			header: "Bearer " + sign(keys, jwt.MapClaims{
+				"id": "3", "role": "student", "sid": "s", "iat": now.Unix(), "exp": now.Add(time.Hour).Unix(),
+			}),
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{name: "valid", header: "Bearer " + sign(keys, auth.NewClaims(3, 1, "student", time.Hour)), wantStatus: fiber.StatusOK, wantBody: "3:student"},
+		{name: "deleted user", header: "Bearer " + sign(keys, auth.NewClaims(9, 1, "student", time.Hour)), wantStatus: fiber.StatusUnauthorized},
+		{name: "demoted user", header: "Bearer " + sign(keys, auth.NewClaims(4, 1, "admin", time.Hour)), wantStatus: fiber.StatusUnauthorized},
+	}
+
+	app := newProtectedApp(t, keys)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.wantBody {
+					t.Fatalf("body = %q, want %q", body, tt.wantBody)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/logging"
+)
+
+// RequestLogger stores a request-scoped logger on the request's user context,
+// tagged with the request ID, method and path. It must run after RequestID;
+// Actor later adds the authenticated user ID.
+func RequestLogger(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestLogger := logger.With(
+			slog.String("request_id", requestID(c)),
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+		)
+		c.SetUserContext(logging.WithContext(c.UserContext(), requestLogger))
+		return c.Next()
+	}
+}
+
+func requestID(c *fiber.Ctx) string {
+	id, _ := c.Locals("requestid").(string)
+	return id
+}
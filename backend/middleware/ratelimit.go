@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/ratelimit"
+)
+
+// RateLimit limits requests against a single named budget. Requests are keyed
+// by the authenticated user when Protected has run, and by client IP otherwise.
+func RateLimit(store ratelimit.Store, name string, budget ratelimit.Budget) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return limit(c, store, name, budget)
+	}
+}
+
+// RateLimitByMethod applies the read budget to safe methods (GET, HEAD,
+// OPTIONS) and the write budget to everything else.
+func RateLimitByMethod(store ratelimit.Store, read, write ratelimit.Budget) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return limit(c, store, "read", read)
+		default:
+			return limit(c, store, "write", write)
+		}
+	}
+}
+
+func limit(c *fiber.Ctx, store ratelimit.Store, name string, budget ratelimit.Budget) error {
+	if !budget.Enabled() {
+		return c.Next()
+	}
+
+	key := name + ":ip:" + c.IP()
+	if user, ok := auth.FromContext(c.UserContext()); ok {
+		key = name + ":user:" + strconv.Itoa(user.ID)
+	}
+
+	res, err := store.Take(c.UserContext(), key, budget)
+	if err != nil {
+		// Fail open: an unavailable limiter should not take the API down with it.
+		logging.FromContext(c.UserContext()).Error("rate limiter unavailable", slog.String("budget", name), slog.Any("error", err))
+		return c.Next()
+	}
+
+	resetSeconds := strconv.Itoa(int(math.Ceil(time.Until(res.Reset).Seconds())))
+	c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Set("RateLimit-Reset", resetSeconds)
+
+	if !res.Allowed {
+		c.Set(fiber.HeaderRetryAfter, resetSeconds)
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many requests"})
+	}
+	return c.Next()
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/ratelimit"
+)
+
+func TestRateLimitClientIP(t *testing.T) {
+	// app.Test connections come from 0.0.0.0.
+	const testPeer = "0.0.0.0"
+
+	tests := []struct {
+		name    string
+		proxies []string
+		// clients are sent in X-Real-IP, one request each; want holds the statuses.
+		clients []string
+		want    []int
+	}{
+		{
+			name:    "trusted proxy, distinct clients",
+			proxies: []string{testPeer},
+			clients: []string{"203.0.113.1", "203.0.113.2", "203.0.113.1"},
+			want:    []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests},
+		},
+		{
+			name:    "untrusted peer cannot pick its IP",
+			proxies: []string{"10.0.0.0/8"},
+			clients: []string{"203.0.113.1", "203.0.113.2"},
+			want:    []int{fiber.StatusOK, fiber.StatusTooManyRequests},
+		},
+		{
+			name:    "trusted proxy, invalid header falls back to the peer",
+			proxies: []string{testPeer},
+			clients: []string{"not-an-ip", "also-not-an-ip"},
+			want:    []int{fiber.StatusOK, fiber.StatusTooManyRequests},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ProxyHeader:             "X-Real-IP",
+				EnableTrustedProxyCheck: true,
+				TrustedProxies:          tt.proxies,
+				EnableIPValidation:      true,
+			})
+			budget := ratelimit.Budget{Limit: 1, Window: time.Minute}
+			app.Post("/login", RateLimit(ratelimit.NewMemoryStore(), "login", budget), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			for i, client := range tt.clients {
+				req := httptest.NewRequest(fiber.MethodPost, "/login", nil)
+				req.Header.Set("X-Real-IP", client)
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != tt.want[i] {
+					t.Errorf("request %d from %s: status %d, want %d", i+1, client, resp.StatusCode, tt.want[i])
+				}
+			}
+		})
+	}
+}
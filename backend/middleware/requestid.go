@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header used to receive and return request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits client-supplied IDs to something safe to log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID assigns every request an ID, reusing the caller's X-Request-ID when
+// it is well formed, and echoes it back in the response header. The ID is stored
+// in Locals under "requestid" and added to the body of JSON error responses so
+// users can quote it when reporting problems.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Locals("requestid", id)
+		c.Set(RequestIDHeader, id)
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		addRequestIDToError(c, id)
+		return nil
+	}
+}
+
+func addRequestIDToError(c *fiber.Ctx, id string) {
+	resp := c.Response()
+	if resp.IsBodyStream() || resp.StatusCode() < fiber.StatusBadRequest || !strings.HasPrefix(string(resp.Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		return
+	}
+	body["request_id"] = id
+	if data, err := json.Marshal(body); err == nil {
+		resp.SetBody(data)
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/auth"
+)
+
+// RequireRole rejects requests whose user does not hold one of the given roles.
+// It must run after Protected.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := auth.FromContext(c.UserContext())
+		if !ok || !user.HasRole(roles...) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "insufficient permissions"})
+		}
+		return c.Next()
+	}
+}
+
+// RequireRoleWithQuery applies RequireRole to requests that set the boolean
+// query parameter, for routes where a flag unlocks privileged data.
+func RequireRoleWithQuery(param string, roles ...string) fiber.Handler {
+	requireRole := RequireRole(roles...)
+	return func(c *fiber.Ctx) error {
+		if c.QueryBool(param) {
+			return requireRole(c)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/auth"
+)
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		target     string
+		wantStatus int
+	}{
+		{name: "admin", role: "admin", target: "/users", wantStatus: fiber.StatusOK},
+		{name: "developer", role: "developer", target: "/users", wantStatus: fiber.StatusOK},
+		{name: "teacher", role: "teacher", target: "/users", wantStatus: fiber.StatusForbidden},
+		{name: "student", role: "student", target: "/users", wantStatus: fiber.StatusForbidden},
+		{name: "anonymous", target: "/users", wantStatus: fiber.StatusForbidden},
+		{name: "teacher without the flag", role: "teacher", target: "/users/list", wantStatus: fiber.StatusOK},
+		{name: "teacher with the flag off", role: "teacher", target: "/users/list?deleted=false", wantStatus: fiber.StatusOK},
+		{name: "teacher with the flag", role: "teacher", target: "/users/list?deleted=true", wantStatus: fiber.StatusForbidden},
+		{name: "admin with the flag", role: "admin", target: "/users/list?deleted=true", wantStatus: fiber.StatusOK},
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if role := c.Get("X-Test-Role"); role != "" {
+			c.SetUserContext(auth.WithCurrentUser(c.UserContext(), auth.CurrentUser{ID: 1, OrganizationID: 1, Role: role}))
+		}
+		return c.Next()
+	})
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/users", RequireRole("admin", "developer"), ok)
+	app.Get("/users/list", RequireRoleWithQuery("deleted", "admin", "developer"), ok)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.target, nil)
+			req.Header.Set("X-Test-Role", tt.role)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+)
+
+// OrganizationHeader names the organization a request is for by its slug. It
+// takes precedence over the subdomain.
+const OrganizationHeader = "X-Organization"
+
+// Tenant scopes the repositories to the organization the request is for: the
+// one named by the X-Organization header or subdomain of baseDomain, else the
+// user's own. Only developers may work in another organization than their
+// own. It must run after Protected.
+func Tenant(organizations services.OrganizationService, baseDomain string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := auth.FromContext(c.UserContext())
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+
+		organizationID := user.OrganizationID
+		if slug := requestedOrganization(c, baseDomain); slug != "" {
+			organization, err := organizations.ResolveOrganization(c.UserContext(), slug)
+			if err != nil {
+				return organizationError(c, err)
+			}
+			if organization.ID != user.OrganizationID && !user.HasRole("developer") {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you are not a member of this organization"})
+			}
+			organizationID = organization.ID
+		}
+
+		ctx := repository.WithTenant(c.UserContext(), organizationID)
+		c.SetUserContext(logging.WithContext(ctx, logging.FromContext(ctx).With(slog.Int("organization_id", organizationID))))
+		return c.Next()
+	}
+}
+
+// PublicTenant scopes the repositories of unauthenticated routes to the
+// organization named by the X-Organization header or subdomain of
+// baseDomain, or to every organization when none is named.
+func PublicTenant(organizations services.OrganizationService, baseDomain string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		slug := requestedOrganization(c, baseDomain)
+		if slug == "" {
+			c.SetUserContext(repository.WithAllTenants(c.UserContext()))
+			return c.Next()
+		}
+
+		organization, err := organizations.ResolveOrganization(c.UserContext(), slug)
+		if err != nil {
+			return organizationError(c, err)
+		}
+		c.SetUserContext(repository.WithTenant(c.UserContext(), organization.ID))
+		return c.Next()
+	}
+}
+
+// requestedOrganization returns the slug of the organization the request
+// names, if any.
+func requestedOrganization(c *fiber.Ctx, baseDomain string) string {
+	if slug := strings.ToLower(strings.TrimSpace(c.Get(OrganizationHeader))); slug != "" {
+		return slug
+	}
+	return subdomain(c.Hostname(), baseDomain)
+}
+
+// subdomain returns the single label host has below baseDomain, or "" when
+// host is not a direct subdomain of it or baseDomain is empty.
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	label, found := strings.CutSuffix(host, "."+strings.ToLower(baseDomain))
+	if !found || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+func organizationError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrOrganizationNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "organization not found"})
+	}
+	logging.FromContext(c.UserContext()).Error("organization lookup failed", slog.Any("error", err))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to resolve organization"})
+}
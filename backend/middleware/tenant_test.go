@@ -0,0 +1,23 @@
+package middleware
+
+import "testing"
+
+func TestSubdomain(t *testing.T) {
+	tests := []struct {
+		host, baseDomain, want string
+	}{
+		{host: "al-noor.example.com", baseDomain: "example.com", want: "al-noor"},
+		{host: "AL-NOOR.Example.com:8080", baseDomain: "example.com", want: "al-noor"},
+		{host: "al-noor.example.com.", baseDomain: "example.com", want: "al-noor"},
+		{host: "example.com", baseDomain: "example.com", want: ""},
+		{host: "a.b.example.com", baseDomain: "example.com", want: ""},
+		{host: "al-noor.example.org", baseDomain: "example.com", want: ""},
+		{host: "badexample.com", baseDomain: "example.com", want: ""},
+		{host: "al-noor.example.com", baseDomain: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := subdomain(tt.host, tt.baseDomain); got != tt.want {
+			t.Errorf("subdomain(%q, %q) = %q, want %q", tt.host, tt.baseDomain, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,19 @@
 package models
 
+import "time"
+
 // User represents a user in the system (teacher, student, or admin).
 type User struct {
-	ID             int     `json:"id"`
-	Username       string  `json:"username"`
-	Password       string  `json:"password,omitempty"` // omitempty to prevent sending it in responses
-	Role           string  `json:"role"`
-	Phone          *string `json:"phone,omitempty"`
-	Classes        []Class `json:"classes,omitempty"`
-	ProgressSurah  *int    `json:"progress_surah,omitempty"`
-	ProgressAyah   *int    `json:"progress_ayah,omitempty"`
-	ProgressPage   *int    `json:"progress_page,omitempty"`
+	ID            int        `json:"id"`
+	Username      string     `json:"username"`
+	Password      string     `json:"password,omitempty"` // omitempty to prevent sending it in responses
+	Role          string     `json:"role"`
+	Phone         *string    `json:"phone,omitempty"`
+	Classes       []Class    `json:"classes,omitempty"`
+	ProgressSurah *int       `json:"progress_surah,omitempty"`
+	ProgressAyah  *int       `json:"progress_ayah,omitempty"`
+	ProgressPage  *int       `json:"progress_page,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 }
 
 // LoginRequest represents the payload for a login request.
@@ -33,3 +36,447 @@ type StudentData struct {
 	ProgressAyah  int    `json:"progress_ayah"`
 	ProgressPage  int    `json:"progress_page"`
 }
+
+// DeletionPreflight describes what deleting a user would affect, so an admin can
+// review it before confirming.
+type DeletionPreflight struct {
+	UserID           int     `json:"user_id"`
+	Username         string  `json:"username"`
+	Role             string  `json:"role"`
+	ClassesOwned     []Class `json:"classes_owned"`
+	StudentsEnrolled int     `json:"students_enrolled"`
+	ClassesEnrolled  []Class `json:"classes_enrolled"`
+}
+
+// Actor identifies who is performing a request, for auditing purposes.
+type Actor struct {
+	ID   int
+	Role string
+	IP   string
+}
+
+// FieldChange holds the before and after value of a single changed field.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditEvent represents a single recorded mutating action.
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	ActorID    *int                   `json:"actor_id"`
+	ActorRole  *string                `json:"actor_role"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   *int                   `json:"entity_id"`
+	Changes    map[string]FieldChange `json:"changes"`
+	IP         *string                `json:"ip"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditFilter narrows down the audit events returned by a query.
+type AuditFilter struct {
+	ActorID    *int
+	Action     string
+	EntityType string
+	EntityID   *int
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// AttendanceSummary counts a class's attendance marks for one day.
+type AttendanceSummary struct {
+	Present  int `json:"present"`
+	Absent   int `json:"absent"`
+	Late     int `json:"late"`
+	Excused  int `json:"excused"`
+	Unmarked int `json:"unmarked"`
+}
+
+// DashboardClass is a teacher's class with its size and today's attendance.
+type DashboardClass struct {
+	ID              int               `json:"id"`
+	Name            string            `json:"name"`
+	StudentCount    int               `json:"student_count"`
+	AttendanceToday AttendanceSummary `json:"attendance_today"`
+}
+
+// StalledStudent is a student who has not recorded progress recently.
+type StalledStudent struct {
+	ID             int        `json:"id"`
+	Username       string     `json:"username"`
+	ClassID        int        `json:"class_id"`
+	ClassName      string     `json:"class_name"`
+	LastProgressAt *time.Time `json:"last_progress_at"`
+}
+
+// Recitation is a graded recitation heard by a teacher.
+type Recitation struct {
+	ID        int       `json:"id"`
+	StudentID int       `json:"student_id"`
+	Username  string    `json:"username"`
+	ClassID   *int      `json:"class_id"`
+	Surah     int       `json:"surah"`
+	AyahFrom  int       `json:"ayah_from"`
+	AyahTo    int       `json:"ayah_to"`
+	Grade     int       `json:"grade"`
+	Notes     *string   `json:"notes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TeacherDashboard aggregates everything the teacher home page shows.
+type TeacherDashboard struct {
+	Date              string           `json:"date"`
+	StalledAfterDays  int              `json:"stalled_after_days"`
+	Classes           []DashboardClass `json:"classes"`
+	StalledStudents   []StalledStudent `json:"stalled_students"`
+	RecentRecitations []Recitation     `json:"recent_recitations"`
+}
+
+// WeeklyCount is the number of entries recorded in the week starting on WeekStart.
+type WeeklyCount struct {
+	WeekStart string `json:"week_start"`
+	Count     int    `json:"count"`
+}
+
+// TeacherWorkload summarizes how many classes and students a teacher has.
+type TeacherWorkload struct {
+	TeacherID         int    `json:"teacher_id"`
+	Username          string `json:"username"`
+	ClassCount        int    `json:"class_count"`
+	StudentCount      int    `json:"student_count"`
+	RecentRecitations int    `json:"recent_recitations"`
+}
+
+// AdminStats is the school-wide overview shown to administrators.
+type AdminStats struct {
+	UsersByRole           map[string]int    `json:"users_by_role"`
+	Classes               int               `json:"classes"`
+	ActiveStudents        int               `json:"active_students"`
+	InactiveStudents      int               `json:"inactive_students"`
+	TotalPagesMemorized   int               `json:"total_pages_memorized"`
+	ProgressEntriesByWeek []WeeklyCount     `json:"progress_entries_by_week"`
+	TeacherWorkload       []TeacherWorkload `json:"teacher_workload"`
+	GeneratedAt           time.Time         `json:"generated_at"`
+}
+
+// ProgressEntry is one point in a student's progress history.
+type ProgressEntry struct {
+	ID        int       `json:"id"`
+	StudentID int       `json:"student_id"`
+	Surah     int       `json:"surah"`
+	Ayah      int       `json:"ayah"`
+	Page      int       `json:"page"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProgressFilter holds the optional filters for listing progress entries.
+type ProgressFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// WeeklyPage is the furthest page a student had reached by the end of the week
+// starting on WeekStart, or nil if they had no progress yet.
+type WeeklyPage struct {
+	WeekStart string
+	Page      *int
+}
+
+// WeeklyProgress is how many pages a student advanced in the week starting on WeekStart.
+type WeeklyProgress struct {
+	WeekStart     string `json:"week_start"`
+	PagesAdvanced int    `json:"pages_advanced"`
+}
+
+// ProgressStreak counts consecutive days on which a student recorded progress.
+type ProgressStreak struct {
+	Current        int     `json:"current"`
+	Longest        int     `json:"longest"`
+	LastActiveDate *string `json:"last_active_date"`
+}
+
+// ProgressProjection estimates when a student will reach TargetPage at their
+// recent pace. EstimatedCompletion is nil when they have not advanced lately.
+type ProgressProjection struct {
+	Target              string  `json:"target"`
+	TargetPage          int     `json:"target_page"`
+	CurrentPage         int     `json:"current_page"`
+	RemainingPages      int     `json:"remaining_pages"`
+	Deadline            *string `json:"deadline,omitempty"`
+	EstimatedCompletion *string `json:"estimated_completion"`
+}
+
+// ProgressAnalytics summarizes a student's pace from their progress history.
+type ProgressAnalytics struct {
+	StudentID    int                `json:"student_id"`
+	Weekly       []WeeklyProgress   `json:"weekly"`
+	PagesPerWeek float64            `json:"pages_per_week"`
+	Streak       ProgressStreak     `json:"streak"`
+	Projection   ProgressProjection `json:"projection"`
+}
+
+// Goal is a target set for a student or a whole class. Range goals cover
+// StartPage to EndPage; quantity goals ask for TargetPages per Period ("day" or
+// "week"). Dates are formatted as YYYY-MM-DD.
+type Goal struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	StudentID   *int      `json:"student_id,omitempty"`
+	ClassID     *int      `json:"class_id,omitempty"`
+	StartPage   *int      `json:"start_page,omitempty"`
+	EndPage     *int      `json:"end_page,omitempty"`
+	TargetPages *int      `json:"target_pages,omitempty"`
+	Period      *string   `json:"period,omitempty"`
+	StartDate   string    `json:"start_date"`
+	Deadline    string    `json:"deadline"`
+	CreatedBy   *int      `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StudentPages is the page sequence a goal's progress is computed from: the last
+// page before the goal started, if any, followed by every page recorded since.
+type StudentPages struct {
+	StudentID int
+	Username  string
+	Baseline  *int
+	Pages     []int
+}
+
+// GoalProgress is one student's progress towards a goal, in pages.
+type GoalProgress struct {
+	StudentID int     `json:"student_id"`
+	Username  string  `json:"username"`
+	Target    int     `json:"target"`
+	Achieved  int     `json:"achieved"`
+	Expected  int     `json:"expected"`
+	Percent   float64 `json:"percent"`
+	Status    string  `json:"status"`
+}
+
+// GoalReport is a goal with the progress of every student it applies to.
+type GoalReport struct {
+	Goal
+	Students     []GoalProgress `json:"students"`
+	StatusCounts map[string]int `json:"status_counts"`
+}
+
+// Khatma is one complete reading of the mushaf by a student.
+type Khatma struct {
+	ID           int        `json:"id"`
+	StudentID    int        `json:"student_id"`
+	Username     string     `json:"username,omitempty"`
+	Status       string     `json:"status"`
+	LastPage     int        `json:"last_page"`
+	StartedAt    time.Time  `json:"started_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	DurationDays *int       `json:"duration_days,omitempty"`
+}
+
+// ImportError is a validation problem with one row (1-based, counting the
+// header) of a roster import.
+type ImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportedUser is a validated roster row. Password holds the generated initial
+// password in clear text until the import has been committed.
+type ImportedUser struct {
+	Row     int
+	User    User
+	ClassID *int
+	Class   string
+}
+
+// ImportResult reports the outcome of a roster import. Users is only filled in
+// once the import has been committed.
+type ImportResult struct {
+	DryRun bool           `json:"dry_run"`
+	Rows   int            `json:"rows"`
+	Valid  bool           `json:"valid"`
+	Errors []ImportError  `json:"errors"`
+	Users  []ImportedUser `json:"-"`
+}
+
+// ProgressReportRow summarizes one class member's progress over a report period.
+// StartPage is where the student stood when the period began, or their first
+// recorded page within it.
+type ProgressReportRow struct {
+	StudentID int
+	Username  string
+	StartPage *int
+	EndPage   *int
+	Surah     *int
+	Ayah      *int
+	Entries   int
+}
+
+// AttendanceReportRow counts one class member's attendance over a report period.
+type AttendanceReportRow struct {
+	StudentID int
+	Username  string
+	Present   int
+	Absent    int
+	Late      int
+	Excused   int
+}
+
+// RecitationReportRow is one graded recitation heard in a class.
+type RecitationReportRow struct {
+	Date      string
+	StudentID int
+	Username  string
+	Surah     int
+	AyahFrom  int
+	AyahTo    int
+	Grade     int
+	Notes     *string
+}
+
+// Certificate is a certificate issued to a student for completing a juz or a
+// khatma. Code is the verification code printed on it.
+type Certificate struct {
+	ID          int       `json:"id"`
+	Code        string    `json:"code"`
+	StudentID   *int      `json:"student_id,omitempty"`
+	StudentName string    `json:"student_name"`
+	TeacherID   *int      `json:"teacher_id,omitempty"`
+	TeacherName string    `json:"teacher_name"`
+	Achievement string    `json:"achievement"`
+	Juz         *int      `json:"juz,omitempty"`
+	KhatmaID    *int      `json:"khatma_id,omitempty"`
+	Template    string    `json:"template"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+// CertificateRequest asks for a certificate. StudentName overrides the
+// student's username as the name printed on it.
+type CertificateRequest struct {
+	StudentID   int    `json:"student_id"`
+	StudentName string `json:"student_name"`
+	Achievement string `json:"achievement"`
+	Juz         *int   `json:"juz"`
+	KhatmaID    *int   `json:"khatma_id"`
+	Template    string `json:"template"`
+}
+
+// CertificateVerification is what anyone holding a verification code may learn
+// about the certificate.
+type CertificateVerification struct {
+	Code        string    `json:"code"`
+	StudentName string    `json:"student_name"`
+	Achievement string    `json:"achievement"`
+	TeacherName string    `json:"teacher_name"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+// AttendanceRecord is one student's attendance mark. Status is empty for a
+// class member who has not been marked yet.
+type AttendanceRecord struct {
+	StudentID int    `json:"student_id"`
+	Username  string `json:"username,omitempty"`
+	Status    string `json:"status"`
+}
+
+// AttendanceSheet is a class's attendance for one day.
+type AttendanceSheet struct {
+	ClassID int                `json:"class_id"`
+	Date    string             `json:"date"`
+	Records []AttendanceRecord `json:"records"`
+}
+
+// WebhookSubscription sends the events it lists (every event when empty) to a
+// URL. The secret signs each request and is only shown when it is created.
+type WebhookSubscription struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedBy   *int      `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is the delivery of one event to one subscription.
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int        `json:"subscription_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PendingDelivery is a delivery claimed for an attempt, with everything needed
+// to send it.
+type PendingDelivery struct {
+	ID             int64
+	Attempts       int
+	EventID        int64
+	EventType      string
+	Payload        string
+	EventCreatedAt time.Time
+	URL            string
+	Secret         string
+}
+
+// NotificationPreferences are the channels and language a user is notified in.
+// Email is the address used by the email channel; SMS and WhatsApp messages go
+// to the user's phone.
+type NotificationPreferences struct {
+	UserID   int      `json:"user_id"`
+	Language string   `json:"language"`
+	Channels []string `json:"channels"`
+	Email    *string  `json:"email,omitempty"`
+}
+
+// NotificationRecipient is a user with their contact details and preferences.
+type NotificationRecipient struct {
+	UserID   int
+	Username string
+	Phone    *string
+	Email    *string
+	Language string
+	Channels []string
+}
+
+// Notification is a rendered message queued for one channel.
+type Notification struct {
+	ID            int64      `json:"id"`
+	UserID        int        `json:"user_id"`
+	Channel       string     `json:"channel"`
+	Recipient     string     `json:"recipient"`
+	Template      string     `json:"template"`
+	Language      string     `json:"language"`
+	Subject       string     `json:"subject,omitempty"`
+	Body          string     `json:"body"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Organization is a mosque or school hosted on the deployment. Its slug names
+// it in subdomains and the X-Organization header.
+type Organization struct {
+	ID        int       `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+)
+
+// LogChannel writes messages to the application log instead of sending them.
+type LogChannel struct {
+	name string
+}
+
+// NewLogChannel creates a LogChannel for the named channel.
+func NewLogChannel(name string) *LogChannel {
+	return &LogChannel{name: name}
+}
+
+// Send implements Channel.
+func (c *LogChannel) Send(ctx context.Context, msg Message) error {
+	logging.FromContext(ctx).Info("notification",
+		slog.String("channel", c.name), slog.String("to", msg.To),
+		slog.String("subject", msg.Subject), slog.String("body", msg.Body))
+	return nil
+}
+
+// FileChannel appends messages as JSON lines to <dir>/<channel>.jsonl instead
+// of sending them.
+type FileChannel struct {
+	name string
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileChannel creates a FileChannel for the named channel writing into dir.
+func NewFileChannel(name, dir string) *FileChannel {
+	return &FileChannel{name: name, path: filepath.Join(dir, name+".jsonl")}
+}
+
+// Send implements Channel.
+func (c *FileChannel) Send(_ context.Context, msg Message) error {
+	line, err := json.Marshal(map[string]interface{}{
+		"time":    time.Now().UTC(),
+		"channel": c.name,
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBody is how much of a failed response's body is kept in the error.
+const maxErrorBody = 512
+
+// Gateway sends text messages through an HTTP gateway, as offered by SMS and
+// WhatsApp providers. Each message is POSTed as {"to": ..., "body": ...} with
+// the token as a bearer credential; any 2xx answer counts as sent.
+type Gateway struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewGateway creates a Gateway posting to url.
+func NewGateway(url, token string, timeout time.Duration) *Gateway {
+	return &Gateway{url: url, token: token, client: &http.Client{Timeout: timeout}}
+}
+
+// Send implements Channel.
+func (g *Gateway) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{"to": msg.To, "body": msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("notify: gateway answered %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package notify renders notification messages and sends them over SMS, email
+// and a WhatsApp-style HTTP gateway.
+//
+// Every channel has a driver for production use and "log" and "file" drivers
+// for local development: the first writes messages to the application log, the
+// second appends them as JSON lines to a file.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Channels a user can be notified on.
+const (
+	SMS      = "sms"
+	Email    = "email"
+	WhatsApp = "whatsapp"
+)
+
+// ChannelNames lists every channel.
+var ChannelNames = []string{SMS, Email, WhatsApp}
+
+// Drivers.
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverHTTP = "http"
+	DriverSMTP = "smtp"
+)
+
+const (
+	// firstRetryDelay is the wait before the first retry; it doubles after each failure.
+	firstRetryDelay = time.Minute
+	// maxRetryDelay caps the wait between two attempts.
+	maxRetryDelay = time.Hour
+)
+
+// Message is one notification ready to be sent. To is a phone number for SMS
+// and WhatsApp and an email address for email; Subject is only used by email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Channel sends messages over one medium.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Channels maps channel names to the drivers that send them.
+type Channels map[string]Channel
+
+// Config selects and configures the driver of each channel.
+type Config struct {
+	SMSDriver      string
+	SMSURL         string
+	SMSToken       string
+	EmailDriver    string
+	SMTPAddr       string
+	SMTPUsername   string
+	SMTPPassword   string
+	EmailFrom      string
+	WhatsAppDriver string
+	WhatsAppURL    string
+	WhatsAppToken  string
+	FileDir        string
+	GatewayTimeout time.Duration
+}
+
+// NewChannels builds the drivers chosen in cfg.
+func NewChannels(cfg Config) (Channels, error) {
+	channels := Channels{}
+	for _, name := range ChannelNames {
+		var driver string
+		switch name {
+		case SMS:
+			driver = cfg.SMSDriver
+		case Email:
+			driver = cfg.EmailDriver
+		case WhatsApp:
+			driver = cfg.WhatsAppDriver
+		}
+
+		switch {
+		case driver == DriverLog:
+			channels[name] = NewLogChannel(name)
+		case driver == DriverFile:
+			channels[name] = NewFileChannel(name, cfg.FileDir)
+		case driver == DriverHTTP && name == SMS:
+			channels[name] = NewGateway(cfg.SMSURL, cfg.SMSToken, cfg.GatewayTimeout)
+		case driver == DriverHTTP && name == WhatsApp:
+			channels[name] = NewGateway(cfg.WhatsAppURL, cfg.WhatsAppToken, cfg.GatewayTimeout)
+		case driver == DriverSMTP && name == Email:
+			channels[name] = NewSMTPChannel(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom)
+		default:
+			return nil, fmt.Errorf("notify: unsupported %s driver %q", name, driver)
+		}
+	}
+	return channels, nil
+}
+
+// Backoff returns how long to wait before retrying after the given number of
+// failed attempts.
+func Backoff(failedAttempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < failedAttempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
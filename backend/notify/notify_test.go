@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	data := map[string]interface{}{"Username": "yusuf", "ClassName": "Hifz A", "Date": "2024-03-01"}
+
+	subject, body, err := Render(StudentAbsent, English, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "yusuf missed class" || body != "yusuf was absent from Hifz A on 2024-03-01." {
+		t.Errorf("Render(en) = %q, %q", subject, body)
+	}
+
+	_, arabic, err := Render(StudentAbsent, "fr", data)
+	if err != nil || !strings.Contains(arabic, "تغيّب") {
+		t.Errorf("Render of an unsupported language = %q, %v; want the Arabic text", arabic, err)
+	}
+
+	if _, _, err := Render(SurahCompleted, English, data); err == nil {
+		t.Error("Render with missing data succeeded")
+	}
+	if _, _, err := Render("nope", English, data); err == nil {
+		t.Error("Render of an unknown template succeeded")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 6: 32 * time.Minute, 7: time.Hour, 20: time.Hour}
+	for attempts, want := range tests {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestGateway(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer server.Close()
+
+	msg := Message{To: "+966500000000", Body: "مرحبا"}
+	if err := NewGateway(server.URL, "token", time.Second).Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if got["to"] != msg.To || got["body"] != msg.Body {
+		t.Errorf("gateway received %v", got)
+	}
+	if err := NewGateway(server.URL, "wrong", time.Second).Send(context.Background(), msg); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Send with a bad token = %v", err)
+	}
+}
+
+func TestFileChannel(t *testing.T) {
+	dir := t.TempDir()
+	channel := NewFileChannel(SMS, filepath.Join(dir, "out"))
+	for _, body := range []string{"one", "two"} {
+		if err := channel.Send(context.Background(), Message{To: "+1", Body: body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", "sms.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"body":"two"`) {
+		t.Errorf("file contents = %q", data)
+	}
+}
+
+func TestNewChannels(t *testing.T) {
+	channels, err := NewChannels(Config{SMSDriver: DriverLog, EmailDriver: DriverSMTP, WhatsAppDriver: DriverFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != len(ChannelNames) {
+		t.Errorf("NewChannels built %d channels", len(channels))
+	}
+	if _, err := NewChannels(Config{SMSDriver: DriverSMTP, EmailDriver: DriverLog, WhatsAppDriver: DriverLog}); err == nil {
+		t.Error("NewChannels accepted an SMTP driver for SMS")
+	}
+}
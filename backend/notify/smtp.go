@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPChannel sends email through an SMTP server, authenticating with PLAIN
+// when a username is set.
+type SMTPChannel struct {
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPChannel creates an SMTPChannel for the server at addr (host:port).
+func NewSMTPChannel(addr, username, password, from string) *SMTPChannel {
+	return &SMTPChannel{addr: addr, username: username, password: password, from: from}
+}
+
+// Send implements Channel.
+func (c *SMTPChannel) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if c.username != "" {
+		host, _, err := net.SplitHostPort(c.addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", c.username, c.password, host)
+	}
+	return smtp.SendMail(c.addr, auth, c.from, []string{msg.To}, buildEmail(c.from, msg, time.Now()))
+}
+
+// buildEmail formats a UTF-8 plain-text email. The body is base64 encoded so
+// Arabic text survives any mail relay.
+func buildEmail(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return []byte(b.String())
+}
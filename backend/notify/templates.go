@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Notification templates.
+const (
+	// StudentAbsent is sent when a student is marked absent. Its data has
+	// Username, ClassName and Date.
+	StudentAbsent = "student.absent"
+	// SurahCompleted is sent when a student finishes a surah. Its data has
+	// Username, Surah (the number) and SurahName.
+	SurahCompleted = "surah.completed"
+)
+
+// Languages messages are written in. Arabic is the default.
+const (
+	Arabic  = "ar"
+	English = "en"
+)
+
+// Languages lists every supported language.
+var Languages = []string{Arabic, English}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var templates = map[string]map[string]messageTemplate{
+	StudentAbsent: {
+		Arabic: parse("الطالب {{.Username}} غائب",
+			"نود إعلامكم بأن الطالب {{.Username}} تغيّب عن حلقة {{.ClassName}} بتاريخ {{.Date}}."),
+		English: parse("{{.Username}} missed class",
+			"{{.Username}} was absent from {{.ClassName}} on {{.Date}}."),
+	},
+	SurahCompleted: {
+		Arabic: parse("أتمّ {{.Username}} سورة {{.SurahName}}",
+			"يسرّنا إعلامكم بأن الطالب {{.Username}} أتمّ سورة {{.SurahName}}. بارك الله فيه."),
+		English: parse("{{.Username}} finished a surah",
+			"{{.Username}} has finished Surah {{.Surah}} ({{.SurahName}}). Congratulations!"),
+	},
+}
+
+func parse(subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Option("missingkey=error").Parse(subject)),
+		body:    template.Must(template.New("body").Option("missingkey=error").Parse(body)),
+	}
+}
+
+// Render returns the subject and body of a template in the given language,
+// falling back to Arabic for a language the template lacks.
+func Render(name, language string, data map[string]interface{}) (subject, body string, err error) {
+	translations, ok := templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("notify: unknown template %q", name)
+	}
+	tmpl, ok := translations[language]
+	if !ok {
+		tmpl = translations[Arabic]
+	}
+
+	var out strings.Builder
+	if err := tmpl.subject.Execute(&out, data); err != nil {
+		return "", "", fmt.Errorf("notify: rendering %s: %w", name, err)
+	}
+	subject = out.String()
+	out.Reset()
+	if err := tmpl.body.Execute(&out, data); err != nil {
+		return "", "", fmt.Errorf("notify: rendering %s: %w", name, err)
+	}
+	return subject, out.String(), nil
+}
@@ -0,0 +1,247 @@
+package pdf
+
+import (
+	"slices"
+	"unicode"
+)
+
+// PDF text is drawn glyph by glyph from left to right, so Arabic has to be
+// shaped (each letter replaced by its initial, medial, final or isolated
+// presentation form) and reordered visually before it is written.
+
+// arabicForms maps the basic Arabic letters to their presentation forms:
+// isolated, final, initial, medial. Letters that only join to the preceding
+// letter have no initial or medial form.
+var arabicForms = map[rune][4]rune{
+	'ء': {0xFE80, 0, 0, 0},
+	'آ': {0xFE81, 0xFE82, 0, 0},
+	'أ': {0xFE83, 0xFE84, 0, 0},
+	'ؤ': {0xFE85, 0xFE86, 0, 0},
+	'إ': {0xFE87, 0xFE88, 0, 0},
+	'ئ': {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	'ا': {0xFE8D, 0xFE8E, 0, 0},
+	'ب': {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	'ة': {0xFE93, 0xFE94, 0, 0},
+	'ت': {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	'ث': {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	'ج': {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	'ح': {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	'خ': {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	'د': {0xFEA9, 0xFEAA, 0, 0},
+	'ذ': {0xFEAB, 0xFEAC, 0, 0},
+	'ر': {0xFEAD, 0xFEAE, 0, 0},
+	'ز': {0xFEAF, 0xFEB0, 0, 0},
+	'س': {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	'ش': {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	'ص': {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	'ض': {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	'ط': {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	'ظ': {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	'ع': {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	'غ': {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	'ـ': {0x0640, 0x0640, 0x0640, 0x0640},
+	'ف': {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	'ق': {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	'ك': {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	'ل': {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	'م': {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	'ن': {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	'ه': {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	'و': {0xFEED, 0xFEEE, 0, 0},
+	'ى': {0xFEEF, 0xFEF0, 0, 0},
+	'ي': {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+}
+
+// lamAlef maps the alef that follows a lam to the isolated and final forms of
+// their mandatory ligature.
+var lamAlef = map[rune][2]rune{
+	'آ': {0xFEF5, 0xFEF6},
+	'أ': {0xFEF7, 0xFEF8},
+	'إ': {0xFEF9, 0xFEFA},
+	'ا': {0xFEFB, 0xFEFC},
+}
+
+const (
+	isolated = iota
+	final
+	initial
+	medial
+)
+
+// mirrored swaps paired punctuation inside right-to-left runs.
+var mirrored = map[rune]rune{'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<', '«': '»', '»': '«'}
+
+// isDiacritic reports whether r is an Arabic vowel mark. Marks are dropped:
+// they would need glyph positioning that plain PDF text cannot do.
+func isDiacritic(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670
+}
+
+// joinsForward reports whether r connects to the letter after it.
+func joinsForward(r rune) bool {
+	forms, ok := arabicForms[r]
+	return ok && forms[initial] != 0
+}
+
+// shape replaces Arabic letters with their contextual presentation forms,
+// keeping logical order.
+func shape(text string) []rune {
+	var runes []rune
+	for _, r := range text {
+		if !isDiacritic(r) {
+			runes = append(runes, r)
+		}
+	}
+
+	shaped := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			shaped = append(shaped, r)
+			continue
+		}
+		joinsPrev := i > 0 && joinsForward(runes[i-1])
+
+		if r == 'ل' && i+1 < len(runes) {
+			if ligature, ok := lamAlef[runes[i+1]]; ok {
+				if joinsPrev {
+					shaped = append(shaped, ligature[1])
+				} else {
+					shaped = append(shaped, ligature[0])
+				}
+				i++
+				continue
+			}
+		}
+
+		nextIsArabic := false
+		if i+1 < len(runes) {
+			_, nextIsArabic = arabicForms[runes[i+1]]
+		}
+		joinsNext := forms[initial] != 0 && nextIsArabic && runes[i+1] != 'ء'
+
+		switch {
+		case joinsPrev && joinsNext:
+			shaped = append(shaped, forms[medial])
+		case joinsPrev:
+			shaped = append(shaped, forms[final])
+		case joinsNext:
+			shaped = append(shaped, forms[initial])
+		default:
+			shaped = append(shaped, forms[isolated])
+		}
+	}
+	return shaped
+}
+
+type direction int
+
+const (
+	neutral direction = iota
+	leftToRight
+	rightToLeft
+)
+
+func directionOf(r rune) direction {
+	switch {
+	case unicode.IsDigit(r):
+		// Numbers, including Arabic-Indic digits, are always read left to right.
+		return leftToRight
+	case unicode.Is(unicode.Arabic, r):
+		return rightToLeft
+	case unicode.IsLetter(r):
+		return leftToRight
+	default:
+		return neutral
+	}
+}
+
+// Visual shapes text and reorders it for left-to-right drawing. Text starting
+// with an Arabic letter is laid out right to left, with embedded Latin words
+// and numbers kept in reading order; other text only has its Arabic words
+// reversed. It is a simplified form of the Unicode bidirectional algorithm
+// that is sufficient for names, labels and table cells.
+func Visual(text string) string {
+	runes := shape(text)
+
+	base := leftToRight
+	for _, r := range runes {
+		if d := directionOf(r); d != neutral {
+			base = d
+			break
+		}
+	}
+
+	// Resolve each rune's direction: neutrals take the direction of the runs on
+	// both sides when they agree, and the base direction otherwise.
+	dirs := make([]direction, len(runes))
+	for i, r := range runes {
+		dirs[i] = directionOf(r)
+	}
+	for i := 0; i < len(runes); {
+		if dirs[i] != neutral {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && dirs[j] == neutral {
+			j++
+		}
+		before, after := base, base
+		if i > 0 {
+			before = dirs[i-1]
+		}
+		if j < len(runes) {
+			after = dirs[j]
+		}
+		resolved := base
+		if before == after {
+			resolved = before
+		}
+		for k := i; k < j; k++ {
+			dirs[k] = resolved
+		}
+		i = j
+	}
+
+	// Split into runs of one direction, reverse the right-to-left runs and,
+	// for right-to-left text, the order of the runs themselves.
+	var runs [][]rune
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && dirs[j] == dirs[i] {
+			j++
+		}
+		run := slices.Clone(runes[i:j])
+		if dirs[i] == rightToLeft {
+			slices.Reverse(run)
+			for k, r := range run {
+				if m, ok := mirrored[r]; ok {
+					run[k] = m
+				}
+			}
+		}
+		runs = append(runs, run)
+		i = j
+	}
+	if base == rightToLeft {
+		slices.Reverse(runs)
+	}
+
+	var out []rune
+	for _, run := range runs {
+		out = append(out, run...)
+	}
+	return string(out)
+}
+
+// IsRightToLeft reports whether text starts with an Arabic letter.
+func IsRightToLeft(text string) bool {
+	for _, r := range text {
+		if d := directionOf(r); d != neutral {
+			return d == rightToLeft
+		}
+	}
+	return false
+}
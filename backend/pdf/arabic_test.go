@@ -0,0 +1,28 @@
+package pdf
+
+import "testing"
+
+func TestVisual(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "latin", in: "Hifz A (boys)", want: "Hifz A (boys)"},
+		// ر never joins the following letter, so ة stands alone.
+		{name: "word", in: "البقرة", want: "\uFE93\uFEAE\uFED8\uFE92\uFEDF\uFE8D"},
+		{name: "lam alef", in: "لا", want: "\uFEFB"},
+		{name: "right-joining alef", in: "سبأ", want: "\uFE84\uFE92\uFEB3"},
+		{name: "diacritics dropped", in: "مَن", want: "\uFEE6\uFEE3"},
+		{name: "number kept in order", in: "صفحة 12", want: "12 \uFE94\uFEA4\uFED4\uFEBB"},
+		{name: "latin inside arabic", in: "الطالب amina", want: "amina \uFE90\uFEDF\uFE8E\uFEC4\uFEDF\uFE8D"},
+		{name: "brackets mirrored", in: "(نوح)", want: "(\uFEA1\uFEEE\uFEE7)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Visual(tt.in); got != tt.want {
+				t.Fatalf("Visual(%q) = %U, want %U", tt.in, []rune(got), []rune(tt.want))
+			}
+		})
+	}
+}
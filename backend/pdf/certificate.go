@@ -0,0 +1,238 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/go-pdf/fpdf"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultCertificateTemplate is the template used when none is named.
+const DefaultCertificateTemplate = "default"
+
+// certificateFont is the family a template's own font is registered under.
+const certificateFont = "certificate"
+
+// CertificateTemplate describes the look of a certificate. Lines are Go
+// templates over CertificateData, centred one below the other under the title;
+// a line holding just {{.Student}} is printed larger, in bold. The date,
+// teacher and verification code are always printed at the bottom.
+type CertificateTemplate struct {
+	Name  string   `yaml:"name"`
+	Title string   `yaml:"title"`
+	Lines []string `yaml:"lines"`
+	// FontFile is a TrueType font to embed instead of DejaVu Sans. It must
+	// cover Arabic if the certificate text is Arabic.
+	FontFile string `yaml:"font_file"`
+	// AccentColor is the "#rrggbb" colour of the title and border.
+	AccentColor string `yaml:"accent_color"`
+	// BackgroundImage is a PNG or JPEG stretched over the whole page.
+	BackgroundImage string `yaml:"background_image"`
+
+	lines  []*template.Template
+	font   []byte
+	accent [3]int
+}
+
+// CertificateData is what a certificate says.
+type CertificateData struct {
+	Student     string
+	Achievement string
+	Date        string
+	Teacher     string
+	Code        string
+	VerifyURL   string
+}
+
+// CertificateTemplates is a set of templates loaded at startup.
+type CertificateTemplates struct {
+	byName map[string]*CertificateTemplate
+}
+
+type certificateTemplatesFile struct {
+	Templates []CertificateTemplate `yaml:"templates"`
+}
+
+func defaultCertificateTemplate() CertificateTemplate {
+	return CertificateTemplate{
+		Name:  DefaultCertificateTemplate,
+		Title: "شهادة تقدير",
+		Lines: []string{
+			"تشهد إدارة الحلقة بأن الطالب",
+			"{{.Student}}",
+			"قد أتم {{.Achievement}}",
+			"سائلين الله له التوفيق والسداد",
+		},
+		AccentColor: "#1b5e20",
+	}
+}
+
+// LoadCertificateTemplates reads the templates listed in a YAML file. The
+// built-in default template is always available unless the file defines its
+// own; an empty path loads only the default.
+func LoadCertificateTemplates(path string) (*CertificateTemplates, error) {
+	templates := &CertificateTemplates{byName: map[string]*CertificateTemplate{}}
+	list := []CertificateTemplate{defaultCertificateTemplate()}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading certificate templates: %w", err)
+		}
+		var file certificateTemplatesFile
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parsing certificate templates %s: %w", path, err)
+		}
+		list = append(list, file.Templates...)
+	}
+
+	for i := range list {
+		tmpl := list[i]
+		if err := tmpl.prepare(); err != nil {
+			return nil, fmt.Errorf("certificate template %q: %w", tmpl.Name, err)
+		}
+		templates.byName[tmpl.Name] = &tmpl
+	}
+	return templates, nil
+}
+
+// Lookup returns the named template, or the default one for an empty name.
+func (t *CertificateTemplates) Lookup(name string) (*CertificateTemplate, bool) {
+	if name == "" {
+		name = DefaultCertificateTemplate
+	}
+	tmpl, ok := t.byName[name]
+	return tmpl, ok
+}
+
+func (t *CertificateTemplate) prepare() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("name is required")
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		return errors.New("title is required")
+	}
+	t.lines = nil
+	for i, line := range t.Lines {
+		parsed, err := template.New(strconv.Itoa(i)).Option("missingkey=error").Parse(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		t.lines = append(t.lines, parsed)
+	}
+
+	if t.AccentColor == "" {
+		t.AccentColor = "#000000"
+	}
+	accent, err := parseColor(t.AccentColor)
+	if err != nil {
+		return err
+	}
+	t.accent = accent
+
+	if t.FontFile != "" {
+		font, err := os.ReadFile(t.FontFile)
+		if err != nil {
+			return fmt.Errorf("reading font: %w", err)
+		}
+		t.font = font
+	}
+	if t.BackgroundImage != "" {
+		if _, err := os.Stat(t.BackgroundImage); err != nil {
+			return fmt.Errorf("background image: %w", err)
+		}
+	}
+
+	// Render once so mistakes in the lines or the font show up at startup.
+	return t.Render(io.Discard, CertificateData{Student: "-", Achievement: "-", Date: "-", Teacher: "-", Code: "-"})
+}
+
+// Render writes the certificate as a one-page landscape PDF.
+func (t *CertificateTemplate) Render(w io.Writer, data CertificateData) error {
+	lines := make([]string, len(t.lines))
+	for i, line := range t.lines {
+		var buf strings.Builder
+		if err := line.Execute(&buf, data); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		lines[i] = buf.String()
+	}
+
+	doc := NewDocument(Landscape)
+	family := fontFamily
+	if t.font != nil {
+		family = certificateFont
+		doc.AddUTF8FontFromBytes(family, "", t.font)
+		doc.AddUTF8FontFromBytes(family, "B", t.font)
+	}
+	doc.SetAutoPageBreak(false, 0)
+	doc.AddPage()
+	width, height := doc.GetPageSize()
+
+	if t.BackgroundImage != "" {
+		doc.ImageOptions(t.BackgroundImage, 0, 0, width, height, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+	}
+	doc.SetDrawColor(t.accent[0], t.accent[1], t.accent[2])
+	doc.SetLineWidth(1.5)
+	doc.Rect(10, 10, width-20, height-20, "D")
+	doc.SetLineWidth(0.4)
+	doc.Rect(14, 14, width-28, height-28, "D")
+
+	doc.SetTextColor(t.accent[0], t.accent[1], t.accent[2])
+	doc.SetFont(family, "B", 34)
+	doc.SetXY(20, 35)
+	doc.CellFormat(width-40, 18, Visual(t.Title), "", 1, "C", false, 0, "")
+
+	doc.SetTextColor(0, 0, 0)
+	doc.SetY(68)
+	for i, line := range lines {
+		style, size := "", 18.0
+		if t.Lines[i] == "{{.Student}}" {
+			style, size = "B", 26
+		}
+		doc.SetFont(family, style, size)
+		doc.SetX(20)
+		doc.CellFormat(width-40, size*0.6, Visual(line), "", 1, "C", false, 0, "")
+		doc.Ln(3)
+	}
+
+	doc.SetFont(family, "", 13)
+	doc.SetXY(30, height-55)
+	doc.CellFormat((width-60)/2, 8, Visual("التاريخ: "+data.Date), "", 0, "L", false, 0, "")
+	doc.CellFormat((width-60)/2, 8, Visual("المعلم: "+data.Teacher), "", 0, "R", false, 0, "")
+
+	doc.SetFont(family, "", 10)
+	doc.SetTextColor(90, 90, 90)
+	doc.SetXY(20, height-35)
+	doc.CellFormat(width-40, 6, Visual("رمز التحقق: "+data.Code), "", 1, "C", false, 0, "")
+	if data.VerifyURL != "" {
+		doc.SetX(20)
+		doc.CellFormat(width-40, 6, data.VerifyURL, "", 1, "C", false, 0, "")
+	}
+
+	if err := doc.Error(); err != nil {
+		return err
+	}
+	return doc.Output(w)
+}
+
+func parseColor(value string) ([3]int, error) {
+	hex, ok := strings.CutPrefix(value, "#")
+	if !ok || len(hex) != 6 {
+		return [3]int{}, fmt.Errorf("accent_color must look like #rrggbb, got %q", value)
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return [3]int{}, fmt.Errorf("accent_color must look like #rrggbb, got %q", value)
+	}
+	return [3]int{int(n >> 16), int(n >> 8 & 0xff), int(n & 0xff)}, nil
+}
@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCertificateTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "certificates.yaml")
+	content := `templates:
+  - name: juz
+    title: شهادة إتمام جزء
+    lines:
+      - "{{.Student}}"
+      - "أتم {{.Achievement}}"
+    accent_color: "#0d47a1"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := LoadCertificateTemplates(path)
+	if err != nil {
+		t.Fatalf("LoadCertificateTemplates: %v", err)
+	}
+	if _, ok := templates.Lookup(""); !ok {
+		t.Error("the default template is missing")
+	}
+	tmpl, ok := templates.Lookup("juz")
+	if !ok {
+		t.Fatal("template juz is missing")
+	}
+	if tmpl.accent != [3]int{0x0d, 0x47, 0xa1} {
+		t.Errorf("accent = %v", tmpl.accent)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Render(&buf, CertificateData{Student: "أحمد", Achievement: "الجزء 30", Date: "2026-10-19", Teacher: "عمر", Code: "ABCD-EFGH-JKLM"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+}
+
+func TestLoadCertificateTemplatesRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": "templates:\n  - name: a\n    title: b\n    colour: red\n",
+		"missing title": "templates:\n  - name: a\n",
+		"bad color":     "templates:\n  - name: a\n    title: b\n    accent_color: green\n",
+		"bad line":      "templates:\n  - name: a\n    title: b\n    lines: [\"{{.Student\"]\n",
+		"unknown key":   "templates:\n  - name: a\n    title: b\n    lines: [\"{{.Grade}}\"]\n",
+		"missing font":  "templates:\n  - name: a\n    title: b\n    font_file: /nonexistent.ttf\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "certificates.yaml")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadCertificateTemplates(path); err == nil {
+				t.Error("expected an error")
+			} else if !strings.Contains(err.Error(), "certificate") {
+				t.Errorf("error %q does not name the templates", err)
+			}
+		})
+	}
+}
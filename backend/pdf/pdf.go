@@ -0,0 +1,51 @@
+// Package pdf renders the PDF documents served by the API: tabular reports and
+// certificates. Text goes through Visual so Arabic is shaped and laid out right
+// to left.
+package pdf
+
+import (
+	"github.com/go-fonts/dejavu/dejavusans"
+	"github.com/go-fonts/dejavu/dejavusansbold"
+	"github.com/go-pdf/fpdf"
+)
+
+// fontFamily is the embedded font; DejaVu Sans covers Latin and Arabic.
+const fontFamily = "dejavu"
+
+// Page orientations.
+const (
+	Portrait  = "P"
+	Landscape = "L"
+)
+
+// NewDocument creates an A4 document with the embedded fonts registered and
+// selected.
+func NewDocument(orientation string) *fpdf.Fpdf {
+	doc := fpdf.New(orientation, "mm", "A4", "")
+	doc.AddUTF8FontFromBytes(fontFamily, "", dejavusans.TTF)
+	doc.AddUTF8FontFromBytes(fontFamily, "B", dejavusansbold.TTF)
+	doc.SetFont(fontFamily, "", 10)
+	return doc
+}
+
+// SetFont selects the embedded font in the given style ("" or "B") and size.
+func SetFont(doc *fpdf.Fpdf, style string, size float64) {
+	doc.SetFont(fontFamily, style, size)
+}
+
+// Fit shortens text with an ellipsis until it fits in width at the current font.
+// Text is cut at its logical end, which for Arabic is on the left once drawn.
+func Fit(doc *fpdf.Fpdf, text string, width float64) string {
+	if doc.GetStringWidth(Visual(text)) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "…"
+		if doc.GetStringWidth(Visual(candidate)) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package pdf
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	rowHeight    = 7
+	cellPadding  = 1.5
+	headerShade  = 230
+	footerOffset = -12
+)
+
+// TableOptions describes a tabular report.
+type TableOptions struct {
+	Title    string
+	Subtitle string
+	Columns  []string
+	// Widths are relative column widths; columns are equally wide when nil.
+	Widths []float64
+	// RightToLeft lays the columns out from the right edge of the page.
+	RightToLeft bool
+	Landscape   bool
+	// GeneratedAt is printed in the footer of every page.
+	GeneratedAt time.Time
+}
+
+// Table writes a report one row at a time, repeating the column headers on
+// every page. The document is written to the output on Close.
+type Table struct {
+	out    io.Writer
+	doc    *fpdf.Fpdf
+	opts   TableOptions
+	widths []float64
+	bottom float64
+	shade  bool
+}
+
+// NewTable starts a report on its first page.
+func NewTable(out io.Writer, opts TableOptions) (*Table, error) {
+	if len(opts.Columns) == 0 {
+		return nil, errors.New("pdf: a table needs at least one column")
+	}
+	orientation := Portrait
+	if opts.Landscape {
+		orientation = Landscape
+	}
+	doc := NewDocument(orientation)
+	doc.SetAutoPageBreak(false, 0)
+	doc.AliasNbPages("")
+	t := &Table{out: out, doc: doc, opts: opts}
+
+	pageWidth, pageHeight := doc.GetPageSize()
+	left, _, right, bottom := doc.GetMargins()
+	t.bottom = pageHeight - bottom - 10
+	t.widths = columnWidths(opts.Widths, len(opts.Columns), pageWidth-left-right)
+
+	doc.SetFooterFunc(func() {
+		doc.SetY(footerOffset)
+		SetFont(doc, "", 8)
+		doc.SetTextColor(120, 120, 120)
+		footer := opts.GeneratedAt.Format("2006-01-02 15:04")
+		doc.CellFormat(0, 5, footer, "", 0, "L", false, 0, "")
+		doc.CellFormat(0, 5, strconv.Itoa(doc.PageNo())+" / {nb}", "", 0, "R", false, 0, "")
+		doc.SetTextColor(0, 0, 0)
+	})
+
+	doc.AddPage()
+	SetFont(doc, "B", 14)
+	doc.CellFormat(0, 9, Visual(opts.Title), "", 1, t.align(), false, 0, "")
+	if opts.Subtitle != "" {
+		SetFont(doc, "", 10)
+		doc.CellFormat(0, 6, Visual(opts.Subtitle), "", 1, t.align(), false, 0, "")
+	}
+	doc.Ln(3)
+	t.header()
+	return t, doc.Error()
+}
+
+// WriteRow adds a row, starting a new page when the current one is full.
+func (t *Table) WriteRow(cells []string) error {
+	if t.doc.GetY()+rowHeight > t.bottom {
+		t.doc.AddPage()
+		t.header()
+	}
+	SetFont(t.doc, "", 9)
+	t.doc.SetFillColor(245, 245, 245)
+	t.row(cells, t.shade)
+	t.shade = !t.shade
+	return t.doc.Error()
+}
+
+// Close writes the finished document to the output.
+func (t *Table) Close() error {
+	return t.doc.Output(t.out)
+}
+
+func (t *Table) header() {
+	SetFont(t.doc, "B", 9)
+	t.doc.SetFillColor(headerShade, headerShade, headerShade)
+	t.row(t.opts.Columns, true)
+}
+
+func (t *Table) row(cells []string, fill bool) {
+	order := make([]int, len(t.widths))
+	for i := range order {
+		order[i] = i
+		if t.opts.RightToLeft {
+			order[i] = len(order) - 1 - i
+		}
+	}
+
+	left, _, _, _ := t.doc.GetMargins()
+	t.doc.SetX(left)
+	for _, i := range order {
+		text := ""
+		if i < len(cells) {
+			text = cells[i]
+		}
+		text = Visual(Fit(t.doc, text, t.widths[i]-2*cellPadding))
+		t.doc.CellFormat(t.widths[i], rowHeight, text, "1", 0, t.align(), fill, 0, "")
+	}
+	t.doc.Ln(rowHeight)
+}
+
+func (t *Table) align() string {
+	if t.opts.RightToLeft {
+		return "R"
+	}
+	return "L"
+}
+
+func columnWidths(weights []float64, columns int, total float64) []float64 {
+	if len(weights) != columns {
+		weights = make([]float64, columns)
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	widths := make([]float64, columns)
+	for i, w := range weights {
+		widths[i] = total * w / sum
+	}
+	return widths
+}
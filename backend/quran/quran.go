@@ -0,0 +1,181 @@
+// Package quran holds metadata about the Quran as laid out in the standard
+// 604-page Madani mushaf.
+package quran
+
+import "fmt"
+
+const (
+	// Pages is the number of pages in the mushaf.
+	Pages = 604
+	// SurahCount is the number of surahs.
+	SurahCount = 114
+	// JuzCount is the number of ajza'.
+	JuzCount = 30
+)
+
+// Surah describes one surah: its Arabic name, how many ayahs it has and the
+// mushaf page it starts on.
+type Surah struct {
+	Number    int    `json:"number"`
+	Name      string `json:"name"`
+	Ayahs     int    `json:"ayahs"`
+	StartPage int    `json:"start_page"`
+}
+
+// Surahs returns every surah in order.
+func Surahs() []Surah {
+	return append([]Surah(nil), surahs[:]...)
+}
+
+// SurahByNumber returns the surah with the given number (1-114).
+func SurahByNumber(number int) (Surah, bool) {
+	if number < 1 || number > SurahCount {
+		return Surah{}, false
+	}
+	return surahs[number-1], true
+}
+
+// SurahName returns the Arabic name of a surah, or a numbered placeholder for
+// numbers outside 1-114.
+func SurahName(number int) string {
+	if surah, ok := SurahByNumber(number); ok {
+		return surah.Name
+	}
+	return fmt.Sprintf("سورة %d", number)
+}
+
+// JuzPages returns the first and last page of a juz (1-30).
+func JuzPages(juz int) (first, last int, ok bool) {
+	if juz < 1 || juz > JuzCount {
+		return 0, 0, false
+	}
+	first = juzStartPages[juz-1]
+	last = Pages
+	if juz < JuzCount {
+		last = juzStartPages[juz] - 1
+	}
+	return first, last, true
+}
+
+var juzStartPages = [JuzCount]int{
+	1, 22, 42, 62, 82, 102, 121, 142, 162, 182,
+	201, 222, 242, 262, 282, 302, 322, 342, 362, 382,
+	402, 422, 442, 462, 482, 502, 522, 542, 562, 582,
+}
+
+var surahs = [SurahCount]Surah{
+	{Number: 1, Name: "الفاتحة", Ayahs: 7, StartPage: 1},
+	{Number: 2, Name: "البقرة", Ayahs: 286, StartPage: 2},
+	{Number: 3, Name: "آل عمران", Ayahs: 200, StartPage: 50},
+	{Number: 4, Name: "النساء", Ayahs: 176, StartPage: 77},
+	{Number: 5, Name: "المائدة", Ayahs: 120, StartPage: 106},
+	{Number: 6, Name: "الأنعام", Ayahs: 165, StartPage: 128},
+	{Number: 7, Name: "الأعراف", Ayahs: 206, StartPage: 151},
+	{Number: 8, Name: "الأنفال", Ayahs: 75, StartPage: 177},
+	{Number: 9, Name: "التوبة", Ayahs: 129, StartPage: 187},
+	{Number: 10, Name: "يونس", Ayahs: 109, StartPage: 208},
+	{Number: 11, Name: "هود", Ayahs: 123, StartPage: 221},
+	{Number: 12, Name: "يوسف", Ayahs: 111, StartPage: 235},
+	{Number: 13, Name: "الرعد", Ayahs: 43, StartPage: 249},
+	{Number: 14, Name: "إبراهيم", Ayahs: 52, StartPage: 255},
+	{Number: 15, Name: "الحجر", Ayahs: 99, StartPage: 262},
+	{Number: 16, Name: "النحل", Ayahs: 128, StartPage: 267},
+	{Number: 17, Name: "الإسراء", Ayahs: 111, StartPage: 282},
+	{Number: 18, Name: "الكهف", Ayahs: 110, StartPage: 293},
+	{Number: 19, Name: "مريم", Ayahs: 98, StartPage: 305},
+	{Number: 20, Name: "طه", Ayahs: 135, StartPage: 312},
+	{Number: 21, Name: "الأنبياء", Ayahs: 112, StartPage: 322},
+	{Number: 22, Name: "الحج", Ayahs: 78, StartPage: 332},
+	{Number: 23, Name: "المؤمنون", Ayahs: 118, StartPage: 342},
+	{Number: 24, Name: "النور", Ayahs: 64, StartPage: 350},
+	{Number: 25, Name: "الفرقان", Ayahs: 77, StartPage: 359},
+	{Number: 26, Name: "الشعراء", Ayahs: 227, StartPage: 367},
+	{Number: 27, Name: "النمل", Ayahs: 93, StartPage: 377},
+	{Number: 28, Name: "القصص", Ayahs: 88, StartPage: 385},
+	{Number: 29, Name: "العنكبوت", Ayahs: 69, StartPage: 396},
+	{Number: 30, Name: "الروم", Ayahs: 60, StartPage: 404},
+	{Number: 31, Name: "لقمان", Ayahs: 34, StartPage: 411},
+	{Number: 32, Name: "السجدة", Ayahs: 30, StartPage: 415},
+	{Number: 33, Name: "الأحزاب", Ayahs: 73, StartPage: 418},
+	{Number: 34, Name: "سبأ", Ayahs: 54, StartPage: 428},
+	{Number: 35, Name: "فاطر", Ayahs: 45, StartPage: 434},
+	{Number: 36, Name: "يس", Ayahs: 83, StartPage: 440},
+	{Number: 37, Name: "الصافات", Ayahs: 182, StartPage: 446},
+	{Number: 38, Name: "ص", Ayahs: 88, StartPage: 453},
+	{Number: 39, Name: "الزمر", Ayahs: 75, StartPage: 458},
+	{Number: 40, Name: "غافر", Ayahs: 85, StartPage: 467},
+	{Number: 41, Name: "فصلت", Ayahs: 54, StartPage: 477},
+	{Number: 42, Name: "الشورى", Ayahs: 53, StartPage: 483},
+	{Number: 43, Name: "الزخرف", Ayahs: 89, StartPage: 489},
+	{Number: 44, Name: "الدخان", Ayahs: 59, StartPage: 496},
+	{Number: 45, Name: "الجاثية", Ayahs: 37, StartPage: 499},
+	{Number: 46, Name: "الأحقاف", Ayahs: 35, StartPage: 502},
+	{Number: 47, Name: "محمد", Ayahs: 38, StartPage: 507},
+	{Number: 48, Name: "الفتح", Ayahs: 29, StartPage: 511},
+	{Number: 49, Name: "الحجرات", Ayahs: 18, StartPage: 515},
+	{Number: 50, Name: "ق", Ayahs: 45, StartPage: 518},
+	{Number: 51, Name: "الذاريات", Ayahs: 60, StartPage: 520},
+	{Number: 52, Name: "الطور", Ayahs: 49, StartPage: 523},
+	{Number: 53, Name: "النجم", Ayahs: 62, StartPage: 526},
+	{Number: 54, Name: "القمر", Ayahs: 55, StartPage: 528},
+	{Number: 55, Name: "الرحمن", Ayahs: 78, StartPage: 531},
+	{Number: 56, Name: "الواقعة", Ayahs: 96, StartPage: 534},
+	{Number: 57, Name: "الحديد", Ayahs: 29, StartPage: 537},
+	{Number: 58, Name: "المجادلة", Ayahs: 22, StartPage: 542},
+	{Number: 59, Name: "الحشر", Ayahs: 24, StartPage: 545},
+	{Number: 60, Name: "الممتحنة", Ayahs: 13, StartPage: 549},
+	{Number: 61, Name: "الصف", Ayahs: 14, StartPage: 551},
+	{Number: 62, Name: "الجمعة", Ayahs: 11, StartPage: 553},
+	{Number: 63, Name: "المنافقون", Ayahs: 11, StartPage: 554},
+	{Number: 64, Name: "التغابن", Ayahs: 18, StartPage: 556},
+	{Number: 65, Name: "الطلاق", Ayahs: 12, StartPage: 558},
+	{Number: 66, Name: "التحريم", Ayahs: 12, StartPage: 560},
+	{Number: 67, Name: "الملك", Ayahs: 30, StartPage: 562},
+	{Number: 68, Name: "القلم", Ayahs: 52, StartPage: 564},
+	{Number: 69, Name: "الحاقة", Ayahs: 52, StartPage: 566},
+	{Number: 70, Name: "المعارج", Ayahs: 44, StartPage: 568},
+	{Number: 71, Name: "نوح", Ayahs: 28, StartPage: 570},
+	{Number: 72, Name: "الجن", Ayahs: 28, StartPage: 572},
+	{Number: 73, Name: "المزمل", Ayahs: 20, StartPage: 574},
+	{Number: 74, Name: "المدثر", Ayahs: 56, StartPage: 575},
+	{Number: 75, Name: "القيامة", Ayahs: 40, StartPage: 577},
+	{Number: 76, Name: "الإنسان", Ayahs: 31, StartPage: 578},
+	{Number: 77, Name: "المرسلات", Ayahs: 50, StartPage: 580},
+	{Number: 78, Name: "النبأ", Ayahs: 40, StartPage: 582},
+	{Number: 79, Name: "النازعات", Ayahs: 46, StartPage: 583},
+	{Number: 80, Name: "عبس", Ayahs: 42, StartPage: 585},
+	{Number: 81, Name: "التكوير", Ayahs: 29, StartPage: 586},
+	{Number: 82, Name: "الإنفطار", Ayahs: 19, StartPage: 587},
+	{Number: 83, Name: "المطففين", Ayahs: 36, StartPage: 587},
+	{Number: 84, Name: "الإنشقاق", Ayahs: 25, StartPage: 589},
+	{Number: 85, Name: "البروج", Ayahs: 22, StartPage: 590},
+	{Number: 86, Name: "الطارق", Ayahs: 17, StartPage: 591},
+	{Number: 87, Name: "الأعلى", Ayahs: 19, StartPage: 591},
+	{Number: 88, Name: "الغاشية", Ayahs: 26, StartPage: 592},
+	{Number: 89, Name: "الفجر", Ayahs: 30, StartPage: 593},
+	{Number: 90, Name: "البلد", Ayahs: 20, StartPage: 594},
+	{Number: 91, Name: "الشمس", Ayahs: 15, StartPage: 595},
+	{Number: 92, Name: "الليل", Ayahs: 21, StartPage: 595},
+	{Number: 93, Name: "الضحى", Ayahs: 11, StartPage: 596},
+	{Number: 94, Name: "الشرح", Ayahs: 8, StartPage: 596},
+	{Number: 95, Name: "التين", Ayahs: 8, StartPage: 597},
+	{Number: 96, Name: "العلق", Ayahs: 19, StartPage: 597},
+	{Number: 97, Name: "القدر", Ayahs: 5, StartPage: 598},
+	{Number: 98, Name: "البينة", Ayahs: 8, StartPage: 598},
+	{Number: 99, Name: "الزلزلة", Ayahs: 8, StartPage: 599},
+	{Number: 100, Name: "العاديات", Ayahs: 11, StartPage: 599},
+	{Number: 101, Name: "القارعة", Ayahs: 11, StartPage: 600},
+	{Number: 102, Name: "التكاثر", Ayahs: 8, StartPage: 600},
+	{Number: 103, Name: "العصر", Ayahs: 3, StartPage: 601},
+	{Number: 104, Name: "الهمزة", Ayahs: 9, StartPage: 601},
+	{Number: 105, Name: "الفيل", Ayahs: 5, StartPage: 601},
+	{Number: 106, Name: "قريش", Ayahs: 4, StartPage: 602},
+	{Number: 107, Name: "الماعون", Ayahs: 7, StartPage: 602},
+	{Number: 108, Name: "الكوثر", Ayahs: 3, StartPage: 602},
+	{Number: 109, Name: "الكافرون", Ayahs: 6, StartPage: 603},
+	{Number: 110, Name: "النصر", Ayahs: 3, StartPage: 603},
+	{Number: 111, Name: "المسد", Ayahs: 5, StartPage: 603},
+	{Number: 112, Name: "الإخلاص", Ayahs: 4, StartPage: 604},
+	{Number: 113, Name: "الفلق", Ayahs: 5, StartPage: 604},
+	{Number: 114, Name: "الناس", Ayahs: 6, StartPage: 604},
+}
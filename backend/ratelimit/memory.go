@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryWindow struct {
+	start time.Time
+	end   time.Time
+	count int
+}
+
+// MemoryStore keeps counters in process memory. Limits are per instance, so
+// use PostgresStore when running several replicas.
+type MemoryStore struct {
+	mu      sync.Mutex
+	windows map[string]*memoryWindow
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: map[string]*memoryWindow{}, now: time.Now}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(_ context.Context, key string, budget Budget) (Result, error) {
+	start := windowStart(s.now(), budget.Window)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows[key]
+	if !ok || !w.start.Equal(start) {
+		w = &memoryWindow{start: start, end: start.Add(budget.Window)}
+		s.windows[key] = w
+	}
+	w.count++
+	return result(w.count, start, budget), nil
+}
+
+// Sweep implements Store.
+func (s *MemoryStore) Sweep(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, w := range s.windows {
+		if !w.end.After(now) {
+			delete(s.windows, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	budget := Budget{Limit: 2, Window: time.Minute}
+	ctx := context.Background()
+
+	for i, want := range []Result{
+		{Allowed: true, Limit: 2, Remaining: 1},
+		{Allowed: true, Limit: 2, Remaining: 0},
+		{Allowed: false, Limit: 2, Remaining: 0},
+	} {
+		got, err := store.Take(ctx, "k", budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Allowed != want.Allowed || got.Remaining != want.Remaining || got.Limit != want.Limit {
+			t.Fatalf("take %d = %+v, want %+v", i+1, got, want)
+		}
+		if !got.Reset.Equal(now.Add(time.Minute)) {
+			t.Fatalf("take %d reset = %v, want %v", i+1, got.Reset, now.Add(time.Minute))
+		}
+	}
+
+	if got, _ := store.Take(ctx, "other", budget); !got.Allowed {
+		t.Fatal("a different key should have its own budget")
+	}
+
+	now = now.Add(time.Minute)
+	if got, _ := store.Take(ctx, "k", budget); !got.Allowed || got.Remaining != 1 {
+		t.Fatalf("new window = %+v, want a fresh budget", got)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	_, _ = store.Take(context.Background(), "k", Budget{Limit: 1, Window: time.Minute})
+
+	_ = store.Sweep(context.Background(), now.Add(30*time.Second))
+	if len(store.windows) != 1 {
+		t.Fatal("sweep removed a window that is still open")
+	}
+	_ = store.Sweep(context.Background(), now.Add(time.Minute))
+	if len(store.windows) != 0 {
+		t.Fatal("sweep kept an expired window")
+	}
+}
+
+func TestParseBudget(t *testing.T) {
+	if b, err := ParseBudget("10/1m"); err != nil || b != (Budget{Limit: 10, Window: time.Minute}) {
+		t.Fatalf("ParseBudget(10/1m) = %v, %v", b, err)
+	}
+	for _, s := range []string{"10", "x/1m", "10/x", "-1/1m", "10/0s"} {
+		if _, err := ParseBudget(s); err == nil {
+			t.Errorf("ParseBudget(%q) succeeded, want an error", s)
+		}
+	}
+}
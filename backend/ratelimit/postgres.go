@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresStore keeps counters in the rate_limits table so every instance
+// shares the same budgets.
+type PostgresStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresStore creates a PostgresStore.
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take implements Store. The counter is reset atomically when a new window starts.
+func (s *PostgresStore) Take(ctx context.Context, key string, budget Budget) (Result, error) {
+	start := windowStart(time.Now(), budget.Window)
+	query := `
+		INSERT INTO rate_limits (key, window_start, window_end, count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limits.window_start = EXCLUDED.window_start THEN rate_limits.count + 1 ELSE 1 END,
+			window_start = EXCLUDED.window_start,
+			window_end = EXCLUDED.window_end
+		RETURNING count
+	`
+	var count int
+	if err := s.db.QueryRow(ctx, query, key, start, start.Add(budget.Window)).Scan(&count); err != nil {
+		return Result{}, err
+	}
+	return result(count, start, budget), nil
+}
+
+// Sweep implements Store.
+func (s *PostgresStore) Sweep(ctx context.Context, now time.Time) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM rate_limits WHERE window_end <= $1", now)
+	return err
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Budget is the number of requests allowed per fixed window. A zero Limit
+// disables limiting.
+type Budget struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParseBudget parses budgets written as "<limit>/<window>", e.g. "10/1m".
+func ParseBudget(s string) (Budget, error) {
+	limit, window, found := strings.Cut(s, "/")
+	if !found {
+		return Budget{}, fmt.Errorf("rate limit %q must look like 10/1m", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n < 0 {
+		return Budget{}, fmt.Errorf("rate limit %q has an invalid limit", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d <= 0 {
+		return Budget{}, fmt.Errorf("rate limit %q has an invalid window", s)
+	}
+	return Budget{Limit: n, Window: d}, nil
+}
+
+// UnmarshalText lets budgets be written as "10/1m" in config files.
+func (b *Budget) UnmarshalText(text []byte) error {
+	parsed, err := ParseBudget(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// String formats the budget as "<limit>/<window>".
+func (b Budget) String() string {
+	return fmt.Sprintf("%d/%s", b.Limit, b.Window)
+}
+
+// Enabled reports whether the budget limits anything.
+func (b Budget) Enabled() bool {
+	return b.Limit > 0
+}
+
+// Result describes the state of a key's budget after taking from it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Store counts requests per key in fixed windows.
+type Store interface {
+	// Take records one request for key and reports whether it fits the budget.
+	Take(ctx context.Context, key string, budget Budget) (Result, error)
+	// Sweep drops windows that ended before now.
+	Sweep(ctx context.Context, now time.Time) error
+}
+
+// windowStart returns the start of the fixed window containing now.
+func windowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
+
+func result(count int, start time.Time, budget Budget) Result {
+	remaining := budget.Limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Allowed:   count <= budget.Limit,
+		Limit:     budget.Limit,
+		Remaining: remaining,
+		Reset:     start.Add(budget.Window),
+	}
+}
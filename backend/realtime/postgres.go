@@ -0,0 +1,100 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/logging"
+)
+
+const (
+	// notifyChannel is the Postgres channel events travel on.
+	notifyChannel = "realtime_events"
+	// maxNotifyPayload is the largest payload Postgres accepts in a notification.
+	maxNotifyPayload = 8000
+	// reconnectDelay is the wait before listening again after the connection failed.
+	reconnectDelay = 2 * time.Second
+)
+
+// PostgresBroker sends events through Postgres LISTEN/NOTIFY so that every
+// instance receives them. Run must be running for events to be delivered,
+// including to the subscribers of the publishing instance.
+type PostgresBroker struct {
+	db  *pgxpool.Pool
+	hub *hub
+}
+
+// NewPostgresBroker creates a PostgresBroker.
+func NewPostgresBroker(db *pgxpool.Pool) *PostgresBroker {
+	return &PostgresBroker{db: db, hub: newHub()}
+}
+
+// Publish implements Broker.
+func (b *PostgresBroker) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		return fmt.Errorf("realtime: %s event is %d bytes, over the %d byte limit", event.Type, len(payload), maxNotifyPayload)
+	}
+	_, err = b.db.Exec(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(payload))
+	return err
+}
+
+// Subscribe implements Broker.
+func (b *PostgresBroker) Subscribe(classIDs []int) *Subscription {
+	return b.hub.subscribe(classIDs)
+}
+
+// Close implements Broker.
+func (b *PostgresBroker) Close() {
+	b.hub.close()
+}
+
+// Run listens for events on a dedicated connection and delivers them to this
+// instance's subscribers until the context is cancelled, reconnecting after
+// failures. Events sent while it is reconnecting are lost.
+func (b *PostgresBroker) Run(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		logging.FromContext(ctx).Error("listening for realtime events failed", slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *PostgresBroker) listen(ctx context.Context) error {
+	conn, err := pgx.ConnectConfig(ctx, b.db.Config().ConnConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			logging.FromContext(ctx).Warn("ignoring malformed realtime event", slog.Any("error", err))
+			continue
+		}
+		b.hub.deliver(event)
+	}
+}
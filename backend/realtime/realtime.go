@@ -0,0 +1,145 @@
+// Package realtime fans class-scoped events out to live subscribers, such as
+// the SSE stream behind /api/events.
+//
+// A MemoryBroker delivers events within one process. A PostgresBroker sends
+// them through LISTEN/NOTIFY so every instance of a multi-instance deployment
+// delivers them to its own subscribers.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer is how many events may wait for a subscriber. One that
+// falls further behind is dropped and has to reconnect.
+const subscriptionBuffer = 64
+
+// Event is something that happened in a class.
+type Event struct {
+	Type    string          `json:"type"`
+	ClassID int             `json:"class_id"`
+	Data    json.RawMessage `json:"data"`
+	Time    time.Time       `json:"time"`
+}
+
+// Broker publishes events and hands them to the subscribers of their class.
+type Broker interface {
+	Publish(ctx context.Context, event Event) error
+	// Subscribe returns a subscription to the events of the given classes.
+	Subscribe(classIDs []int) *Subscription
+	// Close ends every subscription and refuses new ones, so streams finish
+	// before the server shuts down.
+	Close()
+}
+
+// Subscription receives the events of a set of classes until it is closed,
+// either by the subscriber, by the broker shutting down or because the
+// subscriber fell behind.
+type Subscription struct {
+	events  chan Event
+	classes map[int]bool
+	hub     *hub
+}
+
+// Events returns the channel events arrive on. It is closed when the
+// subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// hub tracks the subscribers of one process.
+type hub struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func newHub() *hub {
+	return &hub{subs: map[*Subscription]struct{}{}}
+}
+
+func (h *hub) subscribe(classIDs []int) *Subscription {
+	s := &Subscription{events: make(chan Event, subscriptionBuffer), classes: map[int]bool{}, hub: h}
+	for _, id := range classIDs {
+		s.classes[id] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(s.events)
+		return s
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+func (h *hub) remove(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.events)
+	}
+}
+
+// deliver hands the event to every subscriber of its class without blocking.
+func (h *hub) deliver(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if !s.classes[event.ClassID] {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			delete(h.subs, s)
+			close(s.events)
+		}
+	}
+}
+
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		delete(h.subs, s)
+		close(s.events)
+	}
+}
+
+// MemoryBroker delivers events to the subscribers of this process only.
+type MemoryBroker struct {
+	hub *hub
+}
+
+// NewMemoryBroker creates a MemoryBroker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{hub: newHub()}
+}
+
+// Publish implements Broker.
+func (b *MemoryBroker) Publish(_ context.Context, event Event) error {
+	b.hub.deliver(event)
+	return nil
+}
+
+// Subscribe implements Broker.
+func (b *MemoryBroker) Subscribe(classIDs []int) *Subscription {
+	return b.hub.subscribe(classIDs)
+}
+
+// Close implements Broker.
+func (b *MemoryBroker) Close() {
+	b.hub.close()
+}
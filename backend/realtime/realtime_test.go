@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryBrokerScopesEventsToClasses(t *testing.T) {
+	broker := NewMemoryBroker()
+	first := broker.Subscribe([]int{1})
+	both := broker.Subscribe([]int{1, 2})
+	defer first.Close()
+	defer both.Close()
+
+	_ = broker.Publish(context.Background(), Event{Type: "progress.changed", ClassID: 2})
+	_ = broker.Publish(context.Background(), Event{Type: "attendance.marked", ClassID: 1})
+
+	if event := <-first.Events(); event.Type != "attendance.marked" {
+		t.Errorf("first subscriber got %q", event.Type)
+	}
+	if event := <-both.Events(); event.Type != "progress.changed" {
+		t.Errorf("second subscriber got %q first", event.Type)
+	}
+	if event := <-both.Events(); event.Type != "attendance.marked" {
+		t.Errorf("second subscriber got %q second", event.Type)
+	}
+	select {
+	case event := <-first.Events():
+		t.Errorf("first subscriber got an extra %q", event.Type)
+	default:
+	}
+}
+
+func TestMemoryBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewMemoryBroker()
+	sub := broker.Subscribe([]int{1})
+	for i := 0; i <= subscriptionBuffer; i++ {
+		_ = broker.Publish(context.Background(), Event{ClassID: 1})
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != subscriptionBuffer {
+		t.Errorf("received %d events before being dropped, want %d", received, subscriptionBuffer)
+	}
+	sub.Close() // closing a dropped subscription is harmless
+}
+
+func TestMemoryBrokerClose(t *testing.T) {
+	broker := NewMemoryBroker()
+	sub := broker.Subscribe([]int{1})
+	broker.Close()
+	if _, ok := <-sub.Events(); ok {
+		t.Error("subscription still open after Close")
+	}
+	if _, ok := <-broker.Subscribe([]int{1}).Events(); ok {
+		t.Error("subscription opened after Close")
+	}
+}
+
+func TestWriteSSE(t *testing.T) {
+	var out strings.Builder
+	event := Event{Type: "progress.changed", ClassID: 3, Data: []byte(`{"page":5}`), Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	if err := WriteSSE(&out, event); err != nil {
+		t.Fatal(err)
+	}
+	want := "event: progress.changed\ndata: {\"type\":\"progress.changed\",\"class_id\":3,\"data\":{\"page\":5},\"time\":\"2024-01-02T03:04:05Z\"}\n\n"
+	if out.String() != want {
+		t.Errorf("WriteSSE wrote %q, want %q", out.String(), want)
+	}
+}
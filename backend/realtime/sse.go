@@ -0,0 +1,24 @@
+package realtime
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// WriteSSE writes the event as a server-sent event named after its type, with
+// the whole event as JSON data.
+func WriteSSE(w io.Writer, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "event: "+event.Type+"\ndata: "+string(data)+"\n\n")
+	return err
+}
+
+// WriteHeartbeat writes an SSE comment, which keeps idle connections from
+// being closed by proxies.
+func WriteHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ": ping\n\n")
+	return err
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// AttendanceRepository defines the interface for attendance data operations.
+// Dates are calendar dates in YYYY-MM-DD form.
+type AttendanceRepository interface {
+	FindClassMemberIDs(ctx context.Context, classID int) ([]int, error)
+	MarkAttendance(ctx context.Context, classID int, date string, markedBy int, records []models.AttendanceRecord) error
+	FindAttendance(ctx context.Context, classID int, date string) ([]models.AttendanceRecord, error)
+}
+
+type pgxAttendanceRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAttendanceRepository creates a new attendance repository.
+func NewAttendanceRepository(db *pgxpool.Pool) AttendanceRepository {
+	return &pgxAttendanceRepository{db: db}
+}
+
+// FindClassMemberIDs returns the IDs of the class's current members.
+func (r *pgxAttendanceRepository) FindClassMemberIDs(ctx context.Context, classID int) ([]int, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT cm.student_id FROM class_members cm
+		JOIN users u ON u.id = cm.student_id AND u.deleted_at IS NULL
+		WHERE cm.class_id = $1 AND ($2::int IS NULL OR u.organization_id = $2)
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, classID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkAttendance records the marks for a day, replacing any earlier mark of
+// the same students on that day. It returns ErrClassNotFound when the class is
+// not in the organization.
+func (r *pgxAttendanceRepository) MarkAttendance(ctx context.Context, classID int, date string, markedBy int, records []models.AttendanceRecord) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO attendance (class_id, student_id, date, status, marked_by)
+		SELECT c.id, $2, $3::date, $4, $5
+		FROM classes c WHERE c.id = $1 AND ` + tenantFilter("c.organization_id", 6) + `
+		ON CONFLICT (class_id, student_id, date) DO UPDATE SET
+			status = EXCLUDED.status, marked_by = EXCLUDED.marked_by, created_at = NOW()
+	`
+	batch := &pgx.Batch{}
+	for _, record := range records {
+		batch.Queue(query, classID, record.StudentID, date, record.Status, markedBy, tenant)
+	}
+	results := conn(ctx, r.db).SendBatch(ctx, batch)
+	defer results.Close()
+	for range records {
+		tag, err := results.Exec()
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrClassNotFound
+		}
+	}
+	return nil
+}
+
+// FindAttendance lists every current class member with their mark for the day,
+// ordered by username. Unmarked members have an empty status.
+func (r *pgxAttendanceRepository) FindAttendance(ctx context.Context, classID int, date string) ([]models.AttendanceRecord, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT u.id, u.username, COALESCE(a.status, '')
+		FROM class_members cm
+		JOIN users u ON u.id = cm.student_id AND u.deleted_at IS NULL
+		LEFT JOIN attendance a ON a.class_id = cm.class_id AND a.student_id = u.id AND a.date = $2::date
+		WHERE cm.class_id = $1 AND ($3::int IS NULL OR u.organization_id = $3)
+		ORDER BY u.username
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, classID, date, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.AttendanceRecord{}
+	for rows.Next() {
+		var record models.AttendanceRecord
+		if err := rows.Scan(&record.StudentID, &record.Username, &record.Status); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
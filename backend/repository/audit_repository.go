@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// AuditRepository defines the interface for audit event storage.
+type AuditRepository interface {
+	CreateEvent(ctx context.Context, event *models.AuditEvent) error
+	FindEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type pgxAuditRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAuditRepository creates a new audit repository.
+func NewAuditRepository(db *pgxpool.Pool) AuditRepository {
+	return &pgxAuditRepository{db: db}
+}
+
+// CreateEvent inserts an audit event and fills in its ID and timestamp. Events
+// recorded outside an organization, like those of background jobs, have none.
+func (r *pgxAuditRepository) CreateEvent(ctx context.Context, event *models.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (organization_id, actor_id, actor_role, action, entity_type, entity_id, changes, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRow(ctx, query, optionalTenantID(ctx), event.ActorID, event.ActorRole, event.Action, event.EntityType, event.EntityID, string(changes), event.IP).
+		Scan(&event.ID, &event.CreatedAt)
+}
+
+// FindEvents retrieves audit events matching the filter, newest first.
+func (r *pgxAuditRepository) FindEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conditions := []string{"($1::int IS NULL OR organization_id = $1)"}
+	args := []interface{}{tenant}
+	argId := 2
+
+	if filter.ActorID != nil {
+		conditions = append(conditions, fmt.Sprintf("actor_id=$%d", argId))
+		args = append(args, *filter.ActorID)
+		argId++
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, fmt.Sprintf("action=$%d", argId))
+		args = append(args, filter.Action)
+		argId++
+	}
+	if filter.EntityType != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_type=$%d", argId))
+		args = append(args, filter.EntityType)
+		argId++
+	}
+	if filter.EntityID != nil {
+		conditions = append(conditions, fmt.Sprintf("entity_id=$%d", argId))
+		args = append(args, *filter.EntityID)
+		argId++
+	}
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argId))
+		args = append(args, *filter.From)
+		argId++
+	}
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argId))
+		args = append(args, *filter.To)
+		argId++
+	}
+
+	query := "SELECT id, actor_id, actor_role, action, entity_type, entity_id, changes, ip, created_at FROM audit_events WHERE " + strings.Join(conditions, " AND ")
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argId, argId+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var event models.AuditEvent
+		var changes []byte
+		if err := rows.Scan(&event.ID, &event.ActorID, &event.ActorRole, &event.Action, &event.EntityType, &event.EntityID, &changes, &event.IP, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &event.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// ErrCertificateNotFound is returned when no certificate has the given code.
+var ErrCertificateNotFound = errors.New("certificate not found")
+
+const certificateColumns = "id, code, student_id, student_name, teacher_id, teacher_name, achievement, juz, khatma_id, template, issued_at"
+
+// CertificateRepository defines the interface for certificate data operations.
+type CertificateRepository interface {
+	CreateCertificate(ctx context.Context, certificate *models.Certificate) error
+	FindCertificateByCode(ctx context.Context, code string) (*models.Certificate, error)
+	FindCertificatesForStudent(ctx context.Context, studentID int) ([]models.Certificate, error)
+}
+
+type pgxCertificateRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewCertificateRepository creates a new certificate repository.
+func NewCertificateRepository(db *pgxpool.Pool) CertificateRepository {
+	return &pgxCertificateRepository{db: db}
+}
+
+// CreateCertificate stores a certificate and fills in its ID and issue time.
+// It returns ErrUserNotFound when the student is not in the organization.
+func (r *pgxCertificateRepository) CreateCertificate(ctx context.Context, certificate *models.Certificate) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO certificates (code, student_id, student_name, teacher_id, teacher_name, achievement, juz, khatma_id, template)
+		SELECT $1, u.id, $3, $4, $5, $6, $7, $8, $9
+		FROM users u WHERE u.id = $2 AND ` + tenantFilter("u.organization_id", 10) + `
+		RETURNING id, issued_at
+	`
+	err = r.db.QueryRow(ctx, query,
+		certificate.Code, certificate.StudentID, certificate.StudentName, certificate.TeacherID, certificate.TeacherName,
+		certificate.Achievement, certificate.Juz, certificate.KhatmaID, certificate.Template, tenant,
+	).Scan(&certificate.ID, &certificate.IssuedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// FindCertificateByCode retrieves the certificate with the given verification
+// code. Codes are unique across organizations, so public verification may look
+// them up in all of them.
+func (r *pgxCertificateRepository) FindCertificateByCode(ctx context.Context, code string) (*models.Certificate, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + certificateColumns + ` FROM certificates
+		WHERE code = $1 AND student_id IN (SELECT id FROM users WHERE $2::int IS NULL OR organization_id = $2)`
+	rows, err := r.db.Query(ctx, query, code, tenant)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := scanCertificates(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(certificates) == 0 {
+		return nil, ErrCertificateNotFound
+	}
+	return &certificates[0], nil
+}
+
+// FindCertificatesForStudent lists a student's certificates, newest first.
+func (r *pgxCertificateRepository) FindCertificatesForStudent(ctx context.Context, studentID int) ([]models.Certificate, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + certificateColumns + ` FROM certificates
+		WHERE student_id = $1 AND student_id IN (SELECT id FROM users WHERE $2::int IS NULL OR organization_id = $2)
+		ORDER BY issued_at DESC, id DESC`
+	rows, err := r.db.Query(ctx, query, studentID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return scanCertificates(rows)
+}
+
+func scanCertificates(rows pgx.Rows) ([]models.Certificate, error) {
+	defer rows.Close()
+
+	certificates := []models.Certificate{}
+	for rows.Next() {
+		var c models.Certificate
+		err := rows.Scan(&c.ID, &c.Code, &c.StudentID, &c.StudentName, &c.TeacherID, &c.TeacherName,
+			&c.Achievement, &c.Juz, &c.KhatmaID, &c.Template, &c.IssuedAt)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, c)
+	}
+	return certificates, rows.Err()
+}
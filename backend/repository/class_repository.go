@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	// ErrClassNotFound is returned when a class does not exist.
+	ErrClassNotFound = errors.New("class not found")
+	// ErrClassMemberNotFound is returned when a student is not in the class.
+	ErrClassMemberNotFound = errors.New("class member not found")
+)
+
+// ClassRepository defines the interface for class membership lookups.
+type ClassRepository interface {
+	IsStudentTaughtBy(ctx context.Context, studentID, teacherID int) (bool, error)
+	IsClassTaughtBy(ctx context.Context, classID, teacherID int) (bool, error)
+	IsClassMember(ctx context.Context, classID, studentID int) (bool, error)
+	FindClassName(ctx context.Context, classID int) (string, error)
+	FindTeacherClassIDs(ctx context.Context, teacherID int) ([]int, error)
+	FindStudentClassIDs(ctx context.Context, studentID int) ([]int, error)
+	AddClassMember(ctx context.Context, classID, studentID int) (bool, error)
+	RemoveClassMember(ctx context.Context, classID, studentID int) error
+}
+
+type pgxClassRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewClassRepository creates a new class repository.
+func NewClassRepository(db *pgxpool.Pool) ClassRepository {
+	return &pgxClassRepository{db: db}
+}
+
+// IsStudentTaughtBy reports whether the student is a member of one of the teacher's classes.
+func (r *pgxClassRepository) IsStudentTaughtBy(ctx context.Context, studentID, teacherID int) (bool, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM class_members cm
+			JOIN classes c ON c.id = cm.class_id
+			WHERE cm.student_id = $1 AND c.teacher_id = $2 AND ` + tenantFilter("c.organization_id", 3) + `
+		)
+	`
+	var taught bool
+	err = r.db.QueryRow(ctx, query, studentID, teacherID, tenant).Scan(&taught)
+	return taught, err
+}
+
+// IsClassTaughtBy reports whether the teacher teaches the class.
+func (r *pgxClassRepository) IsClassTaughtBy(ctx context.Context, classID, teacherID int) (bool, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+	query := "SELECT EXISTS (SELECT 1 FROM classes WHERE id = $1 AND teacher_id = $2 AND " + tenantFilter("organization_id", 3) + ")"
+	var taught bool
+	err = r.db.QueryRow(ctx, query, classID, teacherID, tenant).Scan(&taught)
+	return taught, err
+}
+
+// IsClassMember reports whether the student is a member of the class.
+func (r *pgxClassRepository) IsClassMember(ctx context.Context, classID, studentID int) (bool, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM class_members cm
+			JOIN classes c ON c.id = cm.class_id
+			WHERE cm.class_id = $1 AND cm.student_id = $2 AND ` + tenantFilter("c.organization_id", 3) + `
+		)
+	`
+	var member bool
+	err = r.db.QueryRow(ctx, query, classID, studentID, tenant).Scan(&member)
+	return member, err
+}
+
+// FindClassName returns the name of a class. Classes of other organizations
+// are not found, which makes it the check for class routes.
+func (r *pgxClassRepository) FindClassName(ctx context.Context, classID int) (string, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return "", err
+	}
+	var name string
+	err = r.db.QueryRow(ctx, "SELECT name FROM classes WHERE id = $1 AND ($2::int IS NULL OR organization_id = $2)", classID, tenant).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrClassNotFound
+	}
+	return name, err
+}
+
+// FindTeacherClassIDs returns the IDs of the classes the teacher teaches.
+func (r *pgxClassRepository) FindTeacherClassIDs(ctx context.Context, teacherID int) ([]int, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id FROM classes WHERE teacher_id = $1 AND " + tenantFilter("organization_id", 2) + " ORDER BY id"
+	return r.findIDs(ctx, query, teacherID, tenant)
+}
+
+// FindStudentClassIDs returns the IDs of the classes the student is a member of.
+func (r *pgxClassRepository) FindStudentClassIDs(ctx context.Context, studentID int) ([]int, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT cm.class_id FROM class_members cm
+		JOIN classes c ON c.id = cm.class_id
+		WHERE cm.student_id = $1 AND ` + tenantFilter("c.organization_id", 2) + `
+		ORDER BY cm.class_id
+	`
+	return r.findIDs(ctx, query, studentID, tenant)
+}
+
+// AddClassMember enrolls a student in a class and reports whether they were
+// newly added. It returns ErrUserNotFound when the user is not an active
+// student of the class's organization.
+func (r *pgxClassRepository) AddClassMember(ctx context.Context, classID, studentID int) (bool, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM users u
+			JOIN classes c ON c.id = $2 AND c.organization_id = u.organization_id
+			WHERE u.id = $1 AND u.role = 'student' AND u.deleted_at IS NULL
+			AND ($3::int IS NULL OR u.organization_id = $3)
+		)
+	`
+	var student bool
+	err = conn(ctx, r.db).QueryRow(ctx, query, studentID, classID, tenant).Scan(&student)
+	if err != nil {
+		return false, err
+	}
+	if !student {
+		return false, ErrUserNotFound
+	}
+	tag, err := conn(ctx, r.db).Exec(ctx, "INSERT INTO class_members (class_id, student_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", classID, studentID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RemoveClassMember takes a student out of a class. Members of another
+// organization's classes are not found.
+func (r *pgxClassRepository) RemoveClassMember(ctx context.Context, classID, studentID int) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		DELETE FROM class_members cm USING classes c
+		WHERE c.id = cm.class_id AND cm.class_id = $1 AND cm.student_id = $2 AND ` + tenantFilter("c.organization_id", 3) + `
+	`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, classID, studentID, tenant)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrClassMemberNotFound
+	}
+	return nil
+}
+
+func (r *pgxClassRepository) findIDs(ctx context.Context, query string, args ...interface{}) ([]int, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// ErrGoalNotFound is returned when a goal does not exist.
+var ErrGoalNotFound = errors.New("goal not found")
+
+const goalColumns = `id, type, title, student_id, class_id, start_page, end_page, target_pages, period,
+	to_char(start_date, 'YYYY-MM-DD'), to_char(deadline, 'YYYY-MM-DD'), created_by, created_at`
+
+// goalInTenant limits goals to those set for a student or class of the
+// organization passed as $2 (see tenantArg).
+const goalInTenant = `(student_id IN (SELECT id FROM users WHERE $2::int IS NULL OR organization_id = $2)
+	OR class_id IN (SELECT id FROM classes WHERE $2::int IS NULL OR organization_id = $2))`
+
+// GoalRepository defines the interface for goal data operations.
+type GoalRepository interface {
+	CreateGoal(ctx context.Context, goal *models.Goal) error
+	FindGoalByID(ctx context.Context, id int) (*models.Goal, error)
+	DeleteGoal(ctx context.Context, id int) error
+	FindGoalsForStudent(ctx context.Context, studentID int) ([]models.Goal, error)
+	FindGoalsForClass(ctx context.Context, classID int) ([]models.Goal, error)
+	FindGoalPages(ctx context.Context, goal *models.Goal, studentID *int) ([]models.StudentPages, error)
+}
+
+type pgxGoalRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewGoalRepository creates a new goal repository.
+func NewGoalRepository(db *pgxpool.Pool) GoalRepository {
+	return &pgxGoalRepository{db: db}
+}
+
+// CreateGoal inserts a goal and fills in its ID, start date and timestamp. It
+// returns ErrUserNotFound when the goal's student is not an active student of
+// the organization, and ErrClassNotFound when its class is not in it.
+func (r *pgxGoalRepository) CreateGoal(ctx context.Context, goal *models.Goal) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	var startDate *string
+	if goal.StartDate != "" {
+		startDate = &goal.StartDate
+	}
+	query := `
+		INSERT INTO goals (type, title, student_id, class_id, start_page, end_page, target_pages, period, start_date, deadline, created_by)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9::date, CURRENT_DATE), $10, $11
+		WHERE $3::int IN (SELECT id FROM users WHERE role = 'student' AND deleted_at IS NULL AND ($12::int IS NULL OR organization_id = $12))
+		OR $4::int IN (SELECT id FROM classes WHERE $12::int IS NULL OR organization_id = $12)
+		RETURNING id, to_char(start_date, 'YYYY-MM-DD'), created_at
+	`
+	err = r.db.QueryRow(ctx, query, goal.Type, goal.Title, goal.StudentID, goal.ClassID, goal.StartPage, goal.EndPage,
+		goal.TargetPages, goal.Period, startDate, goal.Deadline, goal.CreatedBy, tenant).
+		Scan(&goal.ID, &goal.StartDate, &goal.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if goal.StudentID != nil {
+			return ErrUserNotFound
+		}
+		return ErrClassNotFound
+	}
+	return err
+}
+
+// FindGoalByID retrieves a single goal.
+func (r *pgxGoalRepository) FindGoalByID(ctx context.Context, id int) (*models.Goal, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, "SELECT "+goalColumns+" FROM goals WHERE id=$1 AND "+goalInTenant, id, tenant)
+	if err != nil {
+		return nil, err
+	}
+	goals, err := scanGoals(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(goals) == 0 {
+		return nil, ErrGoalNotFound
+	}
+	return &goals[0], nil
+}
+
+// DeleteGoal removes a goal.
+func (r *pgxGoalRepository) DeleteGoal(ctx context.Context, id int) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	tag, err := r.db.Exec(ctx, "DELETE FROM goals WHERE id=$1 AND "+goalInTenant, id, tenant)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGoalNotFound
+	}
+	return nil
+}
+
+// FindGoalsForStudent retrieves a student's own goals and those of their classes,
+// nearest deadline first.
+func (r *pgxGoalRepository) FindGoalsForStudent(ctx context.Context, studentID int) ([]models.Goal, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + goalColumns + ` FROM goals
+		WHERE (student_id = $1 OR class_id IN (SELECT class_id FROM class_members WHERE student_id = $1))
+		AND ` + goalInTenant + `
+		ORDER BY deadline, id`
+	rows, err := r.db.Query(ctx, query, studentID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return scanGoals(rows)
+}
+
+// FindGoalsForClass retrieves a class's goals, nearest deadline first.
+func (r *pgxGoalRepository) FindGoalsForClass(ctx context.Context, classID int) ([]models.Goal, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, "SELECT "+goalColumns+" FROM goals WHERE class_id = $1 AND "+goalInTenant+" ORDER BY deadline, id", classID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return scanGoals(rows)
+}
+
+// FindGoalPages returns, for every student the goal applies to (or only
+// studentID when given), the pages they recorded since the goal started along
+// with the last page recorded before it.
+func (r *pgxGoalRepository) FindGoalPages(ctx context.Context, goal *models.Goal, studentID *int) ([]models.StudentPages, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT u.id, u.username, p.page, p.baseline
+		FROM users u
+		LEFT JOIN LATERAL (
+			(SELECT page, created_at, id, TRUE AS baseline FROM progress
+				WHERE student_id = u.id AND created_at < $3::date
+				ORDER BY created_at DESC, id DESC
+				LIMIT 1)
+			UNION ALL
+			(SELECT page, created_at, id, FALSE FROM progress
+				WHERE student_id = u.id AND created_at >= $3::date)
+		) p ON TRUE
+		WHERE u.deleted_at IS NULL
+		AND (u.id = $1 OR u.id IN (SELECT student_id FROM class_members WHERE class_id = $2))
+		AND ($4::int IS NULL OR u.id = $4)
+		AND ` + tenantFilter("u.organization_id", 5) + `
+		ORDER BY u.username, u.id, p.created_at, p.id
+	`
+	rows, err := r.db.Query(ctx, query, goal.StudentID, goal.ClassID, goal.StartDate, studentID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := []models.StudentPages{}
+	for rows.Next() {
+		var id int
+		var username string
+		var page *int
+		var baseline *bool
+		if err := rows.Scan(&id, &username, &page, &baseline); err != nil {
+			return nil, err
+		}
+		if len(students) == 0 || students[len(students)-1].StudentID != id {
+			students = append(students, models.StudentPages{StudentID: id, Username: username, Pages: []int{}})
+		}
+		current := &students[len(students)-1]
+		switch {
+		case page == nil:
+		case baseline != nil && *baseline:
+			current.Baseline = page
+		default:
+			current.Pages = append(current.Pages, *page)
+		}
+	}
+	return students, rows.Err()
+}
+
+func scanGoals(rows pgx.Rows) ([]models.Goal, error) {
+	defer rows.Close()
+
+	goals := []models.Goal{}
+	for rows.Next() {
+		var goal models.Goal
+		if err := rows.Scan(&goal.ID, &goal.Type, &goal.Title, &goal.StudentID, &goal.ClassID, &goal.StartPage, &goal.EndPage,
+			&goal.TargetPages, &goal.Period, &goal.StartDate, &goal.Deadline, &goal.CreatedBy, &goal.CreatedAt); err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
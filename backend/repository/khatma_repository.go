@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+const khatmaColumns = "k.id, k.student_id, u.username, k.status, k.last_page, k.started_at, k.ended_at"
+
+// KhatmaRepository defines the interface for khatma data operations.
+type KhatmaRepository interface {
+	FindOpenKhatma(ctx context.Context, studentID int) (*models.Khatma, error)
+	OpenKhatma(ctx context.Context, studentID int) (*models.Khatma, error)
+	UpdateLastPage(ctx context.Context, id, page int) error
+	CloseKhatma(ctx context.Context, id int, status string, page int) (*models.Khatma, error)
+	FindKhatmasForStudent(ctx context.Context, studentID int) ([]models.Khatma, error)
+	FindCompletedKhatmasForClass(ctx context.Context, classID int) ([]models.Khatma, error)
+}
+
+type pgxKhatmaRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewKhatmaRepository creates a new khatma repository.
+func NewKhatmaRepository(db *pgxpool.Pool) KhatmaRepository {
+	return &pgxKhatmaRepository{db: db}
+}
+
+// FindOpenKhatma returns the student's khatma in progress, or nil if there is none.
+func (r *pgxKhatmaRepository) FindOpenKhatma(ctx context.Context, studentID int) (*models.Khatma, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + khatmaColumns + ` FROM khatmas k
+		JOIN users u ON u.id = k.student_id
+		WHERE k.student_id = $1 AND k.status = 'open' AND ` + tenantFilter("u.organization_id", 2)
+	rows, err := conn(ctx, r.db).Query(ctx, query, studentID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	khatmas, err := scanKhatmas(rows)
+	if err != nil || len(khatmas) == 0 {
+		return nil, err
+	}
+	return &khatmas[0], nil
+}
+
+// OpenKhatma starts a new khatma for the student at page 1. It returns
+// ErrUserNotFound when the student is not in the organization.
+func (r *pgxKhatmaRepository) OpenKhatma(ctx context.Context, studentID int) (*models.Khatma, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		INSERT INTO khatmas (student_id)
+		SELECT u.id FROM users u WHERE u.id = $1 AND ` + tenantFilter("u.organization_id", 2) + `
+		RETURNING id, started_at
+	`
+	khatma := &models.Khatma{StudentID: studentID, Status: "open", LastPage: 1}
+	err = conn(ctx, r.db).QueryRow(ctx, query, studentID, tenant).Scan(&khatma.ID, &khatma.StartedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return khatma, nil
+}
+
+// UpdateLastPage moves an open khatma's cursor.
+func (r *pgxKhatmaRepository) UpdateLastPage(ctx context.Context, id, page int) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE khatmas k SET last_page = $1
+		FROM users u
+		WHERE u.id = k.student_id AND k.id = $2 AND k.status = 'open' AND ` + tenantFilter("u.organization_id", 3) + `
+	`
+	_, err = conn(ctx, r.db).Exec(ctx, query, page, id, tenant)
+	return err
+}
+
+// CloseKhatma marks an open khatma completed or abandoned at the given page.
+func (r *pgxKhatmaRepository) CloseKhatma(ctx context.Context, id int, status string, page int) (*models.Khatma, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		UPDATE khatmas k SET status = $1, last_page = $2, ended_at = NOW()
+		FROM users u
+		WHERE u.id = k.student_id AND k.id = $3 AND k.status = 'open' AND ` + tenantFilter("u.organization_id", 4) + `
+		RETURNING k.id, k.student_id, k.status, k.last_page, k.started_at, k.ended_at
+	`
+	khatma := &models.Khatma{}
+	err = conn(ctx, r.db).QueryRow(ctx, query, status, page, id, tenant).
+		Scan(&khatma.ID, &khatma.StudentID, &khatma.Status, &khatma.LastPage, &khatma.StartedAt, &khatma.EndedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return khatma, nil
+}
+
+// FindKhatmasForStudent lists all of a student's khatmas, newest first.
+func (r *pgxKhatmaRepository) FindKhatmasForStudent(ctx context.Context, studentID int) ([]models.Khatma, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + khatmaColumns + ` FROM khatmas k
+		JOIN users u ON u.id = k.student_id
+		WHERE k.student_id = $1 AND ($2::int IS NULL OR u.organization_id = $2)
+		ORDER BY k.started_at DESC, k.id DESC`
+	rows, err := conn(ctx, r.db).Query(ctx, query, studentID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return scanKhatmas(rows)
+}
+
+// FindCompletedKhatmasForClass lists the completed khatmas of a class's current
+// members, most recently completed first.
+func (r *pgxKhatmaRepository) FindCompletedKhatmasForClass(ctx context.Context, classID int) ([]models.Khatma, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + khatmaColumns + ` FROM khatmas k
+		JOIN users u ON u.id = k.student_id AND u.deleted_at IS NULL
+		JOIN class_members cm ON cm.student_id = k.student_id AND cm.class_id = $1
+		WHERE k.status = 'completed' AND ($2::int IS NULL OR u.organization_id = $2)
+		ORDER BY k.ended_at DESC, k.id DESC`
+	rows, err := conn(ctx, r.db).Query(ctx, query, classID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return scanKhatmas(rows)
+}
+
+func scanKhatmas(rows pgx.Rows) ([]models.Khatma, error) {
+	defer rows.Close()
+
+	khatmas := []models.Khatma{}
+	for rows.Next() {
+		var khatma models.Khatma
+		if err := rows.Scan(&khatma.ID, &khatma.StudentID, &khatma.Username, &khatma.Status, &khatma.LastPage, &khatma.StartedAt, &khatma.EndedAt); err != nil {
+			return nil, err
+		}
+		khatmas = append(khatmas, khatma)
+	}
+	return khatmas, rows.Err()
+}
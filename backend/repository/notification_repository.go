@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+const notificationColumns = `id, user_id, channel, recipient, template, language, subject, body, status, attempts,
+	CASE WHEN status = 'pending' THEN next_attempt_at END, last_error, sent_at, created_at`
+
+// NotificationRepository defines the interface for notification preferences
+// and the notification queue.
+type NotificationRepository interface {
+	FindRecipient(ctx context.Context, userID int) (*models.NotificationRecipient, error)
+	SavePreferences(ctx context.Context, preferences *models.NotificationPreferences) error
+	EnqueueNotifications(ctx context.Context, notifications []models.Notification) error
+	FindNotifications(ctx context.Context, status string, limit, offset int) ([]models.Notification, error)
+	ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]models.Notification, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkSendFailed(ctx context.Context, id int64, message string, retryAt *time.Time) error
+	PruneNotifications(ctx context.Context, createdBefore time.Time) (int64, error)
+}
+
+type pgxNotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewNotificationRepository creates a new notification repository.
+func NewNotificationRepository(db *pgxpool.Pool) NotificationRepository {
+	return &pgxNotificationRepository{db: db}
+}
+
+// FindRecipient returns a user's contact details and notification preferences.
+// Users who never set preferences get the table defaults.
+func (r *pgxNotificationRepository) FindRecipient(ctx context.Context, userID int) (*models.NotificationRecipient, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT u.id, u.username, u.phone, p.email, COALESCE(p.language, 'ar'), COALESCE(p.channels, '{sms}')
+		FROM users u
+		LEFT JOIN notification_preferences p ON p.user_id = u.id
+		WHERE u.id = $1 AND u.deleted_at IS NULL AND ($2::int IS NULL OR u.organization_id = $2)
+	`
+	var recipient models.NotificationRecipient
+	err = conn(ctx, r.db).QueryRow(ctx, query, userID, tenant).
+		Scan(&recipient.UserID, &recipient.Username, &recipient.Phone, &recipient.Email, &recipient.Language, &recipient.Channels)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recipient, nil
+}
+
+// SavePreferences creates or replaces a user's notification preferences.
+func (r *pgxNotificationRepository) SavePreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO notification_preferences (user_id, language, channels, email)
+		SELECT id, $2, $3, $4 FROM users WHERE id = $1 AND deleted_at IS NULL AND ($5::int IS NULL OR organization_id = $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			language = EXCLUDED.language, channels = EXCLUDED.channels, email = EXCLUDED.email, updated_at = NOW()
+	`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, preferences.UserID, preferences.Language, preferences.Channels, preferences.Email, tenant)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// EnqueueNotifications queues rendered messages for sending. Called within
+// Transactor.WithinTx, they are only sent if the change they report is committed.
+// It returns ErrUserNotFound when a recipient is not in the organization.
+func (r *pgxNotificationRepository) EnqueueNotifications(ctx context.Context, notifications []models.Notification) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO notifications (user_id, channel, recipient, template, language, subject, body)
+		SELECT u.id, $2, $3, $4, $5, $6, $7
+		FROM users u WHERE u.id = $1 AND ` + tenantFilter("u.organization_id", 8) + `
+	`
+	batch := &pgx.Batch{}
+	for _, n := range notifications {
+		batch.Queue(query, n.UserID, n.Channel, n.Recipient, n.Template, n.Language, n.Subject, n.Body, tenant)
+	}
+	results := conn(ctx, r.db).SendBatch(ctx, batch)
+	defer results.Close()
+	for range notifications {
+		tag, err := results.Exec()
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrUserNotFound
+		}
+	}
+	return nil
+}
+
+// FindNotifications pages through queued notifications, newest first,
+// optionally only those with the given status.
+func (r *pgxNotificationRepository) FindNotifications(ctx context.Context, status string, limit, offset int) ([]models.Notification, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + notificationColumns + `
+		FROM notifications
+		WHERE ($1 = '' OR status = $1)
+		AND user_id IN (SELECT id FROM users WHERE $4::int IS NULL OR organization_id = $4)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(ctx, query, status, limit, offset, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanNotifications(rows)
+}
+
+// ClaimNotifications picks pending notifications that are due and pushes their
+// next attempt back by the lease, so no other dispatcher picks them up while
+// they are being sent.
+func (r *pgxNotificationRepository) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]models.Notification, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM notifications
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notifications n SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM due
+		WHERE n.id = due.id
+		RETURNING ` + notificationColumns
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanNotifications(rows)
+}
+
+// MarkSent records a successful send.
+func (r *pgxNotificationRepository) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, "UPDATE notifications SET status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+// MarkSendFailed records a failed send. The notification is retried at
+// retryAt, or given up on when retryAt is nil.
+func (r *pgxNotificationRepository) MarkSendFailed(ctx context.Context, id int64, message string, retryAt *time.Time) error {
+	query := `
+		UPDATE notifications SET
+			status = CASE WHEN $2::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($2::timestamptz, next_attempt_at),
+			attempts = attempts + 1, last_error = $1
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(ctx, query, message, retryAt, id)
+	return err
+}
+
+// PruneNotifications deletes sent and failed notifications created before the
+// cutoff and returns how many were removed.
+func (r *pgxNotificationRepository) PruneNotifications(ctx context.Context, createdBefore time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, "DELETE FROM notifications WHERE created_at < $1 AND status <> 'pending'", createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanNotifications(rows pgx.Rows) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(&n.ID, &n.UserID, &n.Channel, &n.Recipient, &n.Template, &n.Language, &n.Subject, &n.Body,
+			&n.Status, &n.Attempts, &n.NextAttemptAt, &n.LastError, &n.SentAt, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
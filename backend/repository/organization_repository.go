@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+var (
+	// ErrOrganizationNotFound is returned when an organization does not exist.
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrOrganizationExists is returned when creating an organization whose slug is taken.
+	ErrOrganizationExists = errors.New("organization already exists")
+)
+
+// OrganizationRepository defines the interface for organization data
+// operations. Organizations are not tenant-scoped themselves.
+type OrganizationRepository interface {
+	FindOrganizations(ctx context.Context) ([]models.Organization, error)
+	FindOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error)
+	CreateOrganization(ctx context.Context, organization *models.Organization) error
+}
+
+type pgxOrganizationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewOrganizationRepository creates a new organization repository.
+func NewOrganizationRepository(db *pgxpool.Pool) OrganizationRepository {
+	return &pgxOrganizationRepository{db: db}
+}
+
+// FindOrganizations retrieves every organization.
+func (r *pgxOrganizationRepository) FindOrganizations(ctx context.Context) ([]models.Organization, error) {
+	rows, err := r.db.Query(ctx, "SELECT id, slug, name, created_at FROM organizations ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	organizations := []models.Organization{}
+	for rows.Next() {
+		var o models.Organization
+		if err := rows.Scan(&o.ID, &o.Slug, &o.Name, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, o)
+	}
+	return organizations, rows.Err()
+}
+
+// FindOrganizationBySlug retrieves a single organization by its slug.
+func (r *pgxOrganizationRepository) FindOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	var o models.Organization
+	err := r.db.QueryRow(ctx, "SELECT id, slug, name, created_at FROM organizations WHERE slug = $1", slug).
+		Scan(&o.ID, &o.Slug, &o.Name, &o.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// CreateOrganization inserts an organization and fills in its ID and timestamp.
+func (r *pgxOrganizationRepository) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	query := `
+		INSERT INTO organizations (slug, name) VALUES ($1, $2)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING id, created_at
+	`
+	err := conn(ctx, r.db).QueryRow(ctx, query, organization.Slug, organization.Name).Scan(&organization.ID, &organization.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrOrganizationExists
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// ProgressRepository defines the interface for the progress history.
+type ProgressRepository interface {
+	CreateEntry(ctx context.Context, entry *models.ProgressEntry) error
+	FindEntries(ctx context.Context, studentID int, filter models.ProgressFilter) ([]models.ProgressEntry, error)
+	FindWeeklyPages(ctx context.Context, studentID int, weeks int) ([]models.WeeklyPage, error)
+	FindActiveDates(ctx context.Context, studentID int) ([]string, error)
+}
+
+type pgxProgressRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewProgressRepository creates a new progress repository.
+func NewProgressRepository(db *pgxpool.Pool) ProgressRepository {
+	return &pgxProgressRepository{db: db}
+}
+
+// CreateEntry appends an entry to a student's history and fills in its ID and
+// timestamp. The entry belongs to the student's organization; it returns
+// ErrUserNotFound when the student is not in the organization ctx is scoped to.
+func (r *pgxProgressRepository) CreateEntry(ctx context.Context, entry *models.ProgressEntry) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO progress (organization_id, student_id, surah, ayah, page)
+		SELECT organization_id, id, $2, $3, $4 FROM users WHERE id = $1 AND ` + tenantFilter("organization_id", 5) + `
+		RETURNING id, created_at
+	`
+	err = conn(ctx, r.db).QueryRow(ctx, query, entry.StudentID, entry.Surah, entry.Ayah, entry.Page, tenant).Scan(&entry.ID, &entry.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// FindEntries retrieves a student's progress entries matching the filter, newest first.
+func (r *pgxProgressRepository) FindEntries(ctx context.Context, studentID int, filter models.ProgressFilter) ([]models.ProgressEntry, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conditions := []string{"student_id=$1", "($2::int IS NULL OR organization_id = $2)"}
+	args := []interface{}{studentID, tenant}
+	argId := 3
+
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argId))
+		args = append(args, *filter.From)
+		argId++
+	}
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argId))
+		args = append(args, *filter.To)
+		argId++
+	}
+
+	query := "SELECT id, student_id, surah, ayah, page, created_at FROM progress WHERE " + strings.Join(conditions, " AND ")
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argId, argId+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.ProgressEntry{}
+	for rows.Next() {
+		var entry models.ProgressEntry
+		if err := rows.Scan(&entry.ID, &entry.StudentID, &entry.Surah, &entry.Ayah, &entry.Page, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// FindWeeklyPages returns the page a student had reached by the end of each of
+// the last weeks, oldest first, including the current week.
+func (r *pgxProgressRepository) FindWeeklyPages(ctx context.Context, studentID int, weeks int) ([]models.WeeklyPage, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT to_char(w.week_start, 'YYYY-MM-DD'),
+			(SELECT p.page FROM progress p
+				WHERE p.student_id = $1 AND p.created_at < w.week_start + INTERVAL '1 week'
+				AND ($3::int IS NULL OR p.organization_id = $3)
+				ORDER BY p.created_at DESC, p.id DESC
+				LIMIT 1)
+		FROM generate_series(
+			date_trunc('week', NOW()) - ($2 - 1) * INTERVAL '1 week',
+			date_trunc('week', NOW()),
+			INTERVAL '1 week'
+		) AS w(week_start)
+		ORDER BY w.week_start
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, studentID, weeks, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []models.WeeklyPage{}
+	for rows.Next() {
+		var week models.WeeklyPage
+		if err := rows.Scan(&week.WeekStart, &week.Page); err != nil {
+			return nil, err
+		}
+		pages = append(pages, week)
+	}
+	return pages, rows.Err()
+}
+
+// FindActiveDates returns the distinct days (YYYY-MM-DD, server time) on which a
+// student recorded progress, most recent first.
+func (r *pgxProgressRepository) FindActiveDates(ctx context.Context, studentID int) ([]string, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT DISTINCT to_char(created_at, 'YYYY-MM-DD') AS day
+		FROM progress
+		WHERE student_id = $1 AND ($2::int IS NULL OR organization_id = $2)
+		ORDER BY day DESC
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, studentID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dates := []string{}
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+	return dates, rows.Err()
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// RecitationRepository defines the interface for recording graded recitations.
+type RecitationRepository interface {
+	CreateRecitation(ctx context.Context, recitation *models.Recitation, teacherID int) error
+}
+
+type pgxRecitationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRecitationRepository creates a new recitation repository.
+func NewRecitationRepository(db *pgxpool.Pool) RecitationRepository {
+	return &pgxRecitationRepository{db: db}
+}
+
+// CreateRecitation records a recitation heard in the recitation's class and
+// fills in its ID, creation time and the student's username. It returns
+// ErrClassMemberNotFound unless the student is a current member of the class.
+func (r *pgxRecitationRepository) CreateRecitation(ctx context.Context, recitation *models.Recitation, teacherID int) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		WITH inserted AS (
+			INSERT INTO recitations (student_id, class_id, teacher_id, surah, ayah_from, ayah_to, grade, notes)
+			SELECT u.id, cm.class_id, $3, $4, $5, $6, $7, $8
+			FROM class_members cm
+			JOIN users u ON u.id = cm.student_id AND u.deleted_at IS NULL
+			WHERE cm.class_id = $1 AND cm.student_id = $2 AND ($9::int IS NULL OR u.organization_id = $9)
+			RETURNING id, student_id, created_at
+		)
+		SELECT i.id, u.username, i.created_at FROM inserted i JOIN users u ON u.id = i.student_id
+	`
+	err = conn(ctx, r.db).QueryRow(ctx, query,
+		recitation.ClassID, recitation.StudentID, teacherID,
+		recitation.Surah, recitation.AyahFrom, recitation.AyahTo, recitation.Grade, recitation.Notes, tenant,
+	).Scan(&recitation.ID, &recitation.Username, &recitation.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrClassMemberNotFound
+	}
+	return err
+}
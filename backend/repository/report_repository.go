@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// ReportRepository defines the interface for class reports. Rows are handed to
+// the callback as they are read so a report never has to fit in memory; an
+// error from the callback stops the query and is returned as is.
+//
+// Periods are given as inclusive calendar dates in YYYY-MM-DD form.
+type ReportRepository interface {
+	StreamClassProgress(ctx context.Context, classID int, from, to string, fn func(models.ProgressReportRow) error) error
+	StreamAttendance(ctx context.Context, classID int, from, to string, fn func(models.AttendanceReportRow) error) error
+	StreamRecitations(ctx context.Context, classID int, from, to string, fn func(models.RecitationReportRow) error) error
+}
+
+type pgxReportRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewReportRepository creates a new report repository.
+func NewReportRepository(db *pgxpool.Pool) ReportRepository {
+	return &pgxReportRepository{db: db}
+}
+
+// StreamClassProgress reads, per class member, where they stood before the
+// period, where they were at its end and how many updates they recorded in it.
+func (r *pgxReportRepository) StreamClassProgress(ctx context.Context, classID int, from, to string, fn func(models.ProgressReportRow) error) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		SELECT u.id, u.username,
+			COALESCE(
+				(SELECT p.page FROM progress p
+					WHERE p.student_id = u.id AND p.created_at < $2::date
+					ORDER BY p.created_at DESC, p.id DESC LIMIT 1),
+				(SELECT p.page FROM progress p
+					WHERE p.student_id = u.id AND p.created_at >= $2::date AND p.created_at < $3::date + 1
+					ORDER BY p.created_at, p.id LIMIT 1)
+			),
+			latest.page, latest.surah, latest.ayah,
+			(SELECT COUNT(*) FROM progress p
+				WHERE p.student_id = u.id AND p.created_at >= $2::date AND p.created_at < $3::date + 1)
+		FROM class_members cm
+		JOIN users u ON u.id = cm.student_id AND u.deleted_at IS NULL
+		LEFT JOIN LATERAL (
+			SELECT p.page, p.surah, p.ayah FROM progress p
+			WHERE p.student_id = u.id AND p.created_at < $3::date + 1
+			ORDER BY p.created_at DESC, p.id DESC LIMIT 1
+		) latest ON true
+		WHERE cm.class_id = $1 AND ($4::int IS NULL OR u.organization_id = $4)
+		ORDER BY u.username
+	`
+	rows, err := r.db.Query(ctx, query, classID, from, to, tenant)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.ProgressReportRow
+		if err := rows.Scan(&row.StudentID, &row.Username, &row.StartPage, &row.EndPage, &row.Surah, &row.Ayah, &row.Entries); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StreamAttendance reads each class member's attendance counts for the period.
+func (r *pgxReportRepository) StreamAttendance(ctx context.Context, classID int, from, to string, fn func(models.AttendanceReportRow) error) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		SELECT u.id, u.username,
+			COUNT(a.id) FILTER (WHERE a.status = 'present'),
+			COUNT(a.id) FILTER (WHERE a.status = 'absent'),
+			COUNT(a.id) FILTER (WHERE a.status = 'late'),
+			COUNT(a.id) FILTER (WHERE a.status = 'excused')
+		FROM class_members cm
+		JOIN users u ON u.id = cm.student_id AND u.deleted_at IS NULL
+		LEFT JOIN attendance a ON a.class_id = cm.class_id AND a.student_id = u.id
+			AND a.date BETWEEN $2::date AND $3::date
+		WHERE cm.class_id = $1 AND ($4::int IS NULL OR u.organization_id = $4)
+		GROUP BY u.id, u.username
+		ORDER BY u.username
+	`
+	rows, err := r.db.Query(ctx, query, classID, from, to, tenant)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.AttendanceReportRow
+		if err := rows.Scan(&row.StudentID, &row.Username, &row.Present, &row.Absent, &row.Late, &row.Excused); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StreamRecitations reads the recitations graded in the class during the
+// period, oldest first.
+func (r *pgxReportRepository) StreamRecitations(ctx context.Context, classID int, from, to string, fn func(models.RecitationReportRow) error) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		SELECT to_char(r.created_at, 'YYYY-MM-DD'), u.id, u.username,
+			r.surah, r.ayah_from, r.ayah_to, r.grade, r.notes
+		FROM recitations r
+		JOIN users u ON u.id = r.student_id AND u.deleted_at IS NULL
+		WHERE r.class_id = $1 AND r.created_at >= $2::date AND r.created_at < $3::date + 1
+		AND ($4::int IS NULL OR u.organization_id = $4)
+		ORDER BY r.created_at, r.id
+	`
+	rows, err := r.db.Query(ctx, query, classID, from, to, tenant)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.RecitationReportRow
+		if err := rows.Scan(&row.Date, &row.StudentID, &row.Username, &row.Surah, &row.AyahFrom, &row.AyahTo, &row.Grade, &row.Notes); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// ActiveStudentWindow is how recently a student must have recorded progress to
+// count as active.
+const ActiveStudentWindow = 30 * 24 * time.Hour
+
+// StatsRepository defines the interface for aggregate, school-wide queries.
+type StatsRepository interface {
+	CountActiveStudents(ctx context.Context) (int, error)
+	CountProgressEntriesSince(ctx context.Context, since time.Time) (int, error)
+	FindAdminStats(ctx context.Context, weeks int) (*models.AdminStats, error)
+}
+
+type pgxStatsRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewStatsRepository creates a new stats repository.
+func NewStatsRepository(db *pgxpool.Pool) StatsRepository {
+	return &pgxStatsRepository{db: db}
+}
+
+// CountActiveStudents counts students that recorded progress within ActiveStudentWindow.
+func (r *pgxStatsRepository) CountActiveStudents(ctx context.Context) (int, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return 0, err
+	}
+	query := `
+		SELECT COUNT(*)
+		FROM users u
+		WHERE u.role = 'student' AND u.deleted_at IS NULL AND ($2::int IS NULL OR u.organization_id = $2)
+		AND EXISTS (SELECT 1 FROM progress p WHERE p.student_id = u.id AND p.created_at >= $1)
+	`
+	var count int
+	err = r.db.QueryRow(ctx, query, time.Now().Add(-ActiveStudentWindow), tenant).Scan(&count)
+	return count, err
+}
+
+// CountProgressEntriesSince counts progress entries recorded since the given time.
+func (r *pgxStatsRepository) CountProgressEntriesSince(ctx context.Context, since time.Time) (int, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	err = r.db.QueryRow(ctx, "SELECT COUNT(*) FROM progress WHERE created_at >= $1 AND ($2::int IS NULL OR organization_id = $2)", since, tenant).Scan(&count)
+	return count, err
+}
+
+// FindAdminStats computes the school-wide overview: users per role, class and
+// student counts, pages memorized, progress entries for each of the last weeks
+// (including empty ones) and each teacher's workload. Recitations count towards
+// the workload when heard within ActiveStudentWindow. The queries are sent as
+// a single batch.
+func (r *pgxStatsRepository) FindAdminStats(ctx context.Context, weeks int) (*models.AdminStats, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	activeSince := time.Now().Add(-ActiveStudentWindow)
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		SELECT role, COUNT(*)
+		FROM users
+		WHERE deleted_at IS NULL AND ($1::int IS NULL OR organization_id = $1)
+		GROUP BY role
+	`, tenant)
+	batch.Queue(`
+		SELECT
+			(SELECT COUNT(*) FROM classes WHERE $2::int IS NULL OR organization_id = $2),
+			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM progress p WHERE p.student_id = u.id AND p.created_at >= $1)),
+			COUNT(*) FILTER (WHERE NOT EXISTS (SELECT 1 FROM progress p WHERE p.student_id = u.id AND p.created_at >= $1)),
+			COALESCE(SUM(u.progress_page), 0)
+		FROM users u
+		WHERE u.role = 'student' AND u.deleted_at IS NULL AND ($2::int IS NULL OR u.organization_id = $2)
+	`, activeSince, tenant)
+	batch.Queue(`
+		SELECT to_char(w.week_start, 'YYYY-MM-DD'), COUNT(p.id)
+		FROM generate_series(
+			date_trunc('week', NOW()) - ($1 - 1) * INTERVAL '1 week',
+			date_trunc('week', NOW()),
+			INTERVAL '1 week'
+		) AS w(week_start)
+		LEFT JOIN progress p ON p.created_at >= w.week_start AND p.created_at < w.week_start + INTERVAL '1 week'
+			AND ($2::int IS NULL OR p.organization_id = $2)
+		GROUP BY w.week_start
+		ORDER BY w.week_start
+	`, weeks, tenant)
+	batch.Queue(`
+		SELECT t.id, t.username,
+			(SELECT COUNT(*) FROM classes c WHERE c.teacher_id = t.id AND `+tenantFilter("c.organization_id", 2)+`),
+			(SELECT COUNT(DISTINCT cm.student_id)
+				FROM classes c
+				JOIN class_members cm ON cm.class_id = c.id
+				JOIN users s ON s.id = cm.student_id AND s.deleted_at IS NULL
+				WHERE c.teacher_id = t.id AND `+tenantFilter("c.organization_id", 2)+`),
+			(SELECT COUNT(*) FROM recitations r
+				JOIN users s ON s.id = r.student_id
+				WHERE r.teacher_id = t.id AND r.created_at >= $1 AND `+tenantFilter("s.organization_id", 2)+`)
+		FROM users t
+		WHERE t.role = 'teacher' AND t.deleted_at IS NULL AND ($2::int IS NULL OR t.organization_id = $2)
+		ORDER BY 4 DESC, t.username
+	`, activeSince, tenant)
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	stats := &models.AdminStats{
+		UsersByRole:           map[string]int{},
+		ProgressEntriesByWeek: []models.WeeklyCount{},
+		TeacherWorkload:       []models.TeacherWorkload{},
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var role string
+		var count int
+		if err := rows.Scan(&role, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.UsersByRole[role] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := results.QueryRow().Scan(&stats.Classes, &stats.ActiveStudents, &stats.InactiveStudents, &stats.TotalPagesMemorized); err != nil {
+		return nil, err
+	}
+
+	rows, err = results.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var week models.WeeklyCount
+		if err := rows.Scan(&week.WeekStart, &week.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.ProgressEntriesByWeek = append(stats.ProgressEntriesByWeek, week)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = results.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var workload models.TeacherWorkload
+		if err := rows.Scan(&workload.TeacherID, &workload.Username, &workload.ClassCount, &workload.StudentCount, &workload.RecentRecitations); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.TeacherWorkload = append(stats.TeacherWorkload, workload)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -22,16 +22,20 @@ func NewStudentRepository(db *pgxpool.Pool) StudentRepository {
 }
 
 func (r *pgxStudentRepository) FindStudentData(ctx context.Context, id int) (*models.StudentData, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var student models.StudentData
 	query := `
 		SELECT u.username, p.surah, p.ayah, p.page
 		FROM users u
 		LEFT JOIN progress p ON u.id = p.student_id
-		WHERE u.id = $1
+		WHERE u.id = $1 AND u.deleted_at IS NULL AND ($2::int IS NULL OR u.organization_id = $2)
 		ORDER BY p.id DESC
 		LIMIT 1
 	`
-	err := r.db.QueryRow(ctx, query, id).Scan(&student.Username, &student.ProgressSurah, &student.ProgressAyah, &student.ProgressPage)
+	err = r.db.QueryRow(ctx, query, id, tenant).Scan(&student.Username, &student.ProgressSurah, &student.ProgressAyah, &student.ProgressPage)
 	if err != nil {
 		return nil, err
 	}
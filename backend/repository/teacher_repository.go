@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// TeacherRepository defines the interface for teacher-facing data operations.
+type TeacherRepository interface {
+	FindDashboard(ctx context.Context, teacherID int, today time.Time, stalledSince time.Time, recentLimit int) (*models.TeacherDashboard, error)
+}
+
+type pgxTeacherRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewTeacherRepository creates a new teacher repository.
+func NewTeacherRepository(db *pgxpool.Pool) TeacherRepository {
+	return &pgxTeacherRepository{db: db}
+}
+
+// FindDashboard gathers a teacher's classes with today's attendance, their
+// stalled students and recent recitations. The three queries are sent as a
+// single batch, so the dashboard costs one round trip.
+func (r *pgxTeacherRepository) FindDashboard(ctx context.Context, teacherID int, today time.Time, stalledSince time.Time, recentLimit int) (*models.TeacherDashboard, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		SELECT c.id, c.name, COUNT(u.id),
+			COUNT(a.id) FILTER (WHERE a.status = 'present'),
+			COUNT(a.id) FILTER (WHERE a.status = 'absent'),
+			COUNT(a.id) FILTER (WHERE a.status = 'late'),
+			COUNT(a.id) FILTER (WHERE a.status = 'excused'),
+			COUNT(u.id) - COUNT(a.id)
+		FROM classes c
+		LEFT JOIN class_members cm ON cm.class_id = c.id
+		LEFT JOIN users u ON u.id = cm.student_id AND u.deleted_at IS NULL
+		LEFT JOIN attendance a ON a.class_id = c.id AND a.student_id = u.id AND a.date = $2
+		WHERE c.teacher_id = $1 AND `+tenantFilter("c.organization_id", 3)+`
+		GROUP BY c.id, c.name
+		ORDER BY c.name
+	`, teacherID, today.Format("2006-01-02"), tenant)
+	batch.Queue(`
+		SELECT u.id, u.username, c.id, c.name, MAX(p.created_at)
+		FROM classes c
+		JOIN class_members cm ON cm.class_id = c.id
+		JOIN users u ON u.id = cm.student_id AND u.deleted_at IS NULL
+		LEFT JOIN progress p ON p.student_id = u.id
+		WHERE c.teacher_id = $1 AND `+tenantFilter("c.organization_id", 3)+`
+		GROUP BY u.id, u.username, c.id, c.name
+		HAVING MAX(p.created_at) IS NULL OR MAX(p.created_at) < $2
+		ORDER BY MAX(p.created_at) NULLS FIRST, u.username
+	`, teacherID, stalledSince, tenant)
+	batch.Queue(`
+		SELECT r.id, r.student_id, u.username, r.class_id, r.surah, r.ayah_from, r.ayah_to, r.grade, r.notes, r.created_at
+		FROM recitations r
+		JOIN users u ON u.id = r.student_id
+		WHERE (r.teacher_id = $1 OR r.class_id IN (SELECT id FROM classes WHERE teacher_id = $1))
+			AND `+tenantFilter("u.organization_id", 3)+`
+		ORDER BY r.created_at DESC
+		LIMIT $2
+	`, teacherID, recentLimit, tenant)
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	dashboard := &models.TeacherDashboard{
+		Classes:           []models.DashboardClass{},
+		StalledStudents:   []models.StalledStudent{},
+		RecentRecitations: []models.Recitation{},
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var class models.DashboardClass
+		a := &class.AttendanceToday
+		if err := rows.Scan(&class.ID, &class.Name, &class.StudentCount, &a.Present, &a.Absent, &a.Late, &a.Excused, &a.Unmarked); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		dashboard.Classes = append(dashboard.Classes, class)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = results.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var student models.StalledStudent
+		if err := rows.Scan(&student.ID, &student.Username, &student.ClassID, &student.ClassName, &student.LastProgressAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		dashboard.StalledStudents = append(dashboard.StalledStudents, student)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = results.Query()
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var recitation models.Recitation
+		if err := rows.Scan(&recitation.ID, &recitation.StudentID, &recitation.Username, &recitation.ClassID, &recitation.Surah, &recitation.AyahFrom, &recitation.AyahTo, &recitation.Grade, &recitation.Notes, &recitation.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		dashboard.RecentRecitations = append(dashboard.RecentRecitations, recitation)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dashboard, nil
+}
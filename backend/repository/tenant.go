@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// DefaultOrganizationID is the organization db.sql creates for existing data
+// and the developer account.
+const DefaultOrganizationID = 1
+
+// ErrNoTenant is returned by tenant-scoped queries run with a context that was
+// never scoped with WithTenant or WithAllTenants, and by inserts run with one
+// that is not scoped to a single organization.
+var ErrNoTenant = errors.New("no organization selected")
+
+type tenantKey struct{}
+
+// tenantScope is the organization a context is scoped to; all is set for work
+// that spans every organization.
+type tenantScope struct {
+	organizationID int
+	all            bool
+}
+
+// WithTenant returns a copy of ctx whose repository calls only see, and only
+// create, the organization's users, classes and progress.
+func WithTenant(ctx context.Context, organizationID int) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{organizationID: organizationID})
+}
+
+// WithAllTenants returns a copy of ctx whose repository calls see every
+// organization, for background jobs and platform-wide metrics. Nothing can be
+// created with it.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{all: true})
+}
+
+// TenantFromContext returns the organization ctx is scoped to, if it is scoped
+// to a single one.
+func TenantFromContext(ctx context.Context) (int, bool) {
+	scope, ok := ctx.Value(tenantKey{}).(tenantScope)
+	if !ok || scope.all {
+		return 0, false
+	}
+	return scope.organizationID, true
+}
+
+// tenantArg returns the argument of the "($n::int IS NULL OR organization_id = $n)"
+// condition every tenant-scoped query carries: the organization ctx is scoped
+// to, or nil when it spans all of them. A context without any scope fails, so
+// a caller that forgot to pick one sees nothing rather than everything.
+func tenantArg(ctx context.Context) (*int, error) {
+	scope, ok := ctx.Value(tenantKey{}).(tenantScope)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	if scope.all {
+		return nil, nil
+	}
+	return &scope.organizationID, nil
+}
+
+// tenantFilter returns that condition for an organization_id column and the
+// placeholder number tenantArg's value is passed as, e.g.
+// tenantFilter("u.organization_id", 2) for "($2::int IS NULL OR u.organization_id = $2)".
+// Tables without an organization of their own are scoped through the user or
+// class they belong to.
+func tenantFilter(column string, placeholder int) string {
+	n := "$" + strconv.Itoa(placeholder)
+	return "(" + n + "::int IS NULL OR " + column + " = " + n + ")"
+}
+
+// tenantID returns the organization rows created with ctx belong to.
+func tenantID(ctx context.Context) (int, error) {
+	organizationID, ok := TenantFromContext(ctx)
+	if !ok {
+		return 0, ErrNoTenant
+	}
+	return organizationID, nil
+}
+
+// optionalTenantID returns the organization rows created with ctx belong to,
+// or nil for rows that may also be recorded outside any organization.
+func optionalTenantID(ctx context.Context) *int {
+	if organizationID, ok := TenantFromContext(ctx); ok {
+		return &organizationID
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// tenantTables matches SQL that reads or writes a table holding one
+// organization's data, whether the table carries organization_id itself or is
+// scoped through the user or class it belongs to.
+var tenantTables = regexp.MustCompile(`(?i)\b(FROM|JOIN|INTO|UPDATE|USING)\s+(users|classes|class_members|progress|recitations|attendance|khatmas|certificates|goals|audit_events|outbox_events|webhook_subscriptions|webhook_deliveries|notifications|notification_preferences)\b`)
+
+// unscopedQueries are the functions allowed to query those tables without a
+// tenant condition, with the reason why.
+var unscopedQueries = map[string]string{
+	"pgxNotificationRepository.ClaimNotifications": "the dispatcher sends every organization's notifications",
+	"pgxNotificationRepository.MarkSent":           "records the outcome of a claimed notification",
+	"pgxNotificationRepository.MarkSendFailed":     "records the outcome of a claimed notification",
+	"pgxNotificationRepository.PruneNotifications": "retention applies to every organization",
+	"pgxWebhookRepository.FanOutEvents":            "matches each event to its own organization's subscriptions",
+	"pgxWebhookRepository.ClaimDeliveries":         "the dispatcher delivers every organization's events",
+	"pgxWebhookRepository.MarkDelivered":           "records the outcome of a claimed delivery",
+	"pgxWebhookRepository.MarkAttemptFailed":       "records the outcome of a claimed delivery",
+	"pgxWebhookRepository.PruneEvents":             "retention applies to every organization",
+}
+
+// TestQueriesAreTenantScoped fails when a repository function queries a
+// tenant-owned table without taking the organization from its context and
+// filtering on it. A new query that forgot the condition would otherwise leak
+// other organizations' rows without any test noticing.
+func TestQueriesAreTenantScoped(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, f)
+	}
+
+	// Query fragments shared through package constants, such as goalInTenant,
+	// count as part of the functions using them.
+	constants := map[string]string{}
+	for _, f := range parsed {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				value := spec.(*ast.ValueSpec)
+				for i, name := range value.Names {
+					if i >= len(value.Values) {
+						continue
+					}
+					if lit, ok := value.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						if s, err := strconv.Unquote(lit.Value); err == nil {
+							constants[name.Name] = s
+						}
+					}
+				}
+			}
+		}
+	}
+
+	checked := 0
+	for _, f := range parsed {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			var sql strings.Builder
+			calls := map[string]bool{}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.BasicLit:
+					if n.Kind == token.STRING {
+						if s, err := strconv.Unquote(n.Value); err == nil {
+							sql.WriteString(s + "\n")
+						}
+					}
+				case *ast.Ident:
+					if s, ok := constants[n.Name]; ok {
+						sql.WriteString(s + "\n")
+					}
+				case *ast.CallExpr:
+					if ident, ok := n.Fun.(*ast.Ident); ok {
+						calls[ident.Name] = true
+					}
+				}
+				return true
+			})
+			if !tenantTables.MatchString(sql.String()) {
+				continue
+			}
+			name := funcName(fn)
+			if _, ok := unscopedQueries[name]; ok {
+				continue
+			}
+			checked++
+			if !calls["tenantArg"] && !calls["tenantID"] && !calls["optionalTenantID"] {
+				t.Errorf("%s: %s queries a tenant-owned table without reading the organization from its context", fset.Position(fn.Pos()), name)
+			}
+			if !calls["tenantFilter"] && !strings.Contains(sql.String(), "organization_id") {
+				t.Errorf("%s: %s queries a tenant-owned table without an organization_id condition", fset.Position(fn.Pos()), name)
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no queries were checked")
+	}
+}
+
+// funcName names a function or method as "Type.Method".
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// querier is what repositories need from a connection; both the pool and a
+// transaction provide it.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	BeginFunc(ctx context.Context, f func(pgx.Tx) error) error
+}
+
+type txKey struct{}
+
+// Transactor runs work in a database transaction that every repository call
+// made with the context it hands out takes part in.
+type Transactor interface {
+	// WithinTx runs fn in a transaction, committing it if fn returns nil and
+	// rolling it back otherwise. Called within another WithinTx, it joins the
+	// outer transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type pgxTransactor struct {
+	db *pgxpool.Pool
+}
+
+// NewTransactor creates a new Transactor.
+func NewTransactor(db *pgxpool.Pool) Transactor {
+	return &pgxTransactor{db: db}
+}
+
+// WithinTx implements Transactor.
+func (t *pgxTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	return t.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction started by Transactor.WithinTx, if ctx carries
+// one, and the pool otherwise.
+func conn(ctx context.Context, db *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
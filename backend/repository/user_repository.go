@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/kolind-am/quran-project/backend/models"
 )
@@ -16,8 +20,26 @@ type UserRepository interface {
 	UpdateUser(ctx context.Context, id int, user *models.User) (*models.User, error)
 	DeleteUser(ctx context.Context, id int) error
 	FindUserByUsername(ctx context.Context, username string) (*models.User, error)
+	FindUserByID(ctx context.Context, id int) (*models.User, error)
+	FindActiveRole(ctx context.Context, id int) (string, error)
+	FindDeletedUsers(ctx context.Context) ([]models.User, error)
+	RestoreUser(ctx context.Context, id int) (*models.User, error)
+	FindDeletionPreflight(ctx context.Context, id int) (*models.DeletionPreflight, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	FindExistingUsernames(ctx context.Context, usernames []string) ([]string, error)
+	FindAllClasses(ctx context.Context) ([]models.Class, error)
+	ImportUsers(ctx context.Context, users []models.ImportedUser) error
 }
 
+var (
+	// ErrUserNotFound is returned when a user does not exist or is not in the state
+	// the operation requires (e.g. restoring a user that was never deleted).
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken is returned when another active user of the organization
+	// already has the username.
+	ErrUsernameTaken = errors.New("username already taken")
+)
+
 // pgxUserRepository is an implementation of UserRepository using pgx.
 type pgxUserRepository struct {
 	db *pgxpool.Pool
@@ -30,12 +52,16 @@ func NewUserRepository(db *pgxpool.Pool) UserRepository {
 
 // FindUsersByRole retrieves users from the database filtered by role.
 func (r *pgxUserRepository) FindUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
 	query := `
 		SELECT id, username, role, phone, progress_surah, progress_ayah, progress_page
 		FROM users
-		WHERE role = $1
+		WHERE role = $1 AND deleted_at IS NULL AND ($2::int IS NULL OR organization_id = $2)
 	`
-	rows, err := r.db.Query(ctx, query, role)
+	rows, err := conn(ctx, r.db).Query(ctx, query, role, tenant)
 	if err != nil {
 		return nil, err
 	}
@@ -53,14 +79,26 @@ func (r *pgxUserRepository) FindUsersByRole(ctx context.Context, role string) ([
 	return users, nil
 }
 
-// CreateUser inserts a new user into the database.
+// CreateUser inserts a new user into the ctx's organization and sets its ID.
 func (r *pgxUserRepository) CreateUser(ctx context.Context, user *models.User) error {
-	_, err := r.db.Exec(ctx, "INSERT INTO users (username, password, role, phone) VALUES ($1, $2, $3, $4)", user.Username, user.Password, user.Role, user.Phone)
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	err = conn(ctx, r.db).QueryRow(ctx, "INSERT INTO users (organization_id, username, password, role, phone) VALUES ($1, $2, $3, $4, $5) RETURNING id", organizationID, user.Username, user.Password, user.Role, user.Phone).Scan(&user.ID)
+	if isUniqueViolation(err) {
+		return ErrUsernameTaken
+	}
 	return err
 }
 
 // UpdateUser updates an existing user in the database.
 func (r *pgxUserRepository) UpdateUser(ctx context.Context, id int, user *models.User) (*models.User, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var setClauses []string
 	var args []interface{}
 	argId := 1
@@ -105,36 +143,304 @@ func (r *pgxUserRepository) UpdateUser(ctx context.Context, id int, user *models
 	if len(setClauses) == 0 {
 		// No fields to update, so just fetch and return the current user data
 		updatedUser := &models.User{}
-		err := r.db.QueryRow(ctx, "SELECT id, username, role, phone, progress_surah, progress_ayah, progress_page FROM users WHERE id=$1", id).Scan(&updatedUser.ID, &updatedUser.Username, &updatedUser.Role, &updatedUser.Phone, &updatedUser.ProgressSurah, &updatedUser.ProgressAyah, &updatedUser.ProgressPage)
+		err := conn(ctx, r.db).QueryRow(ctx, "SELECT id, username, role, phone, progress_surah, progress_ayah, progress_page FROM users WHERE id=$1 AND deleted_at IS NULL AND ($2::int IS NULL OR organization_id = $2)", id, tenant).Scan(&updatedUser.ID, &updatedUser.Username, &updatedUser.Role, &updatedUser.Phone, &updatedUser.ProgressSurah, &updatedUser.ProgressAyah, &updatedUser.ProgressPage)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
 		if err != nil {
 			return nil, err
 		}
 		return updatedUser, nil
 	}
 
-	query := fmt.Sprintf("UPDATE users SET %s WHERE id=$%d RETURNING id, username, role, phone, progress_surah, progress_ayah, progress_page", strings.Join(setClauses, ", "), argId)
-	args = append(args, id)
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id=$%d AND deleted_at IS NULL AND ($%d::int IS NULL OR organization_id = $%d) RETURNING id, username, role, phone, progress_surah, progress_ayah, progress_page",
+		strings.Join(setClauses, ", "), argId, argId+1, argId+1)
+	args = append(args, id, tenant)
 
 	updatedUser := &models.User{}
-	err := r.db.QueryRow(ctx, query, args...).Scan(&updatedUser.ID, &updatedUser.Username, &updatedUser.Role, &updatedUser.Phone, &updatedUser.ProgressSurah, &updatedUser.ProgressAyah, &updatedUser.ProgressPage)
+	err = conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(&updatedUser.ID, &updatedUser.Username, &updatedUser.Role, &updatedUser.Phone, &updatedUser.ProgressSurah, &updatedUser.ProgressAyah, &updatedUser.ProgressPage)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrUsernameTaken
+	}
 	if err != nil {
 		return nil, err
 	}
 	return updatedUser, nil
 }
 
-// DeleteUser removes a user from the database.
+// DeleteUser soft-deletes a user by stamping deleted_at. The row (and everything
+// that cascades from it) stays in place until PurgeDeletedUsers removes it.
 func (r *pgxUserRepository) DeleteUser(ctx context.Context, id int) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM users WHERE id=$1", id)
-	return err
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	tag, err := conn(ctx, r.db).Exec(ctx, "UPDATE users SET deleted_at = NOW() WHERE id=$1 AND deleted_at IS NULL AND ($2::int IS NULL OR organization_id = $2)", id, tenant)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
 
-// FindUserByUsername retrieves a single user by their username.
+// FindUserByUsername retrieves a single user by their username, which is only
+// unique within an organization.
 func (r *pgxUserRepository) FindUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var user models.User
-	err := r.db.QueryRow(ctx, "SELECT id, username, password, role, phone FROM users WHERE username=$1", username).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Phone)
+	err = conn(ctx, r.db).QueryRow(ctx, "SELECT id, username, password, role, phone FROM users WHERE username=$1 AND organization_id=$2 AND deleted_at IS NULL", username, organizationID).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Phone)
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+// FindUserByID retrieves a single user by ID, without their password hash.
+func (r *pgxUserRepository) FindUserByID(ctx context.Context, id int) (*models.User, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{}
+	err = conn(ctx, r.db).QueryRow(ctx, "SELECT id, username, role, phone, progress_surah, progress_ayah, progress_page FROM users WHERE id=$1 AND deleted_at IS NULL AND ($2::int IS NULL OR organization_id = $2)", id, tenant).Scan(&user.ID, &user.Username, &user.Role, &user.Phone, &user.ProgressSurah, &user.ProgressAyah, &user.ProgressPage)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// FindActiveRole returns the current role of a user who exists in the ctx's
+// organization and is not soft-deleted, or ErrUserNotFound.
+func (r *pgxUserRepository) FindActiveRole(ctx context.Context, id int) (string, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return "", err
+	}
+	var role string
+	err = conn(ctx, r.db).QueryRow(ctx, "SELECT role FROM users WHERE id=$1 AND deleted_at IS NULL AND ($2::int IS NULL OR organization_id = $2)", id, tenant).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	return role, err
+}
+
+// FindDeletedUsers retrieves all soft-deleted users, most recently deleted first.
+func (r *pgxUserRepository) FindDeletedUsers(ctx context.Context) ([]models.User, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, username, role, phone, progress_surah, progress_ayah, progress_page, deleted_at
+		FROM users
+		WHERE deleted_at IS NOT NULL AND ($1::int IS NULL OR organization_id = $1)
+		ORDER BY deleted_at DESC
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Role, &user.Phone, &user.ProgressSurah, &user.ProgressAyah, &user.ProgressPage, &user.DeletedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// RestoreUser clears deleted_at on a soft-deleted user. It fails with
+// ErrUsernameTaken when their username was given to someone else meanwhile.
+func (r *pgxUserRepository) RestoreUser(ctx context.Context, id int) (*models.User, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{}
+	err = conn(ctx, r.db).QueryRow(ctx, "UPDATE users SET deleted_at = NULL WHERE id=$1 AND deleted_at IS NOT NULL AND ($2::int IS NULL OR organization_id = $2) RETURNING id, username, role, phone, progress_surah, progress_ayah, progress_page", id, tenant).Scan(&user.ID, &user.Username, &user.Role, &user.Phone, &user.ProgressSurah, &user.ProgressAyah, &user.ProgressPage)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrUsernameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// FindDeletionPreflight reports the classes a user owns, how many students are
+// enrolled in them and the classes the user is enrolled in. These are the rows
+// the ON DELETE CASCADE rules remove once the user is purged.
+func (r *pgxUserRepository) FindDeletionPreflight(ctx context.Context, id int) (*models.DeletionPreflight, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	preflight := &models.DeletionPreflight{
+		ClassesOwned:    []models.Class{},
+		ClassesEnrolled: []models.Class{},
+	}
+	err = conn(ctx, r.db).QueryRow(ctx, "SELECT id, username, role FROM users WHERE id=$1 AND ($2::int IS NULL OR organization_id = $2)", id, tenant).Scan(&preflight.UserID, &preflight.Username, &preflight.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	owned, err := r.findClasses(ctx, "SELECT id, name, teacher_id FROM classes WHERE teacher_id=$1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	preflight.ClassesOwned = append(preflight.ClassesOwned, owned...)
+
+	enrolled, err := r.findClasses(ctx, `
+		SELECT c.id, c.name, c.teacher_id
+		FROM classes c
+		JOIN class_members cm ON cm.class_id = c.id
+		WHERE cm.student_id = $1
+		ORDER BY c.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	preflight.ClassesEnrolled = append(preflight.ClassesEnrolled, enrolled...)
+
+	query := `
+		SELECT COUNT(DISTINCT cm.student_id)
+		FROM class_members cm
+		JOIN classes c ON c.id = cm.class_id
+		WHERE c.teacher_id = $1
+	`
+	if err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(&preflight.StudentsEnrolled); err != nil {
+		return nil, err
+	}
+
+	return preflight, nil
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted before the given time.
+func (r *pgxUserRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1 AND ($2::int IS NULL OR organization_id = $2)", deletedBefore, tenant)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// FindExistingUsernames returns which of the usernames are already taken by
+// active users of the organization.
+func (r *pgxUserRepository) FindExistingUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn(ctx, r.db).Query(ctx, "SELECT username FROM users WHERE username = ANY($1) AND organization_id = $2 AND deleted_at IS NULL", usernames, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := []string{}
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		existing = append(existing, username)
+	}
+	return existing, rows.Err()
+}
+
+// FindAllClasses retrieves every class of the organization.
+func (r *pgxUserRepository) FindAllClasses(ctx context.Context) ([]models.Class, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.findClasses(ctx, "SELECT id, name, teacher_id FROM classes WHERE $1::int IS NULL OR organization_id = $1 ORDER BY id", tenant)
+}
+
+// ImportUsers creates the users, enrolls them in their class and records their
+// starting progress in a single transaction, filling in each user's ID. Either
+// every user is created or none is.
+func (r *pgxUserRepository) ImportUsers(ctx context.Context, users []models.ImportedUser) error {
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	return conn(ctx, r.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		for i := range users {
+			user := &users[i].User
+			query := `
+				INSERT INTO users (organization_id, username, password, role, phone, progress_surah, progress_ayah, progress_page)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				RETURNING id
+			`
+			err := tx.QueryRow(ctx, query, organizationID, user.Username, user.Password, user.Role, user.Phone, user.ProgressSurah, user.ProgressAyah, user.ProgressPage).
+				Scan(&user.ID)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", users[i].Row, err)
+			}
+
+			if users[i].ClassID != nil {
+				if _, err := tx.Exec(ctx, "INSERT INTO class_members (class_id, student_id) VALUES ($1, $2)", *users[i].ClassID, user.ID); err != nil {
+					return fmt.Errorf("row %d: %w", users[i].Row, err)
+				}
+			}
+			if user.ProgressSurah != nil && user.ProgressAyah != nil && user.ProgressPage != nil {
+				if _, err := tx.Exec(ctx, "INSERT INTO progress (organization_id, student_id, surah, ayah, page) VALUES ($1, $2, $3, $4, $5)",
+					organizationID, user.ID, *user.ProgressSurah, *user.ProgressAyah, *user.ProgressPage); err != nil {
+					return fmt.Errorf("row %d: %w", users[i].Row, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *pgxUserRepository) findClasses(ctx context.Context, query string, args ...interface{}) ([]models.Class, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []models.Class
+	for rows.Next() {
+		var class models.Class
+		if err := rows.Scan(&class.ID, &class.Name, &class.TeacherID); err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+	return classes, rows.Err()
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+// ErrWebhookNotFound is returned when a webhook subscription does not exist.
+var ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+const deliveryColumns = `d.id, d.subscription_id, d.event_id, e.type, d.status, d.attempts,
+	CASE WHEN d.status = 'pending' THEN d.next_attempt_at END,
+	d.last_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at`
+
+// WebhookRepository defines the interface for the event outbox, webhook
+// subscriptions and their deliveries.
+type WebhookRepository interface {
+	CreateEvent(ctx context.Context, eventType string, payload string) error
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	FindSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+	FindDeliveries(ctx context.Context, subscriptionID, limit, offset int) ([]models.WebhookDelivery, error)
+	FanOutEvents(ctx context.Context, limit int) (int64, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingDelivery, error)
+	MarkDelivered(ctx context.Context, id int64, statusCode int) error
+	MarkAttemptFailed(ctx context.Context, id int64, statusCode *int, message string, retryAt *time.Time) error
+	PruneEvents(ctx context.Context, createdBefore time.Time) (int64, error)
+}
+
+type pgxWebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewWebhookRepository creates a new webhook repository.
+func NewWebhookRepository(db *pgxpool.Pool) WebhookRepository {
+	return &pgxWebhookRepository{db: db}
+}
+
+// CreateEvent appends an event, given as a JSON document, to the outbox. Called
+// within Transactor.WithinTx, it is only committed along with the change it describes.
+func (r *pgxWebhookRepository) CreateEvent(ctx context.Context, eventType string, payload string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, "INSERT INTO outbox_events (organization_id, type, payload) VALUES ($1, $2, $3::jsonb)", optionalTenantID(ctx), eventType, payload)
+	return err
+}
+
+// CreateSubscription stores a subscription and fills in its ID and creation time.
+func (r *pgxWebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO webhook_subscriptions (organization_id, url, secret, events, description, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query, organizationID, subscription.URL, subscription.Secret, subscription.Events, subscription.Description, subscription.Active, subscription.CreatedBy).
+		Scan(&subscription.ID, &subscription.CreatedAt)
+}
+
+// FindSubscriptions lists the organization's subscriptions, without their secrets.
+func (r *pgxWebhookRepository) FindSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, url, events, description, active, created_by, created_at FROM webhook_subscriptions
+		WHERE $1::int IS NULL OR organization_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []models.WebhookSubscription{}
+	for rows.Next() {
+		var s models.WebhookSubscription
+		if err := rows.Scan(&s.ID, &s.URL, &s.Events, &s.Description, &s.Active, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// DeleteSubscription removes a subscription along with its delivery log.
+func (r *pgxWebhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	tag, err := r.db.Exec(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1 AND ($2::int IS NULL OR organization_id = $2)", id, tenant)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// FindDeliveries pages through a subscription's deliveries, newest first.
+func (r *pgxWebhookRepository) FindDeliveries(ctx context.Context, subscriptionID, limit, offset int) ([]models.WebhookDelivery, error) {
+	tenant, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1 AND ($2::int IS NULL OR organization_id = $2))"
+	if err := r.db.QueryRow(ctx, query, subscriptionID, tenant).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+
+	query = "SELECT " + deliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		WHERE d.subscription_id = $1
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(ctx, query, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// FanOutEvents creates a delivery for every active subscription of the same
+// organization interested in each of the oldest undispatched events, and marks
+// those events dispatched. It returns how many events were handled. Instances
+// running it concurrently skip each other's events.
+func (r *pgxWebhookRepository) FanOutEvents(ctx context.Context, limit int) (int64, error) {
+	query := `
+		WITH events AS (
+			SELECT id, organization_id, type FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), deliveries AS (
+			INSERT INTO webhook_deliveries (subscription_id, event_id)
+			SELECT s.id, e.id FROM events e
+			JOIN webhook_subscriptions s ON s.active AND s.organization_id = e.organization_id
+				AND (cardinality(s.events) = 0 OR e.type = ANY(s.events))
+			ON CONFLICT (subscription_id, event_id) DO NOTHING
+		)
+		UPDATE outbox_events SET dispatched_at = NOW() WHERE id IN (SELECT id FROM events)
+	`
+	tag, err := r.db.Exec(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDeliveries picks pending deliveries that are due and pushes their next
+// attempt back by the lease, so no other dispatcher picks them up while they
+// are being sent. A delivery whose sender dies is retried once the lease expires.
+func (r *pgxWebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id AND s.active
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW()
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM due, outbox_events e, webhook_subscriptions s
+		WHERE d.id = due.id AND e.id = d.event_id AND s.id = d.subscription_id
+		RETURNING d.id, d.attempts, e.id, e.type, e.payload::text, e.created_at, s.url, s.secret
+	`
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.PendingDelivery
+	for rows.Next() {
+		var d models.PendingDelivery
+		if err := rows.Scan(&d.ID, &d.Attempts, &d.EventID, &d.EventType, &d.Payload, &d.EventCreatedAt, &d.URL, &d.Secret); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkDelivered records a successful attempt.
+func (r *pgxWebhookRepository) MarkDelivered(ctx context.Context, id int64, statusCode int) error {
+	query := `
+		UPDATE webhook_deliveries SET status = 'succeeded', attempts = attempts + 1, last_attempt_at = NOW(),
+			last_status_code = $1, last_error = NULL, delivered_at = NOW()
+		WHERE id = $2
+	`
+	_, err := r.db.Exec(ctx, query, statusCode, id)
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt. The delivery is retried at
+// retryAt, or given up on when retryAt is nil.
+func (r *pgxWebhookRepository) MarkAttemptFailed(ctx context.Context, id int64, statusCode *int, message string, retryAt *time.Time) error {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3::timestamptz, next_attempt_at),
+			attempts = attempts + 1, last_attempt_at = NOW(), last_status_code = $1, last_error = $2
+		WHERE id = $4
+	`
+	_, err := r.db.Exec(ctx, query, statusCode, message, retryAt, id)
+	return err
+}
+
+// PruneEvents deletes dispatched events created before the cutoff, together
+// with their deliveries, and returns how many events were removed.
+func (r *pgxWebhookRepository) PruneEvents(ctx context.Context, createdBefore time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, "DELETE FROM outbox_events WHERE created_at < $1 AND dispatched_at IS NOT NULL", createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -1,52 +1,156 @@
 package routes
 
 import (
+	"log/slog"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/kolind-am/quran-project/backend/auth"
+	"github.com/kolind-am/quran-project/backend/config"
 	"github.com/kolind-am/quran-project/backend/database"
 	"github.com/kolind-am/quran-project/backend/handlers"
+	"github.com/kolind-am/quran-project/backend/metrics"
 	"github.com/kolind-am/quran-project/backend/middleware"
-	"github.com/kolind-am/quran-project/backend/repository"
-	"github.com/kolind-am/quran-project/backend/services"
+	"github.com/kolind-am/quran-project/backend/ratelimit"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// SetupRoutes configures all the application routes.
-func SetupRoutes(app *fiber.App, jwtSecret string) {
-	app.Use(logger.New())
-
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(database.DB)
-	studentRepo := repository.NewStudentRepository(database.DB)
+// SetupRoutes configures all the application routes on the given services.
+func SetupRoutes(app *fiber.App, cfg *config.Config, keys *auth.KeySet, svc *Services, limiter ratelimit.Store, logger *slog.Logger, healthHandler *handlers.HealthHandler) {
+	app.Use(middleware.RequestID())
+	app.Use(middleware.RequestLogger(logger))
+	app.Use(middleware.AccessLog())
+	app.Use(middleware.Metrics())
 
-	// Initialize services
-	userService := services.NewUserService(userRepo)
-	studentService := services.NewStudentService(studentRepo)
+	// Register collectors that read from the database
+	metrics.Registry.MustRegister(
+		metrics.NewPoolCollector(database.DB),
+		metrics.NewDomainCollector(svc.StatsRepo),
+	)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userRepo, jwtSecret)
-	userHandler := handlers.NewUserHandler(userService)
-	studentHandler := handlers.NewStudentHandler(studentService)
+	authHandler := handlers.NewAuthHandler(svc.UserRepo, keys, cfg.JWTExpiry)
+	userHandler := handlers.NewUserHandler(svc.User)
+	studentHandler := handlers.NewStudentHandler(svc.Student)
+	auditHandler := handlers.NewAuditHandler(svc.Audit)
+	teacherHandler := handlers.NewTeacherHandler(svc.Teacher)
+	statsHandler := handlers.NewStatsHandler(svc.Stats)
+	goalHandler := handlers.NewGoalHandler(svc.Goal)
+	khatmaHandler := handlers.NewKhatmaHandler(svc.Khatma)
+	reportHandler := handlers.NewReportHandler(svc.Report)
+	certificateHandler := handlers.NewCertificateHandler(svc.Certificate)
+	attendanceHandler := handlers.NewAttendanceHandler(svc.Attendance)
+	recitationHandler := handlers.NewRecitationHandler(svc.Recitation)
+	webhookHandler := handlers.NewWebhookHandler(svc.Webhook)
+	notificationHandler := handlers.NewNotificationHandler(svc.Notification)
+	eventsHandler := handlers.NewEventsHandler(svc.Live)
+	classHandler := handlers.NewClassHandler(svc.Class)
+	organizationHandler := handlers.NewOrganizationHandler(svc.Organization)
 
 	// Public routes
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Hello, World!")
 	})
+	app.Get("/.well-known/jwks.json", authHandler.JWKS)
+	app.Get("/healthz", healthHandler.Liveness)
+	app.Get("/readyz", healthHandler.Readiness)
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
 
 	// API group
 	api := app.Group("/api")
 
-	// Public API routes
-	api.Post("/login", authHandler.Login)
+	// Public API routes, for the organization named by subdomain or header
+	publicTenant := middleware.PublicTenant(svc.Organization, cfg.TenantBaseDomain)
+	api.Post("/login", middleware.RateLimit(limiter, "login", cfg.RateLimitLogin), publicTenant, authHandler.Login)
+	api.Get("/certificates/:code", middleware.RateLimit(limiter, "verify", cfg.RateLimitRead), publicTenant, certificateHandler.VerifyCertificate)
 
-	// Protected routes
-	protected := api.Group("/", middleware.Protected(jwtSecret))
+	// Protected routes, scoped to the user's organization
+	protected := api.Group("/",
+		middleware.Protected(keys, svc.UserRepo),
+		middleware.Tenant(svc.Organization, cfg.TenantBaseDomain),
+		middleware.Actor(),
+		middleware.RateLimitByMethod(limiter, cfg.RateLimitRead, cfg.RateLimitWrite),
+	)
 
 	// User Management
-	protected.Get("/users", userHandler.GetUsers)
-	protected.Post("/users", userHandler.CreateUser)
-	protected.Put("/users/:userId", userHandler.UpdateUser)
-	protected.Delete("/users/:userId", userHandler.DeleteUser)
+	protected.Get("/users", middleware.RequireRoleWithQuery("deleted", "admin", "developer"), userHandler.GetUsers)
+	protected.Post("/users", middleware.RequireRole("admin", "developer"), userHandler.CreateUser)
+	protected.Post("/users/import", middleware.RequireRole("admin", "developer"), userHandler.ImportUsers)
+	protected.Put("/users/:userId", middleware.RequireRole("admin", "developer", "teacher"), userHandler.UpdateUser)
+	protected.Delete("/users/:userId", middleware.RequireRole("admin", "developer"), userHandler.DeleteUser)
+	protected.Get("/users/:userId/deletion-preflight", middleware.RequireRole("admin", "developer"), userHandler.GetDeletionPreflight)
+	protected.Post("/users/:userId/restore", middleware.RequireRole("admin", "developer"), userHandler.RestoreUser)
+
+	// Notification preferences
+	protected.Get("/users/me/notification-preferences", notificationHandler.GetMyPreferences)
+	protected.Put("/users/me/notification-preferences", notificationHandler.UpdateMyPreferences)
+	protected.Get("/users/:userId/notification-preferences", middleware.RequireRole("admin", "developer"), notificationHandler.GetPreferences)
+	protected.Put("/users/:userId/notification-preferences", middleware.RequireRole("admin", "developer"), notificationHandler.UpdatePreferences)
 
 	// Student Management
 	protected.Get("/students/me", studentHandler.GetMyData)
+	protected.Get("/students/me/progress", studentHandler.GetMyProgress)
+	protected.Get("/students/me/progress/analytics", studentHandler.GetMyProgressAnalytics)
+	protected.Get("/students/:studentId/progress", studentHandler.GetProgress)
+	protected.Get("/students/:studentId/progress/analytics", studentHandler.GetProgressAnalytics)
+
+	// Goals
+	protected.Post("/goals", goalHandler.CreateGoal)
+	protected.Get("/goals/:goalId", goalHandler.GetGoal)
+	protected.Delete("/goals/:goalId", goalHandler.DeleteGoal)
+	protected.Get("/students/me/goals", goalHandler.GetMyGoals)
+	protected.Get("/students/:studentId/goals", goalHandler.GetStudentGoals)
+	protected.Get("/classes/:classId/goals", goalHandler.GetClassGoals)
+
+	// Khatmas
+	protected.Get("/students/me/khatmas", khatmaHandler.GetMyKhatmas)
+	protected.Get("/students/:studentId/khatmas", khatmaHandler.GetStudentKhatmas)
+	protected.Get("/classes/:classId/khatmas", khatmaHandler.GetClassKhatmas)
+
+	// Class membership
+	protected.Post("/classes/:classId/members", classHandler.AddMember)
+	protected.Delete("/classes/:classId/members/:studentId", classHandler.RemoveMember)
+
+	// Live class events, as server-sent events
+	protected.Get("/events", eventsHandler.Stream)
+
+	// Attendance
+	protected.Post("/classes/:classId/attendance", attendanceHandler.MarkAttendance)
+	protected.Get("/classes/:classId/attendance", attendanceHandler.GetAttendance)
+
+	// Graded recitations
+	protected.Post("/classes/:classId/recitations", recitationHandler.RecordRecitation)
+
+	// Class reports, as CSV, XLSX or PDF
+	protected.Get("/classes/:classId/reports/progress", reportHandler.GetClassProgressReport)
+	protected.Get("/classes/:classId/reports/attendance", reportHandler.GetAttendanceReport)
+	protected.Get("/classes/:classId/reports/recitations", reportHandler.GetRecitationReport)
+
+	// Certificates
+	protected.Post("/certificates", middleware.RequireRole("teacher", "admin", "developer"), certificateHandler.IssueCertificate)
+	protected.Get("/certificates/:code/pdf", certificateHandler.DownloadCertificate)
+	protected.Get("/students/me/certificates", certificateHandler.GetStudentCertificates)
+	protected.Get("/students/:studentId/certificates", certificateHandler.GetStudentCertificates)
+
+	// Teacher dashboard
+	protected.Get("/teachers/me/dashboard", middleware.RequireRole("teacher"), teacherHandler.GetMyDashboard)
+
+	// Audit log
+	protected.Get("/audit", middleware.RequireRole("admin", "developer"), auditHandler.GetEvents)
+
+	// Admin overview
+	protected.Get("/admin/stats", middleware.RequireRole("admin", "developer"), statsHandler.GetAdminStats)
+
+	// Organizations, managed by developers
+	protected.Get("/admin/organizations", middleware.RequireRole("developer"), organizationHandler.GetOrganizations)
+	protected.Post("/admin/organizations", middleware.RequireRole("developer"), organizationHandler.CreateOrganization)
+
+	// Outbound webhooks
+	protected.Post("/admin/webhooks", middleware.RequireRole("admin", "developer"), webhookHandler.CreateWebhook)
+	protected.Get("/admin/webhooks", middleware.RequireRole("admin", "developer"), webhookHandler.GetWebhooks)
+	protected.Delete("/admin/webhooks/:webhookId", middleware.RequireRole("admin", "developer"), webhookHandler.DeleteWebhook)
+	protected.Get("/admin/webhooks/:webhookId/deliveries", middleware.RequireRole("admin", "developer"), webhookHandler.GetDeliveries)
+
+	// Notification queue
+	protected.Get("/admin/notifications", middleware.RequireRole("admin", "developer"), notificationHandler.GetNotifications)
 }
@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"github.com/kolind-am/quran-project/backend/config"
+	"github.com/kolind-am/quran-project/backend/database"
+	"github.com/kolind-am/quran-project/backend/notify"
+	"github.com/kolind-am/quran-project/backend/pdf"
+	"github.com/kolind-am/quran-project/backend/realtime"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/services"
+	"github.com/kolind-am/quran-project/backend/webhook"
+)
+
+// Services is the application's service graph. It is built once and shared by
+// the routes and the background jobs, so both see the same instances.
+type Services struct {
+	// The authentication middleware and the metrics collectors read these
+	// repositories directly.
+	UserRepo  repository.UserRepository
+	StatsRepo repository.StatsRepository
+
+	Audit        services.AuditService
+	Organization services.OrganizationService
+	Khatma       services.KhatmaService
+	Notification services.NotificationService
+	Live         services.LiveService
+	User         services.UserService
+	Student      services.StudentService
+	Teacher      services.TeacherService
+	Stats        services.StatsService
+	Goal         services.GoalService
+	Report       services.ReportService
+	Certificate  services.CertificateService
+	Attendance   services.AttendanceService
+	Recitation   services.RecitationService
+	Class        services.ClassService
+	Webhook      services.WebhookService
+}
+
+// NewServices builds the service graph on the database connection.
+func NewServices(cfg *config.Config, certificateTemplates *pdf.CertificateTemplates, notificationChannels notify.Channels, broker realtime.Broker) *Services {
+	// Initialize repositories
+	userRepo := repository.NewUserRepository(database.DB)
+	studentRepo := repository.NewStudentRepository(database.DB)
+	auditRepo := repository.NewAuditRepository(database.DB)
+	statsRepo := repository.NewStatsRepository(database.DB)
+	teacherRepo := repository.NewTeacherRepository(database.DB)
+	progressRepo := repository.NewProgressRepository(database.DB)
+	goalRepo := repository.NewGoalRepository(database.DB)
+	classRepo := repository.NewClassRepository(database.DB)
+	khatmaRepo := repository.NewKhatmaRepository(database.DB)
+	reportRepo := repository.NewReportRepository(database.DB)
+	certificateRepo := repository.NewCertificateRepository(database.DB)
+	attendanceRepo := repository.NewAttendanceRepository(database.DB)
+	recitationRepo := repository.NewRecitationRepository(database.DB)
+	webhookRepo := repository.NewWebhookRepository(database.DB)
+	notificationRepo := repository.NewNotificationRepository(database.DB)
+	organizationRepo := repository.NewOrganizationRepository(database.DB)
+	transactor := repository.NewTransactor(database.DB)
+
+	// Initialize services
+	auditService := services.NewAuditService(auditRepo)
+	khatmaService := services.NewKhatmaService(khatmaRepo, classRepo, auditService)
+	eventPublisher := services.NewEventPublisher(webhookRepo)
+	notificationService := services.NewNotificationService(notificationRepo, notificationChannels, auditService, cfg.NotificationMaxAttempts)
+	liveService := services.NewLiveService(broker, classRepo)
+
+	return &Services{
+		UserRepo:  userRepo,
+		StatsRepo: statsRepo,
+
+		Audit:        auditService,
+		Organization: services.NewOrganizationService(organizationRepo, auditService),
+		Khatma:       khatmaService,
+		Notification: notificationService,
+		Live:         liveService,
+		User:         services.NewUserService(userRepo, progressRepo, classRepo, khatmaService, auditService, transactor, eventPublisher, notificationService, liveService, cfg.BcryptCost),
+		Student:      services.NewStudentService(studentRepo, progressRepo, classRepo, goalRepo, khatmaRepo),
+		Teacher:      services.NewTeacherService(teacherRepo),
+		Stats:        services.NewStatsService(statsRepo, cfg.StatsCacheTTL),
+		Goal:         services.NewGoalService(goalRepo, classRepo, auditService),
+		Report:       services.NewReportService(reportRepo, classRepo),
+		Certificate:  services.NewCertificateService(certificateRepo, userRepo, khatmaRepo, classRepo, auditService, certificateTemplates, cfg.CertificateVerifyURL),
+		Attendance:   services.NewAttendanceService(attendanceRepo, classRepo, auditService, transactor, eventPublisher, notificationService, liveService),
+		Recitation:   services.NewRecitationService(recitationRepo, classRepo, auditService),
+		Class:        services.NewClassService(classRepo, auditService, liveService),
+		Webhook:      services.NewWebhookService(webhookRepo, webhook.NewSender(cfg.WebhookTimeout), auditService, cfg.WebhookMaxAttempts, 2*cfg.WebhookTimeout),
+	}
+}
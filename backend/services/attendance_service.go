@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/notify"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+var attendanceStatuses = []string{"present", "absent", "late", "excused"}
+
+// ErrInvalidAttendance is wrapped by the validation errors of MarkAttendance.
+var ErrInvalidAttendance = errors.New("invalid attendance")
+
+// AttendanceService defines the interface for taking class attendance. Only
+// admins and the class's teacher may take or see it.
+type AttendanceService interface {
+	MarkAttendance(ctx context.Context, viewerID int, viewerRole string, sheet *models.AttendanceSheet) error
+	GetAttendance(ctx context.Context, viewerID int, viewerRole string, classID int, date string) (*models.AttendanceSheet, error)
+}
+
+type attendanceService struct {
+	repo     repository.AttendanceRepository
+	classes  repository.ClassRepository
+	audit    AuditService
+	tx       repository.Transactor
+	events   EventPublisher
+	notifier Notifier
+	live     LivePublisher
+}
+
+// NewAttendanceService creates a new attendance service.
+func NewAttendanceService(repo repository.AttendanceRepository, classes repository.ClassRepository, audit AuditService, tx repository.Transactor, events EventPublisher, notifier Notifier, live LivePublisher) AttendanceService {
+	return &attendanceService{repo: repo, classes: classes, audit: audit, tx: tx, events: events, notifier: notifier, live: live}
+}
+
+// MarkAttendance records the marks of a class for a day, today when the sheet
+// has no date. Students marked again that day get their new mark. Absent
+// students are notified.
+func (s *attendanceService) MarkAttendance(ctx context.Context, viewerID int, viewerRole string, sheet *models.AttendanceSheet) error {
+	className, err := s.authorize(ctx, viewerID, viewerRole, sheet.ClassID)
+	if err != nil {
+		return err
+	}
+	date, err := attendanceDate(sheet.Date)
+	if err != nil {
+		return err
+	}
+	sheet.Date = date
+	if len(sheet.Records) == 0 {
+		return fmt.Errorf("%w: no students were marked", ErrInvalidAttendance)
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		members, err := s.repo.FindClassMemberIDs(ctx, sheet.ClassID)
+		if err != nil {
+			return err
+		}
+		seen := map[int]bool{}
+		for _, record := range sheet.Records {
+			if !slices.Contains(attendanceStatuses, record.Status) {
+				return fmt.Errorf("%w: status must be one of %v", ErrInvalidAttendance, attendanceStatuses)
+			}
+			if !slices.Contains(members, record.StudentID) {
+				return fmt.Errorf("%w: student %d is not in the class", ErrInvalidAttendance, record.StudentID)
+			}
+			if seen[record.StudentID] {
+				return fmt.Errorf("%w: student %d is marked twice", ErrInvalidAttendance, record.StudentID)
+			}
+			seen[record.StudentID] = true
+		}
+
+		if err := s.repo.MarkAttendance(ctx, sheet.ClassID, sheet.Date, viewerID, sheet.Records); err != nil {
+			return err
+		}
+		for _, record := range sheet.Records {
+			if record.Status != "absent" {
+				continue
+			}
+			err := s.notifier.Notify(ctx, record.StudentID, notify.StudentAbsent, map[string]interface{}{
+				"ClassName": className,
+				"Date":      sheet.Date,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return s.events.Publish(ctx, EventAttendanceMarked, map[string]interface{}{
+			"class_id":  sheet.ClassID,
+			"date":      sheet.Date,
+			"marked_by": viewerID,
+			"records":   sheet.Records,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditAttendanceMark, "class", &sheet.ClassID, nil, sheet)
+
+	counts := map[string]int{}
+	for _, record := range sheet.Records {
+		counts[record.Status]++
+	}
+	s.live.PublishClassEvent(ctx, sheet.ClassID, LiveAttendanceMarked, map[string]interface{}{
+		"date":      sheet.Date,
+		"marked_by": viewerID,
+		"counts":    counts,
+	})
+	return nil
+}
+
+// GetAttendance returns every class member with their mark for the day, today
+// when date is empty.
+func (s *attendanceService) GetAttendance(ctx context.Context, viewerID int, viewerRole string, classID int, date string) (*models.AttendanceSheet, error) {
+	if _, err := s.authorize(ctx, viewerID, viewerRole, classID); err != nil {
+		return nil, err
+	}
+	date, err := attendanceDate(date)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.repo.FindAttendance(ctx, classID, date)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AttendanceSheet{ClassID: classID, Date: date, Records: records}, nil
+}
+
+// authorize checks that the caller may take the class's attendance and
+// returns the class's name.
+func (s *attendanceService) authorize(ctx context.Context, viewerID int, viewerRole string, classID int) (string, error) {
+	name, err := s.classes.FindClassName(ctx, classID)
+	if err != nil {
+		return "", err
+	}
+	switch viewerRole {
+	case "admin", "developer":
+		return name, nil
+	case "teacher":
+		taught, err := s.classes.IsClassTaughtBy(ctx, classID, viewerID)
+		if err != nil {
+			return "", err
+		}
+		if !taught {
+			return "", ErrForbidden
+		}
+		return name, nil
+	default:
+		return "", ErrForbidden
+	}
+}
+
+// attendanceDate validates a YYYY-MM-DD date, defaulting to today.
+func attendanceDate(date string) (string, error) {
+	if date == "" {
+		return time.Now().Format(dateLayout), nil
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return "", fmt.Errorf("%w: date must be in YYYY-MM-DD format", ErrInvalidAttendance)
+	}
+	return date, nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/notify"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// fakeAttendanceRepo holds the members of each class and the marks saved.
+type fakeAttendanceRepo struct {
+	members map[int][]int
+	marked  []models.AttendanceRecord
+	date    string
+}
+
+func (r *fakeAttendanceRepo) FindClassMemberIDs(_ context.Context, classID int) ([]int, error) {
+	return r.members[classID], nil
+}
+
+func (r *fakeAttendanceRepo) MarkAttendance(_ context.Context, _ int, date string, _ int, records []models.AttendanceRecord) error {
+	r.date = date
+	r.marked = append(r.marked, records...)
+	return nil
+}
+
+func (r *fakeAttendanceRepo) FindAttendance(_ context.Context, classID int, _ string) ([]models.AttendanceRecord, error) {
+	records := []models.AttendanceRecord{}
+	for _, id := range r.members[classID] {
+		record := models.AttendanceRecord{StudentID: id}
+		for _, marked := range r.marked {
+			if marked.StudentID == id {
+				record.Status = marked.Status
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// attendanceFixture is an attendance service over fakes: class 3 is taught by
+// teacher 20 and has students 30 and 31.
+type attendanceFixture struct {
+	service  AttendanceService
+	repo     *fakeAttendanceRepo
+	audit    *fakeAudit
+	events   *fakeEvents
+	notifier *fakeNotifier
+	live     *fakeLive
+}
+
+func newAttendanceFixture() *attendanceFixture {
+	f := &attendanceFixture{
+		repo:     &fakeAttendanceRepo{members: map[int][]int{3: {30, 31}}},
+		audit:    &fakeAudit{},
+		events:   &fakeEvents{},
+		notifier: &fakeNotifier{},
+		live:     &fakeLive{},
+	}
+	classes := &fakeClassRepo{teachers: map[int]int{3: 20}}
+	f.service = NewAttendanceService(f.repo, classes, f.audit, fakeTx{}, f.events, f.notifier, f.live)
+	return f
+}
+
+func TestMarkAttendance(t *testing.T) {
+	tests := []struct {
+		name     string
+		viewerID int
+		role     string
+		sheet    models.AttendanceSheet
+		want     error
+	}{
+		{
+			name: "class teacher", viewerID: 20, role: "teacher",
+			sheet: models.AttendanceSheet{ClassID: 3, Date: "2025-03-01", Records: []models.AttendanceRecord{{StudentID: 30, Status: "present"}, {StudentID: 31, Status: "absent"}}},
+		},
+		{
+			name: "admin", viewerID: 1, role: "admin",
+			sheet: models.AttendanceSheet{ClassID: 3, Date: "2025-03-01", Records: []models.AttendanceRecord{{StudentID: 30, Status: "late"}}},
+		},
+		{
+			name: "other teacher", viewerID: 21, role: "teacher",
+			sheet: models.AttendanceSheet{ClassID: 3, Records: []models.AttendanceRecord{{StudentID: 30, Status: "present"}}},
+			want:  ErrForbidden,
+		},
+		{
+			name: "student", viewerID: 30, role: "student",
+			sheet: models.AttendanceSheet{ClassID: 3, Records: []models.AttendanceRecord{{StudentID: 30, Status: "present"}}},
+			want:  ErrForbidden,
+		},
+		{
+			name: "unknown class", viewerID: 1, role: "admin",
+			sheet: models.AttendanceSheet{ClassID: 9, Records: []models.AttendanceRecord{{StudentID: 30, Status: "present"}}},
+			want:  repository.ErrClassNotFound,
+		},
+		{
+			name: "bad date", viewerID: 20, role: "teacher",
+			sheet: models.AttendanceSheet{ClassID: 3, Date: "01/03/2025", Records: []models.AttendanceRecord{{StudentID: 30, Status: "present"}}},
+			want:  ErrInvalidAttendance,
+		},
+		{
+			name: "no records", viewerID: 20, role: "teacher",
+			sheet: models.AttendanceSheet{ClassID: 3},
+			want:  ErrInvalidAttendance,
+		},
+		{
+			name: "unknown status", viewerID: 20, role: "teacher",
+			sheet: models.AttendanceSheet{ClassID: 3, Records: []models.AttendanceRecord{{StudentID: 30, Status: "asleep"}}},
+			want:  ErrInvalidAttendance,
+		},
+		{
+			name: "student outside the class", viewerID: 20, role: "teacher",
+			sheet: models.AttendanceSheet{ClassID: 3, Records: []models.AttendanceRecord{{StudentID: 40, Status: "present"}}},
+			want:  ErrInvalidAttendance,
+		},
+		{
+			name: "student marked twice", viewerID: 20, role: "teacher",
+			sheet: models.AttendanceSheet{ClassID: 3, Records: []models.AttendanceRecord{{StudentID: 30, Status: "present"}, {StudentID: 30, Status: "absent"}}},
+			want:  ErrInvalidAttendance,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAttendanceFixture()
+			err := f.service.MarkAttendance(context.Background(), tt.viewerID, tt.role, &tt.sheet)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("MarkAttendance = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if len(f.repo.marked) != 0 || len(f.audit.actions) != 0 || len(f.events.types) != 0 || len(f.live.events) != 0 {
+					t.Errorf("rejected sheet had effects: marked %v, audit %v, events %v, live %v", f.repo.marked, f.audit.actions, f.events.types, f.live.events)
+				}
+				return
+			}
+			if !reflect.DeepEqual(f.repo.marked, tt.sheet.Records) {
+				t.Errorf("marked %v, want %v", f.repo.marked, tt.sheet.Records)
+			}
+			if !reflect.DeepEqual(f.audit.actions, []string{AuditAttendanceMark}) {
+				t.Errorf("audited %v", f.audit.actions)
+			}
+			if !reflect.DeepEqual(f.events.types, []string{EventAttendanceMarked}) {
+				t.Errorf("published %v", f.events.types)
+			}
+			if !reflect.DeepEqual(f.live.events, []string{LiveAttendanceMarked + ":class:3"}) {
+				t.Errorf("live events %v", f.live.events)
+			}
+		})
+	}
+}
+
+func TestMarkAttendanceNotifiesAbsentStudents(t *testing.T) {
+	f := newAttendanceFixture()
+	sheet := &models.AttendanceSheet{ClassID: 3, Records: []models.AttendanceRecord{
+		{StudentID: 30, Status: "absent"},
+		{StudentID: 31, Status: "excused"},
+	}}
+	if err := f.service.MarkAttendance(context.Background(), 20, "teacher", sheet); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{notify.StudentAbsent + ":30"}; !reflect.DeepEqual(f.notifier.sent, want) {
+		t.Errorf("notified %v, want %v", f.notifier.sent, want)
+	}
+	if today := time.Now().Format(dateLayout); sheet.Date != today || f.repo.date != today {
+		t.Errorf("sheet dated %q, saved for %q; want today, %s", sheet.Date, f.repo.date, today)
+	}
+}
+
+func TestMarkAttendanceFailsWithTheNotification(t *testing.T) {
+	f := newAttendanceFixture()
+	f.notifier.err = errors.New("queue unavailable")
+	sheet := &models.AttendanceSheet{ClassID: 3, Records: []models.AttendanceRecord{{StudentID: 30, Status: "absent"}}}
+
+	if err := f.service.MarkAttendance(context.Background(), 20, "teacher", sheet); !errors.Is(err, f.notifier.err) {
+		t.Fatalf("MarkAttendance = %v, want %v", err, f.notifier.err)
+	}
+	if len(f.audit.actions) != 0 || len(f.events.types) != 0 || len(f.live.events) != 0 {
+		t.Errorf("failed marking was announced: audit %v, events %v, live %v", f.audit.actions, f.events.types, f.live.events)
+	}
+}
+
+func TestGetAttendance(t *testing.T) {
+	f := newAttendanceFixture()
+	f.repo.marked = []models.AttendanceRecord{{StudentID: 31, Status: "late"}}
+
+	if _, err := f.service.GetAttendance(context.Background(), 21, "teacher", 3, ""); !errors.Is(err, ErrForbidden) {
+		t.Errorf("another teacher's view = %v, want ErrForbidden", err)
+	}
+	if _, err := f.service.GetAttendance(context.Background(), 20, "teacher", 3, "2025-13-01"); !errors.Is(err, ErrInvalidAttendance) {
+		t.Errorf("invalid date = %v, want ErrInvalidAttendance", err)
+	}
+
+	sheet, err := f.service.GetAttendance(context.Background(), 20, "teacher", 3, "2025-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &models.AttendanceSheet{ClassID: 3, Date: "2025-03-01", Records: []models.AttendanceRecord{
+		{StudentID: 30},
+		{StudentID: 31, Status: "late"},
+	}}
+	if !reflect.DeepEqual(sheet, want) {
+		t.Errorf("sheet = %+v, want %+v", sheet, want)
+	}
+}
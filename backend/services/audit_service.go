@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// Audit actions recorded by the services.
+const (
+	AuditUserCreate              = "user.create"
+	AuditUserUpdate              = "user.update"
+	AuditUserDelete              = "user.delete"
+	AuditUserRestore             = "user.restore"
+	AuditUserPurge               = "user.purge"
+	AuditUserImport              = "user.import"
+	AuditProgressRecord          = "progress.record"
+	AuditGoalCreate              = "goal.create"
+	AuditGoalDelete              = "goal.delete"
+	AuditKhatmaStart             = "khatma.start"
+	AuditKhatmaComplete          = "khatma.complete"
+	AuditKhatmaAbandon           = "khatma.abandon"
+	AuditCertificateIssue        = "certificate.issue"
+	AuditWebhookCreate           = "webhook.create"
+	AuditWebhookDelete           = "webhook.delete"
+	AuditAttendanceMark          = "attendance.mark"
+	AuditRecitationRecord        = "recitation.record"
+	AuditNotificationPreferences = "notification.preferences"
+	AuditClassMemberAdd          = "class.member_add"
+	AuditClassMemberRemove       = "class.member_remove"
+	AuditOrganizationCreate      = "organization.create"
+)
+
+const redactedValue = "[REDACTED]"
+
+// sensitiveFields are never written to the audit log in clear text.
+var sensitiveFields = []string{"password", "token", "secret"}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor performing the request.
+func WithActor(ctx context.Context, actor models.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, if any.
+func ActorFromContext(ctx context.Context) (models.Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(models.Actor)
+	return actor, ok
+}
+
+// AuditService defines the interface for recording and querying audit events.
+type AuditService interface {
+	Record(ctx context.Context, action, entityType string, entityID *int, before, after interface{})
+	GetEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+// NewAuditService creates a new audit service.
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// Record stores an audit event for the actor in ctx. before and after are
+// snapshots of the entity (nil for creations and deletions respectively);
+// only the fields that differ are kept, with secrets redacted. Failures are
+// logged rather than returned so auditing never blocks the action itself.
+func (s *auditService) Record(ctx context.Context, action, entityType string, entityID *int, before, after interface{}) {
+	event := &models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    diff(toFields(before), toFields(after)),
+	}
+	if actor, ok := ActorFromContext(ctx); ok {
+		event.ActorID = &actor.ID
+		event.ActorRole = &actor.Role
+		event.IP = &actor.IP
+	}
+
+	if err := s.repo.CreateEvent(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("recording audit event failed",
+			slog.String("action", action), slog.String("entity_type", entityType), slog.Any("error", err))
+	}
+}
+
+// GetEvents retrieves audit events matching the filter.
+func (s *auditService) GetEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	return s.repo.FindEvents(ctx, filter)
+}
+
+// toFields flattens a snapshot into its JSON fields.
+func toFields(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+func diff(before, after map[string]interface{}) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+	for key, from := range before {
+		to, ok := after[key]
+		if !ok || !reflect.DeepEqual(from, to) {
+			changes[key] = redact(key, models.FieldChange{From: from, To: to})
+		}
+	}
+	for key, to := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = redact(key, models.FieldChange{To: to})
+		}
+	}
+	return changes
+}
+
+func redact(key string, change models.FieldChange) models.FieldChange {
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			if change.From != nil {
+				change.From = redactedValue
+			}
+			if change.To != nil {
+				change.To = redactedValue
+			}
+		}
+	}
+	return change
+}
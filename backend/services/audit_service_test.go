@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{"username": "ali", "role": "student", "phone": "0100", "password": "old-hash"}
+	after := map[string]interface{}{"username": "ali", "role": "teacher", "password": "new-hash", "progress_page": 12.0}
+
+	got := diff(before, after)
+	want := map[string]models.FieldChange{
+		"role":          {From: "student", To: "teacher"},
+		"phone":         {From: "0100"},
+		"password":      {From: redactedValue, To: redactedValue},
+		"progress_page": {To: 12.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff = %v, want %v", got, want)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		key  string
+		in   models.FieldChange
+		want models.FieldChange
+	}{
+		{key: "password", in: models.FieldChange{From: "a", To: "b"}, want: models.FieldChange{From: redactedValue, To: redactedValue}},
+		{key: "Secret", in: models.FieldChange{To: "s"}, want: models.FieldChange{To: redactedValue}},
+		{key: "refresh_token", in: models.FieldChange{From: "t"}, want: models.FieldChange{From: redactedValue}},
+		{key: "username", in: models.FieldChange{From: "a", To: "b"}, want: models.FieldChange{From: "a", To: "b"}},
+	}
+	for _, tt := range tests {
+		if got := redact(tt.key, tt.in); got != tt.want {
+			t.Errorf("redact(%q, %v) = %v, want %v", tt.key, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToFields(t *testing.T) {
+	var none *models.User
+	if got := toFields(none); len(got) != 0 {
+		t.Errorf("toFields(nil pointer) = %v, want no fields", got)
+	}
+	got := toFields(&models.User{ID: 3, Username: "ali"})
+	if got["id"] != 3.0 || got["username"] != "ali" {
+		t.Errorf("toFields = %v", got)
+	}
+}
+
+// fakeAuditRepo keeps the events it is given.
+type fakeAuditRepo struct {
+	repository.AuditRepository
+	events []*models.AuditEvent
+}
+
+func (r *fakeAuditRepo) CreateEvent(_ context.Context, event *models.AuditEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	repo := &fakeAuditRepo{}
+	ctx := WithActor(context.Background(), models.Actor{ID: 4, Role: "admin", IP: "203.0.113.9"})
+	id := 7
+	NewAuditService(repo).Record(ctx, AuditUserUpdate, "user", &id, &models.User{ID: 7, Role: "student"}, &models.User{ID: 7, Role: "teacher"})
+
+	if len(repo.events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(repo.events))
+	}
+	event := repo.events[0]
+	if *event.ActorID != 4 || *event.ActorRole != "admin" || *event.IP != "203.0.113.9" || *event.EntityID != 7 {
+		t.Errorf("event = %+v, want it attributed to the actor", event)
+	}
+	if len(event.Changes) != 1 || event.Changes["role"] != (models.FieldChange{From: "student", To: "teacher"}) {
+		t.Errorf("changes = %v, want only the role", event.Changes)
+	}
+}
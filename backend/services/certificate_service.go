@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/pdf"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// Certificate achievements.
+const (
+	AchievementJuz    = "juz"
+	AchievementKhatma = "khatma"
+)
+
+// codeAlphabet leaves out letters and digits that are easily confused when a
+// code is typed in from paper. It has 32 symbols, so each random byte maps onto
+// it without bias.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// codeLength is the number of symbols in a verification code, printed in groups of four.
+const codeLength = 12
+
+// ErrInvalidCertificate is wrapped by the validation errors of IssueCertificate.
+var ErrInvalidCertificate = errors.New("invalid certificate")
+
+// CertificateService defines the interface for issuing and verifying certificates.
+type CertificateService interface {
+	IssueCertificate(ctx context.Context, viewerID int, viewerRole string, req *models.CertificateRequest) (*models.Certificate, error)
+	VerifyCertificate(ctx context.Context, code string) (*models.CertificateVerification, error)
+	RenderCertificate(ctx context.Context, viewerID int, viewerRole string, code string, w io.Writer) (*models.Certificate, error)
+	GetStudentCertificates(ctx context.Context, viewerID int, viewerRole string, studentID int) ([]models.Certificate, error)
+}
+
+type certificateService struct {
+	repo      repository.CertificateRepository
+	users     repository.UserRepository
+	khatmas   repository.KhatmaRepository
+	classes   repository.ClassRepository
+	audit     AuditService
+	templates *pdf.CertificateTemplates
+	verifyURL string
+}
+
+// NewCertificateService creates a new certificate service. verifyURL, when set,
+// is printed on certificates with the verification code appended.
+func NewCertificateService(repo repository.CertificateRepository, users repository.UserRepository, khatmas repository.KhatmaRepository, classes repository.ClassRepository, audit AuditService, templates *pdf.CertificateTemplates, verifyURL string) CertificateService {
+	return &certificateService{repo: repo, users: users, khatmas: khatmas, classes: classes, audit: audit, templates: templates, verifyURL: verifyURL}
+}
+
+// IssueCertificate records a certificate for a student's completed juz or
+// khatma. Admins may issue certificates to any student, teachers to the
+// students in their classes. A khatma must have been completed, and a juz
+// read to its last page.
+func (s *certificateService) IssueCertificate(ctx context.Context, viewerID int, viewerRole string, req *models.CertificateRequest) (*models.Certificate, error) {
+	switch req.Achievement {
+	case AchievementJuz:
+		if req.Juz == nil || *req.Juz < 1 || *req.Juz > quran.JuzCount {
+			return nil, fmt.Errorf("%w: juz must be between 1 and %d", ErrInvalidCertificate, quran.JuzCount)
+		}
+		req.KhatmaID = nil
+	case AchievementKhatma:
+		if req.KhatmaID == nil {
+			return nil, fmt.Errorf("%w: khatma_id is required", ErrInvalidCertificate)
+		}
+		req.Juz = nil
+	default:
+		return nil, fmt.Errorf("%w: achievement must be %q or %q", ErrInvalidCertificate, AchievementJuz, AchievementKhatma)
+	}
+	if req.Template == "" {
+		req.Template = pdf.DefaultCertificateTemplate
+	}
+	if _, ok := s.templates.Lookup(req.Template); !ok {
+		return nil, fmt.Errorf("%w: unknown template %q", ErrInvalidCertificate, req.Template)
+	}
+
+	switch viewerRole {
+	case "admin", "developer":
+	case "teacher":
+		taught, err := s.classes.IsStudentTaughtBy(ctx, req.StudentID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !taught {
+			return nil, ErrForbidden
+		}
+	default:
+		return nil, ErrForbidden
+	}
+
+	student, err := s.users.FindUserByID(ctx, req.StudentID)
+	if errors.Is(err, repository.ErrUserNotFound) || (err == nil && student.Role != "student") {
+		return nil, fmt.Errorf("%w: student %d does not exist", ErrInvalidCertificate, req.StudentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	teacher, err := s.users.FindUserByID(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Achievement {
+	case AchievementJuz:
+		if err := s.checkJuzCompleted(ctx, student, *req.Juz); err != nil {
+			return nil, err
+		}
+	case AchievementKhatma:
+		if err := s.checkKhatmaCompleted(ctx, student.ID, *req.KhatmaID); err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := newCertificateCode()
+	if err != nil {
+		return nil, err
+	}
+	studentName := strings.TrimSpace(req.StudentName)
+	if studentName == "" {
+		studentName = student.Username
+	}
+	certificate := &models.Certificate{
+		Code:        code,
+		StudentID:   &student.ID,
+		StudentName: studentName,
+		TeacherID:   &teacher.ID,
+		TeacherName: teacher.Username,
+		Achievement: req.Achievement,
+		Juz:         req.Juz,
+		KhatmaID:    req.KhatmaID,
+		Template:    req.Template,
+	}
+	if err := s.repo.CreateCertificate(ctx, certificate); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, AuditCertificateIssue, "certificate", &certificate.ID, nil, certificate)
+	return certificate, nil
+}
+
+func (s *certificateService) checkJuzCompleted(ctx context.Context, student *models.User, juz int) error {
+	_, last, _ := quran.JuzPages(juz)
+	if student.ProgressPage != nil && *student.ProgressPage >= last {
+		return nil
+	}
+	// A student who has read the whole Quran has completed every juz, even
+	// once their position has wrapped around to the start again.
+	khatmas, err := s.khatmas.FindKhatmasForStudent(ctx, student.ID)
+	if err != nil {
+		return err
+	}
+	for _, khatma := range khatmas {
+		if khatma.Status == KhatmaCompleted {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: juz %d has not been completed", ErrInvalidCertificate, juz)
+}
+
+func (s *certificateService) checkKhatmaCompleted(ctx context.Context, studentID, khatmaID int) error {
+	khatmas, err := s.khatmas.FindKhatmasForStudent(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	for _, khatma := range khatmas {
+		if khatma.ID != khatmaID {
+			continue
+		}
+		if khatma.Status != KhatmaCompleted {
+			return fmt.Errorf("%w: khatma %d has not been completed", ErrInvalidCertificate, khatmaID)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: khatma %d does not belong to the student", ErrInvalidCertificate, khatmaID)
+}
+
+// VerifyCertificate looks up a certificate by the code printed on it. Codes are
+// accepted in any case, with or without their dashes.
+func (s *certificateService) VerifyCertificate(ctx context.Context, code string) (*models.CertificateVerification, error) {
+	certificate, err := s.findByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CertificateVerification{
+		Code:        certificate.Code,
+		StudentName: certificate.StudentName,
+		Achievement: achievementLabel(certificate),
+		TeacherName: certificate.TeacherName,
+		IssuedAt:    certificate.IssuedAt,
+	}, nil
+}
+
+// RenderCertificate writes a certificate as a PDF. The student, admins, the
+// issuing teacher and the student's teachers may download it.
+func (s *certificateService) RenderCertificate(ctx context.Context, viewerID int, viewerRole string, code string, w io.Writer) (*models.Certificate, error) {
+	certificate, err := s.findByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	allowed := viewerRole == "admin" || viewerRole == "developer" ||
+		(certificate.TeacherID != nil && *certificate.TeacherID == viewerID)
+	if !allowed && certificate.StudentID != nil {
+		allowed, err = canViewStudent(ctx, s.classes, viewerID, viewerRole, *certificate.StudentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	// A template removed from the configuration falls back to the default.
+	tmpl, ok := s.templates.Lookup(certificate.Template)
+	if !ok {
+		tmpl, _ = s.templates.Lookup(pdf.DefaultCertificateTemplate)
+	}
+	verifyURL := ""
+	if s.verifyURL != "" {
+		verifyURL = s.verifyURL + certificate.Code
+	}
+	err = tmpl.Render(w, pdf.CertificateData{
+		Student:     certificate.StudentName,
+		Achievement: achievementLabel(certificate),
+		Date:        certificate.IssuedAt.Format(dateLayout),
+		Teacher:     certificate.TeacherName,
+		Code:        certificate.Code,
+		VerifyURL:   verifyURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// GetStudentCertificates lists a student's certificates, newest first.
+func (s *certificateService) GetStudentCertificates(ctx context.Context, viewerID int, viewerRole string, studentID int) ([]models.Certificate, error) {
+	allowed, err := canViewStudent(ctx, s.classes, viewerID, viewerRole, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return s.repo.FindCertificatesForStudent(ctx, studentID)
+}
+
+func (s *certificateService) findByCode(ctx context.Context, code string) (*models.Certificate, error) {
+	normalized, ok := normalizeCertificateCode(code)
+	if !ok {
+		return nil, repository.ErrCertificateNotFound
+	}
+	return s.repo.FindCertificateByCode(ctx, normalized)
+}
+
+// achievementLabel describes what a certificate was awarded for, as printed on it.
+func achievementLabel(certificate *models.Certificate) string {
+	if certificate.Achievement == AchievementJuz && certificate.Juz != nil {
+		return "الجزء " + strconv.Itoa(*certificate.Juz) + " من القرآن الكريم"
+	}
+	return "ختمة القرآن الكريم"
+}
+
+// newCertificateCode returns a random verification code such as "K7QM-2XBD-9HTR".
+func newCertificateCode() (string, error) {
+	random := make([]byte, codeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	symbols := make([]byte, codeLength)
+	for i, b := range random {
+		symbols[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return groupCode(string(symbols)), nil
+}
+
+// normalizeCertificateCode turns a code as typed in by someone into its stored
+// form, reporting false if it cannot be a valid code.
+func normalizeCertificateCode(code string) (string, bool) {
+	var symbols strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch {
+		case r == '-' || r == ' ':
+			continue
+		case r > 127 || !strings.ContainsRune(codeAlphabet, r):
+			return "", false
+		}
+		symbols.WriteRune(r)
+	}
+	if symbols.Len() != codeLength {
+		return "", false
+	}
+	return groupCode(symbols.String()), true
+}
+
+func groupCode(symbols string) string {
+	var groups []string
+	for i := 0; i < len(symbols); i += 4 {
+		groups = append(groups, symbols[i:min(i+4, len(symbols))])
+	}
+	return strings.Join(groups, "-")
+}
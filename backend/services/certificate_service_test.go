@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+func TestNewCertificateCode(t *testing.T) {
+	code, err := newCertificateCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != codeLength+2 || code[4] != '-' || code[9] != '-' {
+		t.Fatalf("code %q is not grouped as XXXX-XXXX-XXXX", code)
+	}
+	for _, r := range strings.ReplaceAll(code, "-", "") {
+		if !strings.ContainsRune(codeAlphabet, r) {
+			t.Errorf("code %q contains %q", code, r)
+		}
+	}
+	if normalized, ok := normalizeCertificateCode(code); !ok || normalized != code {
+		t.Errorf("normalizeCertificateCode(%q) = %q, %v", code, normalized, ok)
+	}
+}
+
+func TestNormalizeCertificateCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"K7QM-2XBD-9HTR", "K7QM-2XBD-9HTR", true},
+		{"k7qm2xbd9htr", "K7QM-2XBD-9HTR", true},
+		{" K7QM 2XBD 9HTR ", "K7QM-2XBD-9HTR", true},
+		{"K7QM-2XBD-9HT", "", false},
+		{"K7QM-2XBD-9HTRA", "", false},
+		{"K7QM-2XBD-9HT0", "", false},
+		{"K7QM-2XBD-9HTÉ", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeCertificateCode(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizeCertificateCode(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCheckJuzCompleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    *int
+		khatmas []models.Khatma
+		juz     int
+		want    error
+	}{
+		{name: "read to the last page", page: intPtr(21), juz: 1},
+		{name: "read past it", page: intPtr(300), juz: 2},
+		{name: "one page short", page: intPtr(20), juz: 1, want: ErrInvalidCertificate},
+		{name: "no position", juz: 1, want: ErrInvalidCertificate},
+		{name: "a later juz", page: intPtr(300), juz: 30, want: ErrInvalidCertificate},
+		{name: "wrapped around after a khatma", page: intPtr(5), juz: 30, khatmas: []models.Khatma{{ID: 1, StudentID: 7, Status: KhatmaCompleted}}},
+		{name: "only an abandoned khatma", page: intPtr(5), juz: 30, khatmas: []models.Khatma{{ID: 1, StudentID: 7, Status: KhatmaAbandoned}}, want: ErrInvalidCertificate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &certificateService{khatmas: &fakeKhatmaRepo{khatmas: tt.khatmas}}
+			student := &models.User{ID: 7, Role: "student", ProgressPage: tt.page}
+			if err := service.checkJuzCompleted(context.Background(), student, tt.juz); !errors.Is(err, tt.want) {
+				t.Errorf("checkJuzCompleted = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// ClassService defines the interface for managing class membership. Admins
+// may manage any class and teachers their own.
+type ClassService interface {
+	AddMember(ctx context.Context, viewerID int, viewerRole string, classID, studentID int) error
+	RemoveMember(ctx context.Context, viewerID int, viewerRole string, classID, studentID int) error
+}
+
+type classService struct {
+	classes repository.ClassRepository
+	audit   AuditService
+	live    LivePublisher
+}
+
+// NewClassService creates a new class service.
+func NewClassService(classes repository.ClassRepository, audit AuditService, live LivePublisher) ClassService {
+	return &classService{classes: classes, audit: audit, live: live}
+}
+
+// AddMember enrolls a student in a class. Adding a current member does nothing.
+func (s *classService) AddMember(ctx context.Context, viewerID int, viewerRole string, classID, studentID int) error {
+	if err := s.authorize(ctx, viewerID, viewerRole, classID); err != nil {
+		return err
+	}
+	added, err := s.classes.AddClassMember(ctx, classID, studentID)
+	if err != nil || !added {
+		return err
+	}
+	s.audit.Record(ctx, AuditClassMemberAdd, "class", &classID, nil, map[string]int{"student_id": studentID})
+	s.live.PublishClassEvent(ctx, classID, LiveMembershipChanged, membershipChange("added", studentID))
+	return nil
+}
+
+// RemoveMember takes a student out of a class.
+func (s *classService) RemoveMember(ctx context.Context, viewerID int, viewerRole string, classID, studentID int) error {
+	if err := s.authorize(ctx, viewerID, viewerRole, classID); err != nil {
+		return err
+	}
+	if err := s.classes.RemoveClassMember(ctx, classID, studentID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditClassMemberRemove, "class", &classID, map[string]int{"student_id": studentID}, nil)
+	s.live.PublishClassEvent(ctx, classID, LiveMembershipChanged, membershipChange("removed", studentID))
+	return nil
+}
+
+func (s *classService) authorize(ctx context.Context, viewerID int, viewerRole string, classID int) error {
+	if _, err := s.classes.FindClassName(ctx, classID); err != nil {
+		return err
+	}
+	switch viewerRole {
+	case "admin", "developer":
+		return nil
+	case "teacher":
+		taught, err := s.classes.IsClassTaughtBy(ctx, classID, viewerID)
+		if err != nil {
+			return err
+		}
+		if !taught {
+			return ErrForbidden
+		}
+		return nil
+	default:
+		return ErrForbidden
+	}
+}
+
+// membershipChange is the data of a membership.changed live event.
+func membershipChange(action string, studentIDs ...int) map[string]interface{} {
+	return map[string]interface{}{"action": action, "student_ids": studentIDs}
+}
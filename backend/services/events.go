@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// Domain events published to the outbox and delivered to webhook subscribers.
+const (
+	EventStudentProgressed  = "student.progressed"
+	EventStudentJuzComplete = "student.juz_completed"
+	EventUserCreated        = "user.created"
+	EventAttendanceMarked   = "attendance.marked"
+)
+
+// EventTypes lists every event a webhook can subscribe to.
+var EventTypes = []string{EventStudentProgressed, EventStudentJuzComplete, EventUserCreated, EventAttendanceMarked}
+
+// EventPublisher records domain events in the outbox. Publishing within
+// Transactor.WithinTx ties the event to the change it describes: it is only
+// delivered if that change is committed.
+type EventPublisher interface {
+	Publish(ctx context.Context, eventType string, payload interface{}) error
+}
+
+type outboxPublisher struct {
+	repo repository.WebhookRepository
+}
+
+// NewEventPublisher creates an EventPublisher writing to the outbox.
+func NewEventPublisher(repo repository.WebhookRepository) EventPublisher {
+	return &outboxPublisher{repo: repo}
+}
+
+// Publish implements EventPublisher.
+func (p *outboxPublisher) Publish(ctx context.Context, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return p.repo.CreateEvent(ctx, eventType, string(data))
+}
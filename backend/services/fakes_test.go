@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// fakeTx runs the work directly; the fakes have nothing to roll back.
+type fakeTx struct{}
+
+func (fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeAudit remembers the actions it was asked to record.
+type fakeAudit struct {
+	AuditService
+	actions []string
+}
+
+func (a *fakeAudit) Record(_ context.Context, action, _ string, _ *int, _, _ interface{}) {
+	a.actions = append(a.actions, action)
+}
+
+// asActor returns a context carrying an actor with the given role.
+func asActor(role string) context.Context {
+	return WithActor(context.Background(), models.Actor{ID: 1, Role: role})
+}
+
+// fakeClassRepo knows the teacher and the students of each class, by class ID.
+type fakeClassRepo struct {
+	repository.ClassRepository
+	teachers map[int]int
+	students map[int][]int
+}
+
+func (r *fakeClassRepo) FindClassName(_ context.Context, classID int) (string, error) {
+	if _, ok := r.teachers[classID]; !ok {
+		return "", repository.ErrClassNotFound
+	}
+	return fmt.Sprintf("Class %d", classID), nil
+}
+
+func (r *fakeClassRepo) IsClassTaughtBy(_ context.Context, classID, teacherID int) (bool, error) {
+	return r.teachers[classID] == teacherID, nil
+}
+
+func (r *fakeClassRepo) IsStudentTaughtBy(_ context.Context, studentID, teacherID int) (bool, error) {
+	for classID, students := range r.students {
+		if r.teachers[classID] == teacherID && slices.Contains(students, studentID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeEvents remembers the outbox events it was asked to publish.
+type fakeEvents struct {
+	types []string
+}
+
+func (e *fakeEvents) Publish(_ context.Context, eventType string, _ interface{}) error {
+	e.types = append(e.types, eventType)
+	return nil
+}
+
+// fakeNotifier remembers who was notified of what, failing with err if set.
+type fakeNotifier struct {
+	sent []string
+	err  error
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, userID int, template string, _ map[string]interface{}) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, fmt.Sprintf("%s:%d", template, userID))
+	return nil
+}
+
+// fakeLive remembers the live events it was asked to publish.
+type fakeLive struct {
+	events []string
+}
+
+func (l *fakeLive) PublishClassEvent(_ context.Context, classID int, eventType string, _ interface{}) {
+	l.events = append(l.events, fmt.Sprintf("%s:class:%d", eventType, classID))
+}
+
+func (l *fakeLive) PublishStudentEvent(_ context.Context, studentID int, eventType string, _ interface{}) {
+	l.events = append(l.events, fmt.Sprintf("%s:student:%d", eventType, studentID))
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// Goal statuses reported by GoalService.
+const (
+	GoalCompleted = "completed"
+	GoalOnTrack   = "on_track"
+	GoalBehind    = "behind"
+	GoalOverdue   = "overdue"
+)
+
+var (
+	// ErrInvalidGoal is wrapped by the validation errors of CreateGoal.
+	ErrInvalidGoal = errors.New("invalid goal")
+	// ErrForbidden is returned when the caller may not see or change a resource.
+	ErrForbidden = errors.New("forbidden")
+)
+
+var goalTypes = []string{"memorization", "reading", "revision"}
+
+// GoalService defines the interface for goal-related business logic. Every
+// method takes the caller's ID and role and enforces who may see what.
+type GoalService interface {
+	CreateGoal(ctx context.Context, viewerID int, viewerRole string, goal *models.Goal) error
+	GetGoal(ctx context.Context, viewerID int, viewerRole string, id int) (*models.GoalReport, error)
+	DeleteGoal(ctx context.Context, viewerID int, viewerRole string, id int) error
+	GetStudentGoals(ctx context.Context, viewerID int, viewerRole string, studentID int) ([]models.GoalReport, error)
+	GetClassGoals(ctx context.Context, viewerID int, viewerRole string, classID int) ([]models.GoalReport, error)
+}
+
+type goalService struct {
+	repo    repository.GoalRepository
+	classes repository.ClassRepository
+	audit   AuditService
+}
+
+// NewGoalService creates a new goal service.
+func NewGoalService(repo repository.GoalRepository, classes repository.ClassRepository, audit AuditService) GoalService {
+	return &goalService{repo: repo, classes: classes, audit: audit}
+}
+
+// CreateGoal validates and stores a goal. Admins may set goals for anyone,
+// teachers for their classes and the students in them, and students for themselves.
+func (s *goalService) CreateGoal(ctx context.Context, viewerID int, viewerRole string, goal *models.Goal) error {
+	if err := validateGoal(goal); err != nil {
+		return err
+	}
+	allowed, err := s.canManage(ctx, viewerID, viewerRole, goal)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+
+	goal.CreatedBy = &viewerID
+	if err := s.repo.CreateGoal(ctx, goal); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditGoalCreate, "goal", &goal.ID, nil, goal)
+	return nil
+}
+
+// GetGoal returns a goal with the progress of every student it applies to.
+// Students only see their own line of a class goal.
+func (s *goalService) GetGoal(ctx context.Context, viewerID int, viewerRole string, id int) (*models.GoalReport, error) {
+	goal, err := s.repo.FindGoalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var onlyStudent *int
+	switch viewerRole {
+	case "admin", "developer", "teacher":
+		allowed, err := s.canManage(ctx, viewerID, viewerRole, goal)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrForbidden
+		}
+	default:
+		allowed := goal.StudentID != nil && *goal.StudentID == viewerID
+		if goal.ClassID != nil {
+			if allowed, err = s.classes.IsClassMember(ctx, *goal.ClassID, viewerID); err != nil {
+				return nil, err
+			}
+		}
+		if !allowed {
+			return nil, ErrForbidden
+		}
+		onlyStudent = &viewerID
+	}
+
+	return s.report(ctx, goal, onlyStudent)
+}
+
+// DeleteGoal removes a goal the caller is allowed to manage.
+func (s *goalService) DeleteGoal(ctx context.Context, viewerID int, viewerRole string, id int) error {
+	goal, err := s.repo.FindGoalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	allowed, err := s.canManage(ctx, viewerID, viewerRole, goal)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+
+	if err := s.repo.DeleteGoal(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditGoalDelete, "goal", &id, goal, nil)
+	return nil
+}
+
+// GetStudentGoals returns a student's own and class goals with their progress.
+func (s *goalService) GetStudentGoals(ctx context.Context, viewerID int, viewerRole string, studentID int) ([]models.GoalReport, error) {
+	allowed, err := canViewStudent(ctx, s.classes, viewerID, viewerRole, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	goals, err := s.repo.FindGoalsForStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reports(ctx, goals, &studentID)
+}
+
+// GetClassGoals returns a class's goals with the progress of each member.
+func (s *goalService) GetClassGoals(ctx context.Context, viewerID int, viewerRole string, classID int) ([]models.GoalReport, error) {
+	var onlyStudent *int
+	switch viewerRole {
+	case "admin", "developer":
+	case "teacher":
+		taught, err := s.classes.IsClassTaughtBy(ctx, classID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !taught {
+			return nil, ErrForbidden
+		}
+	default:
+		member, err := s.classes.IsClassMember(ctx, classID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, ErrForbidden
+		}
+		onlyStudent = &viewerID
+	}
+
+	goals, err := s.repo.FindGoalsForClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reports(ctx, goals, onlyStudent)
+}
+
+func (s *goalService) canManage(ctx context.Context, viewerID int, viewerRole string, goal *models.Goal) (bool, error) {
+	switch viewerRole {
+	case "admin", "developer":
+		return true, nil
+	case "teacher":
+		if goal.ClassID != nil {
+			return s.classes.IsClassTaughtBy(ctx, *goal.ClassID, viewerID)
+		}
+		return s.classes.IsStudentTaughtBy(ctx, *goal.StudentID, viewerID)
+	default:
+		return goal.StudentID != nil && *goal.StudentID == viewerID, nil
+	}
+}
+
+func (s *goalService) reports(ctx context.Context, goals []models.Goal, onlyStudent *int) ([]models.GoalReport, error) {
+	reports := []models.GoalReport{}
+	for i := range goals {
+		report, err := s.report(ctx, &goals[i], onlyStudent)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+func (s *goalService) report(ctx context.Context, goal *models.Goal, onlyStudent *int) (*models.GoalReport, error) {
+	students, err := s.repo.FindGoalPages(ctx, goal, onlyStudent)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now()
+	report := &models.GoalReport{Goal: *goal, Students: []models.GoalProgress{}, StatusCounts: map[string]int{}}
+	for _, student := range students {
+		progress := goalProgress(goal, student, today)
+		report.Students = append(report.Students, progress)
+		report.StatusCounts[progress.Status]++
+	}
+	return report, nil
+}
+
+func validateGoal(goal *models.Goal) error {
+	goal.Title = strings.TrimSpace(goal.Title)
+	if goal.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidGoal)
+	}
+	if !slices.Contains(goalTypes, goal.Type) {
+		return fmt.Errorf("%w: type must be one of %s", ErrInvalidGoal, strings.Join(goalTypes, ", "))
+	}
+	if (goal.StudentID == nil) == (goal.ClassID == nil) {
+		return fmt.Errorf("%w: exactly one of student_id and class_id is required", ErrInvalidGoal)
+	}
+
+	isRange := goal.StartPage != nil || goal.EndPage != nil
+	isQuantity := goal.TargetPages != nil || goal.Period != nil
+	switch {
+	case isRange && isQuantity:
+		return fmt.Errorf("%w: a goal has either a page range or target_pages per period, not both", ErrInvalidGoal)
+	case isRange:
+		if goal.StartPage == nil || goal.EndPage == nil {
+			return fmt.Errorf("%w: start_page and end_page are both required", ErrInvalidGoal)
+		}
+		if *goal.StartPage < 1 || *goal.EndPage > quran.Pages || *goal.StartPage > *goal.EndPage {
+			return fmt.Errorf("%w: pages must satisfy 1 <= start_page <= end_page <= %d", ErrInvalidGoal, quran.Pages)
+		}
+	case isQuantity:
+		if goal.TargetPages == nil || *goal.TargetPages <= 0 {
+			return fmt.Errorf("%w: target_pages must be positive", ErrInvalidGoal)
+		}
+		if goal.Period == nil || (*goal.Period != "day" && *goal.Period != "week") {
+			return fmt.Errorf("%w: period must be \"day\" or \"week\"", ErrInvalidGoal)
+		}
+	default:
+		return fmt.Errorf("%w: a page range or target_pages per period is required", ErrInvalidGoal)
+	}
+
+	deadline, err := time.Parse(dateLayout, goal.Deadline)
+	if err != nil {
+		return fmt.Errorf("%w: deadline must be a YYYY-MM-DD date", ErrInvalidGoal)
+	}
+	if goal.StartDate != "" {
+		start, err := time.Parse(dateLayout, goal.StartDate)
+		if err != nil {
+			return fmt.Errorf("%w: start_date must be a YYYY-MM-DD date", ErrInvalidGoal)
+		}
+		if deadline.Before(start) {
+			return fmt.Errorf("%w: deadline must not be before start_date", ErrInvalidGoal)
+		}
+	}
+	return nil
+}
+
+// goalProgress computes a student's progress towards a goal as of today. Range
+// goals count the pages of the range reached so far; quantity goals count the
+// pages advanced since the goal started. The expected amount grows evenly up to
+// the deadline and only counts days that have fully passed.
+func goalProgress(goal *models.Goal, student models.StudentPages, today time.Time) models.GoalProgress {
+	progress := models.GoalProgress{StudentID: student.StudentID, Username: student.Username}
+
+	start, _ := time.Parse(dateLayout, goal.StartDate)
+	deadline, _ := time.Parse(dateLayout, goal.Deadline)
+	day, _ := time.Parse(dateLayout, today.Format(dateLayout))
+	totalDays := daysBetween(start, deadline) + 1
+	elapsedDays := min(max(daysBetween(start, day), 0), totalDays)
+
+	if goal.StartPage != nil && goal.EndPage != nil {
+		progress.Target = *goal.EndPage - *goal.StartPage + 1
+		reached := 0
+		if student.Baseline != nil {
+			reached = *student.Baseline
+		}
+		for _, page := range student.Pages {
+			reached = max(reached, page)
+		}
+		progress.Achieved = min(max(reached-*goal.StartPage+1, 0), progress.Target)
+		progress.Expected = int(math.Ceil(float64(progress.Target) * float64(elapsedDays) / float64(totalDays)))
+	} else if goal.TargetPages != nil && goal.Period != nil {
+		periodDays := 1
+		if *goal.Period == "week" {
+			periodDays = 7
+		}
+		progress.Target = *goal.TargetPages * ceilDiv(totalDays, periodDays)
+		progress.Expected = *goal.TargetPages * (elapsedDays / periodDays)
+		progress.Achieved = pagesAdvanced(student)
+	}
+
+	if progress.Target > 0 {
+		progress.Percent = math.Round(float64(progress.Achieved)/float64(progress.Target)*1000) / 10
+	}
+	switch {
+	case progress.Achieved >= progress.Target:
+		progress.Status = GoalCompleted
+	case day.After(deadline):
+		progress.Status = GoalOverdue
+	case progress.Achieved >= progress.Expected:
+		progress.Status = GoalOnTrack
+	default:
+		progress.Status = GoalBehind
+	}
+	return progress
+}
+
+// pagesAdvanced sums the forward moves in a student's page sequence. Without a
+// baseline the first page recorded since the goal started serves as one.
+func pagesAdvanced(student models.StudentPages) int {
+	pages := student.Pages
+	if student.Baseline != nil {
+		pages = append([]int{*student.Baseline}, pages...)
+	}
+	advanced := 0
+	for i := 1; i < len(pages); i++ {
+		if pages[i] > pages[i-1] {
+			advanced += pages[i] - pages[i-1]
+		}
+	}
+	return advanced
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+func TestGoalProgressRange(t *testing.T) {
+	// Juz' Amma (pages 582-604, 23 pages) over 23 days.
+	goal := &models.Goal{StudentID: intPtr(1), StartPage: intPtr(582), EndPage: intPtr(604), StartDate: "2025-03-01", Deadline: "2025-03-23"}
+	today := time.Date(2025, 3, 11, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		student  models.StudentPages
+		achieved int
+		status   string
+	}{
+		{name: "no progress", student: models.StudentPages{}, achieved: 0, status: GoalBehind},
+		{name: "on track", student: models.StudentPages{Baseline: intPtr(570), Pages: []int{585, 591}}, achieved: 10, status: GoalOnTrack},
+		{name: "behind", student: models.StudentPages{Pages: []int{584}}, achieved: 3, status: GoalBehind},
+		{name: "done", student: models.StudentPages{Pages: []int{604}}, achieved: 23, status: GoalCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := goalProgress(goal, tt.student, today)
+			if got.Target != 23 || got.Expected != 10 || got.Achieved != tt.achieved || got.Status != tt.status {
+				t.Fatalf("goalProgress = %+v, want target 23, expected 10, achieved %d, status %s", got, tt.achieved, tt.status)
+			}
+		})
+	}
+
+	late := goalProgress(goal, models.StudentPages{Pages: []int{600}}, today.AddDate(0, 1, 0))
+	if late.Status != GoalOverdue {
+		t.Fatalf("after the deadline status = %s, want %s", late.Status, GoalOverdue)
+	}
+}
+
+func TestGoalProgressQuantity(t *testing.T) {
+	// Two pages a day for a week.
+	goal := &models.Goal{ClassID: intPtr(1), TargetPages: intPtr(2), Period: strPtr("day"), StartDate: "2025-03-01", Deadline: "2025-03-07"}
+	today := time.Date(2025, 3, 4, 20, 0, 0, 0, time.UTC)
+
+	got := goalProgress(goal, models.StudentPages{Baseline: intPtr(40), Pages: []int{42, 41, 47}}, today)
+	if got.Target != 14 || got.Expected != 6 || got.Achieved != 8 || got.Status != GoalOnTrack {
+		t.Fatalf("goalProgress = %+v, want target 14, expected 6, achieved 8, on track", got)
+	}
+}
+
+func TestValidateGoal(t *testing.T) {
+	valid := func() *models.Goal {
+		return &models.Goal{Type: "memorization", Title: "Juz' Amma", StudentID: intPtr(1), StartPage: intPtr(582), EndPage: intPtr(604), Deadline: "2025-06-30"}
+	}
+	tests := []struct {
+		name   string
+		mutate func(g *models.Goal)
+		valid  bool
+	}{
+		{name: "range", mutate: func(g *models.Goal) {}, valid: true},
+		{name: "quantity", mutate: func(g *models.Goal) {
+			g.StartPage, g.EndPage, g.TargetPages, g.Period = nil, nil, intPtr(2), strPtr("day")
+		}, valid: true},
+		{name: "unknown type", mutate: func(g *models.Goal) { g.Type = "tajweed" }},
+		{name: "two owners", mutate: func(g *models.Goal) { g.ClassID = intPtr(3) }},
+		{name: "range and quantity", mutate: func(g *models.Goal) { g.TargetPages = intPtr(2) }},
+		{name: "reversed range", mutate: func(g *models.Goal) { g.StartPage, g.EndPage = intPtr(604), intPtr(582) }},
+		{name: "bad period", mutate: func(g *models.Goal) {
+			g.StartPage, g.EndPage, g.TargetPages, g.Period = nil, nil, intPtr(2), strPtr("month")
+		}},
+		{name: "deadline before start", mutate: func(g *models.Goal) { g.StartDate = "2025-07-01" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := valid()
+			tt.mutate(goal)
+			err := validateGoal(goal)
+			if tt.valid && err != nil {
+				t.Fatalf("validateGoal = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidGoal) {
+				t.Fatalf("validateGoal = %v, want ErrInvalidGoal", err)
+			}
+		})
+	}
+}
+
+func strPtr(v string) *string { return &v }
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"math"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// Khatma statuses.
+const (
+	KhatmaOpen      = "open"
+	KhatmaCompleted = "completed"
+	KhatmaAbandoned = "abandoned"
+)
+
+// KhatmaService defines the interface for khatma tracking.
+type KhatmaService interface {
+	TrackPage(ctx context.Context, studentID, page int) error
+	StartKhatmaAt(ctx context.Context, studentID, page int) error
+	GetStudentKhatmas(ctx context.Context, viewerID int, viewerRole string, studentID int) ([]models.Khatma, error)
+	GetClassKhatmas(ctx context.Context, viewerID int, viewerRole string, classID int) ([]models.Khatma, error)
+}
+
+type khatmaService struct {
+	repo    repository.KhatmaRepository
+	classes repository.ClassRepository
+	audit   AuditService
+}
+
+// NewKhatmaService creates a new khatma service.
+func NewKhatmaService(repo repository.KhatmaRepository, classes repository.ClassRepository, audit AuditService) KhatmaService {
+	return &khatmaService{repo: repo, classes: classes, audit: audit}
+}
+
+// TrackPage advances the student's khatma to the page they just recorded.
+// Recording page 1 opens a khatma, abandoning an unfinished one that had moved
+// past it; reaching the last page completes it.
+func (s *khatmaService) TrackPage(ctx context.Context, studentID, page int) error {
+	open, err := s.repo.FindOpenKhatma(ctx, studentID)
+	if err != nil {
+		return err
+	}
+
+	if page == 1 {
+		if open != nil && open.LastPage == 1 {
+			return nil
+		}
+		if open != nil {
+			abandoned, err := s.repo.CloseKhatma(ctx, open.ID, KhatmaAbandoned, open.LastPage)
+			if err != nil {
+				return err
+			}
+			if abandoned != nil {
+				s.audit.Record(ctx, AuditKhatmaAbandon, "khatma", &abandoned.ID, open, abandoned)
+			}
+		}
+		started, err := s.repo.OpenKhatma(ctx, studentID)
+		if err != nil {
+			return err
+		}
+		s.audit.Record(ctx, AuditKhatmaStart, "khatma", &started.ID, nil, started)
+		return nil
+	}
+
+	if open == nil {
+		return nil
+	}
+	if page >= quran.Pages {
+		completed, err := s.repo.CloseKhatma(ctx, open.ID, KhatmaCompleted, page)
+		if err != nil {
+			return err
+		}
+		if completed != nil {
+			withDuration(completed)
+			s.audit.Record(ctx, AuditKhatmaComplete, "khatma", &completed.ID, open, completed)
+		}
+		return nil
+	}
+	return s.repo.UpdateLastPage(ctx, open.ID, page)
+}
+
+// StartKhatmaAt opens a khatma for a student whose position was set rather
+// than read up to, such as one imported from a roster, and moves it to that
+// page so later progress advances it. Nothing is left to read from the last
+// page, so no khatma is opened there.
+func (s *khatmaService) StartKhatmaAt(ctx context.Context, studentID, page int) error {
+	if page >= quran.Pages {
+		return nil
+	}
+	started, err := s.repo.OpenKhatma(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if page > started.LastPage {
+		if err := s.repo.UpdateLastPage(ctx, started.ID, page); err != nil {
+			return err
+		}
+		started.LastPage = page
+	}
+	s.audit.Record(ctx, AuditKhatmaStart, "khatma", &started.ID, nil, started)
+	return nil
+}
+
+// GetStudentKhatmas lists a student's khatmas, newest first.
+func (s *khatmaService) GetStudentKhatmas(ctx context.Context, viewerID int, viewerRole string, studentID int) ([]models.Khatma, error) {
+	allowed, err := canViewStudent(ctx, s.classes, viewerID, viewerRole, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	khatmas, err := s.repo.FindKhatmasForStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range khatmas {
+		withDuration(&khatmas[i])
+	}
+	return khatmas, nil
+}
+
+// GetClassKhatmas lists the khatmas completed by a class's members. Only admins
+// and the class's teacher may see them.
+func (s *khatmaService) GetClassKhatmas(ctx context.Context, viewerID int, viewerRole string, classID int) ([]models.Khatma, error) {
+	switch viewerRole {
+	case "admin", "developer":
+	case "teacher":
+		taught, err := s.classes.IsClassTaughtBy(ctx, classID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !taught {
+			return nil, ErrForbidden
+		}
+	default:
+		return nil, ErrForbidden
+	}
+
+	khatmas, err := s.repo.FindCompletedKhatmasForClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range khatmas {
+		withDuration(&khatmas[i])
+	}
+	return khatmas, nil
+}
+
+// withDuration fills in how many days a finished khatma took, counting both the
+// first and the last day.
+func withDuration(khatma *models.Khatma) {
+	if khatma.EndedAt == nil {
+		return
+	}
+	days := int(math.Floor(khatma.EndedAt.Sub(khatma.StartedAt).Hours()/24)) + 1
+	khatma.DurationDays = &days
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// fakeKhatmaRepo keeps khatmas in memory, in the order they were opened.
+type fakeKhatmaRepo struct {
+	repository.KhatmaRepository
+	khatmas []models.Khatma
+	now     time.Time
+}
+
+func (r *fakeKhatmaRepo) FindOpenKhatma(_ context.Context, studentID int) (*models.Khatma, error) {
+	for _, k := range r.khatmas {
+		if k.StudentID == studentID && k.Status == KhatmaOpen {
+			return &k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeKhatmaRepo) OpenKhatma(_ context.Context, studentID int) (*models.Khatma, error) {
+	k := models.Khatma{ID: len(r.khatmas) + 1, StudentID: studentID, Status: KhatmaOpen, LastPage: 1, StartedAt: r.now}
+	r.khatmas = append(r.khatmas, k)
+	return &k, nil
+}
+
+func (r *fakeKhatmaRepo) UpdateLastPage(_ context.Context, id, page int) error {
+	if k := r.find(id); k != nil && k.Status == KhatmaOpen {
+		k.LastPage = page
+	}
+	return nil
+}
+
+func (r *fakeKhatmaRepo) CloseKhatma(_ context.Context, id int, status string, page int) (*models.Khatma, error) {
+	k := r.find(id)
+	if k == nil || k.Status != KhatmaOpen {
+		return nil, nil
+	}
+	ended := r.now
+	k.Status, k.LastPage, k.EndedAt = status, page, &ended
+	closed := *k
+	return &closed, nil
+}
+
+func (r *fakeKhatmaRepo) FindKhatmasForStudent(_ context.Context, studentID int) ([]models.Khatma, error) {
+	var khatmas []models.Khatma
+	for _, k := range r.khatmas {
+		if k.StudentID == studentID {
+			khatmas = append(khatmas, k)
+		}
+	}
+	return khatmas, nil
+}
+
+func (r *fakeKhatmaRepo) find(id int) *models.Khatma {
+	for i := range r.khatmas {
+		if r.khatmas[i].ID == id {
+			return &r.khatmas[i]
+		}
+	}
+	return nil
+}
+
+// khatmaState is a khatma's status and cursor, for comparing outcomes.
+type khatmaState struct {
+	Status   string
+	LastPage int
+}
+
+func TestTrackPage(t *testing.T) {
+	const student = 30
+
+	tests := []struct {
+		name        string
+		pages       []int
+		wantKhatmas []khatmaState
+		wantAudit   []string
+	}{
+		{
+			name:        "pages before any khatma are ignored",
+			pages:       []int{5, 6, quran.Pages},
+			wantKhatmas: nil,
+		},
+		{
+			name:        "page 1 opens a khatma",
+			pages:       []int{1},
+			wantKhatmas: []khatmaState{{KhatmaOpen, 1}},
+			wantAudit:   []string{AuditKhatmaStart},
+		},
+		{
+			name:        "recording page 1 twice keeps the khatma",
+			pages:       []int{1, 1},
+			wantKhatmas: []khatmaState{{KhatmaOpen, 1}},
+			wantAudit:   []string{AuditKhatmaStart},
+		},
+		{
+			name:        "pages advance the cursor",
+			pages:       []int{1, 20, 300},
+			wantKhatmas: []khatmaState{{KhatmaOpen, 300}},
+			wantAudit:   []string{AuditKhatmaStart},
+		},
+		{
+			name:        "backward moves follow the recorded page",
+			pages:       []int{1, 300, 250},
+			wantKhatmas: []khatmaState{{KhatmaOpen, 250}},
+			wantAudit:   []string{AuditKhatmaStart},
+		},
+		{
+			name:        "the last page completes the khatma",
+			pages:       []int{1, 600, quran.Pages},
+			wantKhatmas: []khatmaState{{KhatmaCompleted, quran.Pages}},
+			wantAudit:   []string{AuditKhatmaStart, AuditKhatmaComplete},
+		},
+		{
+			name:        "pages after completion are ignored until page 1",
+			pages:       []int{1, quran.Pages, 12},
+			wantKhatmas: []khatmaState{{KhatmaCompleted, quran.Pages}},
+			wantAudit:   []string{AuditKhatmaStart, AuditKhatmaComplete},
+		},
+		{
+			name:        "wrapping around to page 1 starts the next khatma",
+			pages:       []int{1, quran.Pages, 1, 2},
+			wantKhatmas: []khatmaState{{KhatmaCompleted, quran.Pages}, {KhatmaOpen, 2}},
+			wantAudit:   []string{AuditKhatmaStart, AuditKhatmaComplete, AuditKhatmaStart},
+		},
+		{
+			name:        "restarting midway abandons the open khatma",
+			pages:       []int{1, 150, 1},
+			wantKhatmas: []khatmaState{{KhatmaAbandoned, 150}, {KhatmaOpen, 1}},
+			wantAudit:   []string{AuditKhatmaStart, AuditKhatmaAbandon, AuditKhatmaStart},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeKhatmaRepo{now: time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)}
+			audit := &fakeAudit{}
+			service := NewKhatmaService(repo, &fakeClassRepo{}, audit)
+
+			for _, page := range tt.pages {
+				repo.now = repo.now.Add(24 * time.Hour)
+				if err := service.TrackPage(context.Background(), student, page); err != nil {
+					t.Fatalf("TrackPage(%d): %v", page, err)
+				}
+			}
+
+			var got []khatmaState
+			for _, k := range repo.khatmas {
+				got = append(got, khatmaState{k.Status, k.LastPage})
+			}
+			if !reflect.DeepEqual(got, tt.wantKhatmas) {
+				t.Errorf("khatmas = %v, want %v", got, tt.wantKhatmas)
+			}
+			if !reflect.DeepEqual(audit.actions, tt.wantAudit) {
+				t.Errorf("audited %v, want %v", audit.actions, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestTrackPageOnlyTouchesTheStudentsKhatma(t *testing.T) {
+	repo := &fakeKhatmaRepo{}
+	service := NewKhatmaService(repo, &fakeClassRepo{}, &fakeAudit{})
+	ctx := context.Background()
+
+	for _, step := range []struct{ student, page int }{{30, 1}, {31, 1}, {30, 80}, {31, quran.Pages}} {
+		if err := service.TrackPage(ctx, step.student, step.page); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []models.Khatma{
+		{ID: 1, StudentID: 30, Status: KhatmaOpen, LastPage: 80},
+		{ID: 2, StudentID: 31, Status: KhatmaCompleted, LastPage: quran.Pages},
+	}
+	if len(repo.khatmas) != len(want) {
+		t.Fatalf("got %d khatmas, want %d", len(repo.khatmas), len(want))
+	}
+	for i, k := range repo.khatmas {
+		if k.ID != want[i].ID || k.StudentID != want[i].StudentID || k.Status != want[i].Status || k.LastPage != want[i].LastPage {
+			t.Errorf("khatma %d = %+v, want %+v", i+1, k, want[i])
+		}
+	}
+}
+
+func TestWithDuration(t *testing.T) {
+	start := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		ended *time.Time
+		want  *int
+	}{
+		{name: "open", ended: nil, want: nil},
+		{name: "same day", ended: timePtr(start.Add(2 * time.Hour)), want: intPtr(1)},
+		{name: "next day", ended: timePtr(start.Add(24 * time.Hour)), want: intPtr(2)},
+		{name: "thirty days", ended: timePtr(start.Add(29*24*time.Hour + time.Hour)), want: intPtr(30)},
+	}
+	for _, tt := range tests {
+		khatma := &models.Khatma{StartedAt: start, EndedAt: tt.ended}
+		withDuration(khatma)
+		if !reflect.DeepEqual(khatma.DurationDays, tt.want) {
+			t.Errorf("%s: duration = %v, want %v", tt.name, khatma.DurationDays, tt.want)
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func TestStartKhatmaAt(t *testing.T) {
+	tests := []struct {
+		page        int
+		wantKhatmas []khatmaState
+	}{
+		{page: 1, wantKhatmas: []khatmaState{{KhatmaOpen, 1}}},
+		{page: 250, wantKhatmas: []khatmaState{{KhatmaOpen, 250}}},
+		{page: quran.Pages, wantKhatmas: nil},
+	}
+	for _, tt := range tests {
+		repo := &fakeKhatmaRepo{}
+		audit := &fakeAudit{}
+		if err := NewKhatmaService(repo, &fakeClassRepo{}, audit).StartKhatmaAt(context.Background(), 30, tt.page); err != nil {
+			t.Fatal(err)
+		}
+		var got []khatmaState
+		for _, k := range repo.khatmas {
+			got = append(got, khatmaState{k.Status, k.LastPage})
+		}
+		if !reflect.DeepEqual(got, tt.wantKhatmas) {
+			t.Errorf("StartKhatmaAt(%d) left %v, want %v", tt.page, got, tt.wantKhatmas)
+		}
+		if wantAudit := len(tt.wantKhatmas); len(audit.actions) != wantAudit {
+			t.Errorf("StartKhatmaAt(%d) audited %v", tt.page, audit.actions)
+		}
+	}
+}
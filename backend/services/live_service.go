@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/realtime"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// Live events, pushed to the subscribers of a class.
+const (
+	LiveProgressChanged   = "progress.changed"
+	LiveAttendanceMarked  = "attendance.marked"
+	LiveMembershipChanged = "membership.changed"
+)
+
+// maxLiveClasses is how many classes one stream may follow.
+const maxLiveClasses = 50
+
+// ErrInvalidSubscription is returned for a live subscription that cannot be made.
+var ErrInvalidSubscription = errors.New("invalid subscription")
+
+// LivePublisher pushes events to the live subscribers of a class. Events are
+// published once the change they describe is committed; a failure to publish
+// is logged rather than returned, since the change itself went through.
+type LivePublisher interface {
+	PublishClassEvent(ctx context.Context, classID int, eventType string, data interface{})
+	// PublishStudentEvent publishes the event to every class the student is in.
+	PublishStudentEvent(ctx context.Context, studentID int, eventType string, data interface{})
+}
+
+// LiveService defines the interface for live class updates.
+type LiveService interface {
+	LivePublisher
+	Subscribe(ctx context.Context, viewerID int, viewerRole string, classIDs []int) (*realtime.Subscription, error)
+}
+
+type liveService struct {
+	broker  realtime.Broker
+	classes repository.ClassRepository
+}
+
+// NewLiveService creates a new live service.
+func NewLiveService(broker realtime.Broker, classes repository.ClassRepository) LiveService {
+	return &liveService{broker: broker, classes: classes}
+}
+
+// Subscribe follows the given classes. Admins must name the classes; teachers
+// and students may name classes they teach or attend, and follow all of those
+// when they name none.
+func (s *liveService) Subscribe(ctx context.Context, viewerID int, viewerRole string, classIDs []int) (*realtime.Subscription, error) {
+	if len(classIDs) > maxLiveClasses {
+		return nil, fmt.Errorf("%w: at most %d classes can be followed", ErrInvalidSubscription, maxLiveClasses)
+	}
+
+	var allowed []int
+	var err error
+	switch viewerRole {
+	case "admin", "developer":
+		if len(classIDs) == 0 {
+			return nil, fmt.Errorf("%w: class_id is required", ErrInvalidSubscription)
+		}
+		for _, id := range classIDs {
+			if _, err := s.classes.FindClassName(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+		return s.broker.Subscribe(classIDs), nil
+	case "teacher":
+		allowed, err = s.classes.FindTeacherClassIDs(ctx, viewerID)
+	case "student":
+		allowed, err = s.classes.FindStudentClassIDs(ctx, viewerID)
+	default:
+		return nil, ErrForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(classIDs) == 0 {
+		return s.broker.Subscribe(allowed), nil
+	}
+	for _, id := range classIDs {
+		if !slices.Contains(allowed, id) {
+			return nil, ErrForbidden
+		}
+	}
+	return s.broker.Subscribe(classIDs), nil
+}
+
+// PublishClassEvent implements LivePublisher.
+func (s *liveService) PublishClassEvent(ctx context.Context, classID int, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err == nil {
+		err = s.broker.Publish(ctx, realtime.Event{Type: eventType, ClassID: classID, Data: payload, Time: time.Now().UTC()})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("publishing live event failed",
+			slog.String("type", eventType), slog.Int("class_id", classID), slog.Any("error", err))
+	}
+}
+
+// PublishStudentEvent implements LivePublisher.
+func (s *liveService) PublishStudentEvent(ctx context.Context, studentID int, eventType string, data interface{}) {
+	classIDs, err := s.classes.FindStudentClassIDs(ctx, studentID)
+	if err != nil {
+		logging.FromContext(ctx).Error("publishing live event failed",
+			slog.String("type", eventType), slog.Int("student_id", studentID), slog.Any("error", err))
+		return
+	}
+	for _, id := range classIDs {
+		s.PublishClassEvent(ctx, id, eventType, data)
+	}
+}
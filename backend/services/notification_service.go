@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"slices"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/notify"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+const (
+	// notificationBatchSize is how many notifications are sent per dispatch.
+	notificationBatchSize = 100
+	// notificationLease hides a claimed notification from other dispatchers
+	// while it is being sent.
+	notificationLease = 2 * time.Minute
+	// maxNotificationError is the longest error message kept on a notification.
+	maxNotificationError = 1000
+)
+
+// ErrInvalidPreferences is wrapped by the validation errors of UpdatePreferences.
+var ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+// Notifier queues notifications for a user on every channel they chose.
+type Notifier interface {
+	// Notify renders the template in the user's language and queues it. The
+	// user's username is added to data. Called within Transactor.WithinTx, the
+	// notifications are only sent if the transaction commits.
+	Notify(ctx context.Context, userID int, template string, data map[string]interface{}) error
+}
+
+// NotificationService defines the interface for notification preferences and
+// sending queued notifications.
+type NotificationService interface {
+	Notifier
+	GetPreferences(ctx context.Context, userID int) (*models.NotificationPreferences, error)
+	UpdatePreferences(ctx context.Context, preferences *models.NotificationPreferences) error
+	GetNotifications(ctx context.Context, status string, limit, offset int) ([]models.Notification, error)
+	Dispatch(ctx context.Context) (int, error)
+	PruneNotifications(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type notificationService struct {
+	repo        repository.NotificationRepository
+	channels    notify.Channels
+	audit       AuditService
+	maxAttempts int
+}
+
+// NewNotificationService creates a new notification service sending through
+// the given channels. A notification is given up on after maxAttempts failed sends.
+func NewNotificationService(repo repository.NotificationRepository, channels notify.Channels, audit AuditService, maxAttempts int) NotificationService {
+	return &notificationService{repo: repo, channels: channels, audit: audit, maxAttempts: maxAttempts}
+}
+
+// Notify implements Notifier. Channels the user has no address for (no phone
+// for SMS or WhatsApp, no email for email) are skipped.
+func (s *notificationService) Notify(ctx context.Context, userID int, template string, data map[string]interface{}) error {
+	recipient, err := s.repo.FindRecipient(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{"Username": recipient.Username}
+	for k, v := range data {
+		values[k] = v
+	}
+	subject, body, err := notify.Render(template, recipient.Language, values)
+	if err != nil {
+		return err
+	}
+
+	var notifications []models.Notification
+	for _, channel := range recipient.Channels {
+		to := recipient.Phone
+		if channel == notify.Email {
+			to = recipient.Email
+		}
+		if to == nil || *to == "" {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:    userID,
+			Channel:   channel,
+			Recipient: *to,
+			Template:  template,
+			Language:  recipient.Language,
+			Subject:   subject,
+			Body:      body,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	return s.repo.EnqueueNotifications(ctx, notifications)
+}
+
+// GetPreferences returns a user's notification preferences, the defaults when
+// they never set any.
+func (s *notificationService) GetPreferences(ctx context.Context, userID int) (*models.NotificationPreferences, error) {
+	recipient, err := s.repo.FindRecipient(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationPreferences{
+		UserID:   recipient.UserID,
+		Language: recipient.Language,
+		Channels: recipient.Channels,
+		Email:    recipient.Email,
+	}, nil
+}
+
+// UpdatePreferences validates and saves a user's notification preferences.
+func (s *notificationService) UpdatePreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	if err := validatePreferences(preferences); err != nil {
+		return err
+	}
+	before, err := s.GetPreferences(ctx, preferences.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SavePreferences(ctx, preferences); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditNotificationPreferences, "user", &preferences.UserID, before, preferences)
+	return nil
+}
+
+// GetNotifications pages through queued notifications, newest first,
+// optionally only those with the given status.
+func (s *notificationService) GetNotifications(ctx context.Context, status string, limit, offset int) ([]models.Notification, error) {
+	return s.repo.FindNotifications(ctx, status, limit, offset)
+}
+
+// Dispatch sends the notifications that are due, returning how many were
+// attempted. Failed sends are retried with exponential backoff until
+// maxAttempts is reached.
+func (s *notificationService) Dispatch(ctx context.Context) (int, error) {
+	notifications, err := s.repo.ClaimNotifications(ctx, notificationBatchSize, notificationLease)
+	if err != nil {
+		return 0, err
+	}
+	for _, n := range notifications {
+		if err := s.send(ctx, n); err != nil {
+			return 0, err
+		}
+	}
+	return len(notifications), nil
+}
+
+// send makes one attempt at sending a notification and records its outcome.
+// Only a failure to record the outcome is returned.
+func (s *notificationService) send(ctx context.Context, n models.Notification) error {
+	channel, ok := s.channels[n.Channel]
+	if !ok {
+		return s.repo.MarkSendFailed(ctx, n.ID, fmt.Sprintf("no driver for channel %q", n.Channel), nil)
+	}
+	sendErr := channel.Send(ctx, notify.Message{To: n.Recipient, Subject: n.Subject, Body: n.Body})
+	if sendErr == nil {
+		return s.repo.MarkSent(ctx, n.ID)
+	}
+
+	var retryAt *time.Time
+	if attempts := n.Attempts + 1; attempts < s.maxAttempts {
+		next := time.Now().Add(notify.Backoff(attempts))
+		retryAt = &next
+	}
+	message := sendErr.Error()
+	if len(message) > maxNotificationError {
+		message = message[:maxNotificationError]
+	}
+	logging.FromContext(ctx).Warn("sending notification failed",
+		slog.Int64("notification_id", n.ID), slog.String("channel", n.Channel),
+		slog.Int("attempt", n.Attempts+1), slog.String("error", message))
+	return s.repo.MarkSendFailed(ctx, n.ID, message, retryAt)
+}
+
+// PruneNotifications deletes sent and failed notifications older than the
+// retention period.
+func (s *notificationService) PruneNotifications(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PruneNotifications(ctx, time.Now().Add(-retention))
+}
+
+func validatePreferences(preferences *models.NotificationPreferences) error {
+	if preferences.Language == "" {
+		preferences.Language = notify.Arabic
+	}
+	if !slices.Contains(notify.Languages, preferences.Language) {
+		return fmt.Errorf("%w: language must be one of %v", ErrInvalidPreferences, notify.Languages)
+	}
+	if preferences.Channels == nil {
+		preferences.Channels = []string{}
+	}
+	for i, channel := range preferences.Channels {
+		if !slices.Contains(notify.ChannelNames, channel) {
+			return fmt.Errorf("%w: channel must be one of %v", ErrInvalidPreferences, notify.ChannelNames)
+		}
+		if slices.Contains(preferences.Channels[:i], channel) {
+			return fmt.Errorf("%w: channel %q is listed twice", ErrInvalidPreferences, channel)
+		}
+	}
+	if preferences.Email != nil && *preferences.Email == "" {
+		preferences.Email = nil
+	}
+	if preferences.Email != nil {
+		address, err := mail.ParseAddress(*preferences.Email)
+		if err != nil || address.Address != *preferences.Email {
+			return fmt.Errorf("%w: email must be a plain email address", ErrInvalidPreferences)
+		}
+	}
+	if slices.Contains(preferences.Channels, notify.Email) && preferences.Email == nil {
+		return fmt.Errorf("%w: the email channel needs an email address", ErrInvalidPreferences)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// organizationSlug is what a slug must look like to serve as a DNS label.
+var organizationSlug = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ErrInvalidOrganization is wrapped by the validation errors of CreateOrganization.
+var ErrInvalidOrganization = errors.New("invalid organization")
+
+// OrganizationService defines the interface for managing the organizations
+// hosted on the deployment and resolving the one a request is for.
+type OrganizationService interface {
+	GetOrganizations(ctx context.Context) ([]models.Organization, error)
+	CreateOrganization(ctx context.Context, organization *models.Organization) error
+	ResolveOrganization(ctx context.Context, slug string) (*models.Organization, error)
+}
+
+type organizationService struct {
+	repo  repository.OrganizationRepository
+	audit AuditService
+	// bySlug caches resolved organizations. They are never renamed or
+	// deleted, so entries do not go stale.
+	bySlug sync.Map
+}
+
+// NewOrganizationService creates a new organization service.
+func NewOrganizationService(repo repository.OrganizationRepository, audit AuditService) OrganizationService {
+	return &organizationService{repo: repo, audit: audit}
+}
+
+// GetOrganizations retrieves every organization.
+func (s *organizationService) GetOrganizations(ctx context.Context) ([]models.Organization, error) {
+	return s.repo.FindOrganizations(ctx)
+}
+
+// CreateOrganization validates and stores a new organization. Its admins are
+// then created by a developer working in it.
+func (s *organizationService) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	organization.Slug = strings.ToLower(strings.TrimSpace(organization.Slug))
+	organization.Name = strings.TrimSpace(organization.Name)
+	if !organizationSlug.MatchString(organization.Slug) {
+		return fmt.Errorf("%w: slug must be lowercase letters, digits and hyphens", ErrInvalidOrganization)
+	}
+	if organization.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidOrganization)
+	}
+
+	if err := s.repo.CreateOrganization(ctx, organization); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditOrganizationCreate, "organization", &organization.ID, nil, organization)
+	return nil
+}
+
+// ResolveOrganization looks an organization up by its slug.
+func (s *organizationService) ResolveOrganization(ctx context.Context, slug string) (*models.Organization, error) {
+	if cached, ok := s.bySlug.Load(slug); ok {
+		return cached.(*models.Organization), nil
+	}
+	organization, err := s.repo.FindOrganizationBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	s.bySlug.Store(slug, organization)
+	return organization, nil
+}
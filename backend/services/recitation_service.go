@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// maxRecitationGrade is the best grade a recitation can get.
+const maxRecitationGrade = 100
+
+// ErrInvalidRecitation is wrapped by the validation errors of RecordRecitation.
+var ErrInvalidRecitation = errors.New("invalid recitation")
+
+// RecitationService defines the interface for grading recitations. Only admins
+// and the class's teacher may record them.
+type RecitationService interface {
+	RecordRecitation(ctx context.Context, viewerID int, viewerRole string, recitation *models.Recitation) error
+}
+
+type recitationService struct {
+	repo    repository.RecitationRepository
+	classes repository.ClassRepository
+	audit   AuditService
+}
+
+// NewRecitationService creates a new recitation service.
+func NewRecitationService(repo repository.RecitationRepository, classes repository.ClassRepository, audit AuditService) RecitationService {
+	return &recitationService{repo: repo, classes: classes, audit: audit}
+}
+
+// RecordRecitation records a graded recitation of a class member. The ayah
+// range must lie within the surah.
+func (s *recitationService) RecordRecitation(ctx context.Context, viewerID int, viewerRole string, recitation *models.Recitation) error {
+	if recitation.ClassID == nil {
+		return fmt.Errorf("%w: a class is required", ErrInvalidRecitation)
+	}
+	if err := s.authorize(ctx, viewerID, viewerRole, *recitation.ClassID); err != nil {
+		return err
+	}
+	if err := validateRecitation(recitation); err != nil {
+		return err
+	}
+	if err := s.repo.CreateRecitation(ctx, recitation, viewerID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditRecitationRecord, "recitation", &recitation.ID, nil, recitation)
+	return nil
+}
+
+func (s *recitationService) authorize(ctx context.Context, viewerID int, viewerRole string, classID int) error {
+	if _, err := s.classes.FindClassName(ctx, classID); err != nil {
+		return err
+	}
+	switch viewerRole {
+	case "admin", "developer":
+		return nil
+	case "teacher":
+		taught, err := s.classes.IsClassTaughtBy(ctx, classID, viewerID)
+		if err != nil {
+			return err
+		}
+		if !taught {
+			return ErrForbidden
+		}
+		return nil
+	default:
+		return ErrForbidden
+	}
+}
+
+func validateRecitation(recitation *models.Recitation) error {
+	if recitation.StudentID <= 0 {
+		return fmt.Errorf("%w: student_id is required", ErrInvalidRecitation)
+	}
+	surah, ok := quran.SurahByNumber(recitation.Surah)
+	if !ok {
+		return fmt.Errorf("%w: surah must be between 1 and %d", ErrInvalidRecitation, quran.SurahCount)
+	}
+	if recitation.AyahFrom < 1 || recitation.AyahFrom > recitation.AyahTo || recitation.AyahTo > surah.Ayahs {
+		return fmt.Errorf("%w: ayahs must be a range within 1-%d", ErrInvalidRecitation, surah.Ayahs)
+	}
+	if recitation.Grade < 0 || recitation.Grade > maxRecitationGrade {
+		return fmt.Errorf("%w: grade must be between 0 and %d", ErrInvalidRecitation, maxRecitationGrade)
+	}
+	return nil
+}
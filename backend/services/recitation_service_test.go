@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// fakeRecitationRepo stores recitations of the members of each class.
+type fakeRecitationRepo struct {
+	members map[int][]int
+	created []models.Recitation
+	teacher int
+}
+
+func (r *fakeRecitationRepo) CreateRecitation(_ context.Context, recitation *models.Recitation, teacherID int) error {
+	for _, id := range r.members[*recitation.ClassID] {
+		if id == recitation.StudentID {
+			recitation.ID = len(r.created) + 1
+			recitation.CreatedAt = time.Now()
+			r.created = append(r.created, *recitation)
+			r.teacher = teacherID
+			return nil
+		}
+	}
+	return repository.ErrClassMemberNotFound
+}
+
+func TestRecordRecitation(t *testing.T) {
+	const (
+		classID = 3
+		teacher = 20
+		student = 30
+	)
+	valid := func() *models.Recitation {
+		return &models.Recitation{ClassID: intPtr(classID), StudentID: student, Surah: 2, AyahFrom: 1, AyahTo: 5, Grade: 90}
+	}
+
+	tests := []struct {
+		name     string
+		viewerID int
+		role     string
+		mutate   func(r *models.Recitation)
+		want     error
+	}{
+		{name: "class teacher", viewerID: teacher, role: "teacher", mutate: func(*models.Recitation) {}},
+		{name: "admin", viewerID: 1, role: "admin", mutate: func(*models.Recitation) {}},
+		{name: "whole surah", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.Surah, r.AyahFrom, r.AyahTo = 1, 1, 7 }},
+		{name: "other teacher", viewerID: 21, role: "teacher", mutate: func(*models.Recitation) {}, want: ErrForbidden},
+		{name: "student", viewerID: student, role: "student", mutate: func(*models.Recitation) {}, want: ErrForbidden},
+		{name: "unknown class", viewerID: 1, role: "admin", mutate: func(r *models.Recitation) { r.ClassID = intPtr(99) }, want: repository.ErrClassNotFound},
+		{name: "no class", viewerID: 1, role: "admin", mutate: func(r *models.Recitation) { r.ClassID = nil }, want: ErrInvalidRecitation},
+		{name: "student not in the class", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.StudentID = 31 }, want: repository.ErrClassMemberNotFound},
+		{name: "missing student", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.StudentID = 0 }, want: ErrInvalidRecitation},
+		{name: "surah out of range", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.Surah = 115 }, want: ErrInvalidRecitation},
+		{name: "ayah past the surah", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.Surah, r.AyahTo = 1, 8 }, want: ErrInvalidRecitation},
+		{name: "ayahs reversed", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.AyahFrom, r.AyahTo = 5, 4 }, want: ErrInvalidRecitation},
+		{name: "ayah zero", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.AyahFrom = 0 }, want: ErrInvalidRecitation},
+		{name: "grade above 100", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.Grade = 101 }, want: ErrInvalidRecitation},
+		{name: "negative grade", viewerID: teacher, role: "teacher", mutate: func(r *models.Recitation) { r.Grade = -1 }, want: ErrInvalidRecitation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRecitationRepo{members: map[int][]int{classID: {student}}}
+			audit := &fakeAudit{}
+			service := NewRecitationService(repo, &fakeClassRepo{teachers: map[int]int{classID: teacher}}, audit)
+
+			recitation := valid()
+			tt.mutate(recitation)
+			err := service.RecordRecitation(context.Background(), tt.viewerID, tt.role, recitation)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("RecordRecitation error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if len(repo.created) != 0 || len(audit.actions) != 0 {
+					t.Errorf("rejected recitation was stored (%d) or audited (%v)", len(repo.created), audit.actions)
+				}
+				return
+			}
+			if len(repo.created) != 1 || recitation.ID != 1 {
+				t.Fatalf("stored %d recitations, ID %d", len(repo.created), recitation.ID)
+			}
+			if repo.teacher != tt.viewerID {
+				t.Errorf("graded by %d, want %d", repo.teacher, tt.viewerID)
+			}
+			if len(audit.actions) != 1 || audit.actions[0] != AuditRecitationRecord {
+				t.Errorf("audited %v, want [%s]", audit.actions, AuditRecitationRecord)
+			}
+		})
+	}
+}
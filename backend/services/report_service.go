@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// maxReportDays is the longest period a report may cover.
+const maxReportDays = 366
+
+// ErrInvalidReport is returned for a report period that cannot be produced.
+var ErrInvalidReport = errors.New("invalid report")
+
+// Report is a class report ready to be rendered. Its rows are only read from
+// the database when Rows is called, one at a time.
+type Report struct {
+	Title    string
+	Subtitle string
+	// Name is a file name for the report, without an extension.
+	Name    string
+	Columns []string
+	// Widths are the relative widths of the columns when printed.
+	Widths []float64
+	Rows   func(ctx context.Context, emit func([]string) error) error
+}
+
+// ReportService defines the interface for class reports.
+type ReportService interface {
+	ClassProgressReport(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (*Report, error)
+	AttendanceReport(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (*Report, error)
+	RecitationReport(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (*Report, error)
+}
+
+type reportService struct {
+	repo    repository.ReportRepository
+	classes repository.ClassRepository
+}
+
+// NewReportService creates a new report service.
+func NewReportService(repo repository.ReportRepository, classes repository.ClassRepository) ReportService {
+	return &reportService{repo: repo, classes: classes}
+}
+
+// ClassProgressReport reports how far each class member got during the period.
+func (s *reportService) ClassProgressReport(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (*Report, error) {
+	className, err := s.prepare(ctx, viewerID, viewerRole, classID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	start, end := from.Format(dateLayout), to.Format(dateLayout)
+	return &Report{
+		Title:    "تقرير الحفظ — " + className,
+		Subtitle: periodLabel(start, end),
+		Name:     fmt.Sprintf("progress-%d-%s-%s", classID, start, end),
+		Columns:  []string{"الطالب", "صفحة البداية", "الصفحة الحالية", "الصفحات المنجزة", "السورة", "الآية", "عدد التحديثات"},
+		Widths:   []float64{3, 1.5, 1.5, 1.5, 2, 1, 1.5},
+		Rows: func(ctx context.Context, emit func([]string) error) error {
+			return s.repo.StreamClassProgress(ctx, classID, start, end, func(row models.ProgressReportRow) error {
+				advanced := ""
+				if row.StartPage != nil && row.EndPage != nil {
+					advanced = strconv.Itoa(*row.EndPage - *row.StartPage)
+				}
+				surah := ""
+				if row.Surah != nil {
+					surah = quran.SurahName(*row.Surah)
+				}
+				return emit([]string{row.Username, formatOptionalInt(row.StartPage), formatOptionalInt(row.EndPage), advanced, surah, formatOptionalInt(row.Ayah), strconv.Itoa(row.Entries)})
+			})
+		},
+	}, nil
+}
+
+// AttendanceReport reports each class member's attendance during the period.
+// The attendance rate counts late arrivals as attended.
+func (s *reportService) AttendanceReport(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (*Report, error) {
+	className, err := s.prepare(ctx, viewerID, viewerRole, classID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	start, end := from.Format(dateLayout), to.Format(dateLayout)
+	return &Report{
+		Title:    "تقرير الحضور — " + className,
+		Subtitle: periodLabel(start, end),
+		Name:     fmt.Sprintf("attendance-%d-%s-%s", classID, start, end),
+		Columns:  []string{"الطالب", "حاضر", "غائب", "متأخر", "بعذر", "نسبة الحضور"},
+		Widths:   []float64{3, 1, 1, 1, 1, 1.5},
+		Rows: func(ctx context.Context, emit func([]string) error) error {
+			return s.repo.StreamAttendance(ctx, classID, start, end, func(row models.AttendanceReportRow) error {
+				rate := ""
+				if total := row.Present + row.Absent + row.Late + row.Excused; total > 0 {
+					rate = fmt.Sprintf("%.0f%%", float64(row.Present+row.Late)*100/float64(total))
+				}
+				return emit([]string{row.Username, strconv.Itoa(row.Present), strconv.Itoa(row.Absent), strconv.Itoa(row.Late), strconv.Itoa(row.Excused), rate})
+			})
+		},
+	}, nil
+}
+
+// RecitationReport lists the recitations graded in the class during the period.
+func (s *reportService) RecitationReport(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (*Report, error) {
+	className, err := s.prepare(ctx, viewerID, viewerRole, classID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	start, end := from.Format(dateLayout), to.Format(dateLayout)
+	return &Report{
+		Title:    "تقرير التسميع — " + className,
+		Subtitle: periodLabel(start, end),
+		Name:     fmt.Sprintf("recitations-%d-%s-%s", classID, start, end),
+		Columns:  []string{"التاريخ", "الطالب", "السورة", "الآيات", "الدرجة", "ملاحظات"},
+		Widths:   []float64{1.5, 2.5, 2, 1.2, 1, 3.5},
+		Rows: func(ctx context.Context, emit func([]string) error) error {
+			return s.repo.StreamRecitations(ctx, classID, start, end, func(row models.RecitationReportRow) error {
+				notes := ""
+				if row.Notes != nil {
+					notes = *row.Notes
+				}
+				ayahs := fmt.Sprintf("%d-%d", row.AyahFrom, row.AyahTo)
+				return emit([]string{row.Date, row.Username, quran.SurahName(row.Surah), ayahs, strconv.Itoa(row.Grade), notes})
+			})
+		},
+	}, nil
+}
+
+// prepare checks the period and that the viewer may report on the class, which
+// only admins and the class's teacher may, and returns the class name.
+func (s *reportService) prepare(ctx context.Context, viewerID int, viewerRole string, classID int, from, to time.Time) (string, error) {
+	if to.Before(from) {
+		return "", fmt.Errorf("%w: the period ends before it starts", ErrInvalidReport)
+	}
+	if to.Sub(from) >= maxReportDays*24*time.Hour {
+		return "", fmt.Errorf("%w: a report covers at most %d days", ErrInvalidReport, maxReportDays)
+	}
+
+	switch viewerRole {
+	case "admin", "developer":
+	case "teacher":
+		taught, err := s.classes.IsClassTaughtBy(ctx, classID, viewerID)
+		if err != nil {
+			return "", err
+		}
+		if !taught {
+			return "", ErrForbidden
+		}
+	default:
+		return "", ErrForbidden
+	}
+	return s.classes.FindClassName(ctx, classID)
+}
+
+func periodLabel(from, to string) string {
+	return "من " + from + " إلى " + to
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
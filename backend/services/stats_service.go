@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// statsWeeks is how many weeks of progress entries the admin stats cover.
+const statsWeeks = 12
+
+// statsQueryTimeout bounds a stats query. The query is shared by every caller
+// waiting for it, so it does not end with the request that started it.
+const statsQueryTimeout = 30 * time.Second
+
+// StatsService defines the interface for school-wide statistics.
+type StatsService interface {
+	GetAdminStats(ctx context.Context) (*models.AdminStats, error)
+}
+
+type statsService struct {
+	repo         repository.StatsRepository
+	cacheTTL     time.Duration
+	queryTimeout time.Duration
+	now          func() time.Time
+
+	mu sync.Mutex
+	// cached and inflight are keyed by organization; organization 0 is all of them.
+	cached   map[int]cachedStats
+	inflight map[int]*statsCall
+}
+
+type cachedStats struct {
+	stats     *models.AdminStats
+	expiresAt time.Time
+}
+
+// statsCall is a query in progress; done is closed once stats and err are set.
+type statsCall struct {
+	done  chan struct{}
+	stats *models.AdminStats
+	err   error
+}
+
+// NewStatsService creates a new stats service. Results are reused for
+// cacheTTL; a zero TTL queries the database on every call.
+func NewStatsService(repo repository.StatsRepository, cacheTTL time.Duration) StatsService {
+	return &statsService{
+		repo:         repo,
+		cacheTTL:     cacheTTL,
+		queryTimeout: statsQueryTimeout,
+		now:          time.Now,
+		cached:       map[int]cachedStats{},
+		inflight:     map[int]*statsCall{},
+	}
+}
+
+// GetAdminStats returns the school-wide overview of the organization ctx is
+// scoped to. Concurrent callers for the same organization share a single
+// query instead of each querying the database; other organizations are not
+// held up by it. Every caller stops waiting when its own ctx ends.
+func (s *statsService) GetAdminStats(ctx context.Context) (*models.AdminStats, error) {
+	organizationID, _ := repository.TenantFromContext(ctx)
+
+	s.mu.Lock()
+	if entry, ok := s.cached[organizationID]; ok && s.now().Before(entry.expiresAt) {
+		s.mu.Unlock()
+		return entry.stats, nil
+	}
+	call, ok := s.inflight[organizationID]
+	if !ok {
+		call = &statsCall{done: make(chan struct{})}
+		s.inflight[organizationID] = call
+		go s.query(ctx, organizationID, call)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.stats, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// query runs a shared stats query and caches its result. It keeps the values
+// of the ctx that started it, such as the tenant, but not its cancellation, so
+// the other callers are not failed when that request ends.
+func (s *statsService) query(ctx context.Context, organizationID int, call *statsCall) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.queryTimeout)
+	defer cancel()
+
+	call.stats, call.err = s.repo.FindAdminStats(ctx, statsWeeks)
+	now := s.now()
+	if call.err == nil {
+		call.stats.GeneratedAt = now
+	}
+
+	s.mu.Lock()
+	if call.err == nil && s.cacheTTL > 0 {
+		s.cached[organizationID] = cachedStats{stats: call.stats, expiresAt: now.Add(s.cacheTTL)}
+	}
+	delete(s.inflight, organizationID)
+	s.mu.Unlock()
+	close(call.done)
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// fakeStatsRepo counts queries per organization. Queries for an organization
+// in block wait until its channel is closed or their context ends.
+type fakeStatsRepo struct {
+	repository.StatsRepository
+
+	mu      sync.Mutex
+	queries map[int]int
+	started chan int
+	block   map[int]chan struct{}
+	err     error
+}
+
+func newFakeStatsRepo() *fakeStatsRepo {
+	return &fakeStatsRepo{queries: map[int]int{}, started: make(chan int, 16), block: map[int]chan struct{}{}}
+}
+
+func (r *fakeStatsRepo) FindAdminStats(ctx context.Context, _ int) (*models.AdminStats, error) {
+	organizationID, _ := repository.TenantFromContext(ctx)
+	r.mu.Lock()
+	r.queries[organizationID]++
+	block, err := r.block[organizationID], r.err
+	r.mu.Unlock()
+
+	r.started <- organizationID
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.AdminStats{Classes: organizationID}, nil
+}
+
+func (r *fakeStatsRepo) count(organizationID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.queries[organizationID]
+}
+
+func TestGetAdminStatsCaching(t *testing.T) {
+	repo := newFakeStatsRepo()
+	service := NewStatsService(repo, time.Minute).(*statsService)
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	org1, org2 := repository.WithTenant(context.Background(), 1), repository.WithTenant(context.Background(), 2)
+
+	steps := []struct {
+		name        string
+		ctx         context.Context
+		advance     time.Duration
+		wantClasses int
+		wantQueries map[int]int
+	}{
+		{name: "first call queries", ctx: org1, wantClasses: 1, wantQueries: map[int]int{1: 1}},
+		{name: "second call is cached", ctx: org1, advance: 59 * time.Second, wantClasses: 1, wantQueries: map[int]int{1: 1}},
+		{name: "other organization has its own entry", ctx: org2, wantClasses: 2, wantQueries: map[int]int{1: 1, 2: 1}},
+		{name: "expired entry is refreshed", ctx: org1, advance: time.Second, wantClasses: 1, wantQueries: map[int]int{1: 2, 2: 1}},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		stats, err := service.GetAdminStats(step.ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if stats.Classes != step.wantClasses {
+			t.Errorf("%s: got the stats of organization %d, want %d", step.name, stats.Classes, step.wantClasses)
+		}
+		for organizationID, want := range step.wantQueries {
+			if got := repo.count(organizationID); got != want {
+				t.Errorf("%s: organization %d queried %d times, want %d", step.name, organizationID, got, want)
+			}
+		}
+	}
+}
+
+func TestGetAdminStatsWithoutCache(t *testing.T) {
+	repo := newFakeStatsRepo()
+	service := NewStatsService(repo, 0)
+	ctx := repository.WithTenant(context.Background(), 1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetAdminStats(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := repo.count(1); got != 3 {
+		t.Errorf("queried %d times, want 3", got)
+	}
+}
+
+func TestGetAdminStatsErrorsAreNotCached(t *testing.T) {
+	repo := newFakeStatsRepo()
+	repo.err = errors.New("database down")
+	service := NewStatsService(repo, time.Minute)
+	ctx := repository.WithTenant(context.Background(), 1)
+
+	if _, err := service.GetAdminStats(ctx); !errors.Is(err, repo.err) {
+		t.Fatalf("GetAdminStats error = %v, want %v", err, repo.err)
+	}
+	repo.mu.Lock()
+	repo.err = nil
+	repo.mu.Unlock()
+	if _, err := service.GetAdminStats(ctx); err != nil {
+		t.Fatalf("GetAdminStats after recovery: %v", err)
+	}
+	if got := repo.count(1); got != 2 {
+		t.Errorf("queried %d times, want 2", got)
+	}
+}
+
+func TestGetAdminStatsBatchesConcurrentCallers(t *testing.T) {
+	const callers = 5
+	repo := newFakeStatsRepo()
+	release := make(chan struct{})
+	repo.block[1] = release
+	service := NewStatsService(repo, time.Minute)
+	org1 := repository.WithTenant(context.Background(), 1)
+
+	results := make(chan *models.AdminStats, callers)
+	var wg sync.WaitGroup
+	start := func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats, err := service.GetAdminStats(org1)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- stats
+		}()
+	}
+	start()
+	<-repo.started // the first caller is now querying
+	for i := 1; i < callers; i++ {
+		start()
+	}
+
+	// Another organization is served while organization 1's query is stuck.
+	done := make(chan error, 1)
+	go func() {
+		_, err := service.GetAdminStats(repository.WithTenant(context.Background(), 2))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("organization 2 waited for organization 1's query")
+	}
+
+	// A waiting caller gives up when its own context ends.
+	cancelled, cancel := context.WithCancel(org1)
+	cancel()
+	if _, err := service.GetAdminStats(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	wg.Wait()
+	close(results)
+
+	var first *models.AdminStats
+	for stats := range results {
+		if first == nil {
+			first = stats
+		}
+		if stats != first {
+			t.Error("concurrent callers got different results")
+		}
+	}
+	if got := repo.count(1); got != 1 {
+		t.Errorf("organization 1 queried %d times by %d concurrent callers, want 1", got, callers)
+	}
+}
+
+func TestGetAdminStatsOutlivesTheFirstCaller(t *testing.T) {
+	repo := newFakeStatsRepo()
+	release := make(chan struct{})
+	repo.block[1] = release
+	service := NewStatsService(repo, time.Minute)
+	org1 := repository.WithTenant(context.Background(), 1)
+
+	first, cancel := context.WithCancel(org1)
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := service.GetAdminStats(first)
+		firstErr <- err
+	}()
+	<-repo.started
+
+	second := make(chan error, 1)
+	go func() {
+		stats, err := service.GetAdminStats(org1)
+		if err == nil && stats.Classes != 1 {
+			t.Errorf("stats = %+v, want organization 1's", stats)
+		}
+		second <- err
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled first caller error = %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("second caller failed with the first one's cancellation: %v", err)
+	}
+	if got := repo.count(1); got != 1 {
+		t.Errorf("queried %d times, want 1", got)
+	}
+}
+
+func TestGetAdminStatsQueryTimeout(t *testing.T) {
+	repo := newFakeStatsRepo()
+	repo.block[1] = make(chan struct{})
+	service := NewStatsService(repo, time.Minute).(*statsService)
+	service.queryTimeout = 10 * time.Millisecond
+
+	if _, err := service.GetAdminStats(repository.WithTenant(context.Background(), 1)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("stuck query error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
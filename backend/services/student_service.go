@@ -2,25 +2,228 @@ package services
 
 import (
 	"context"
+	"math"
+	"time"
 
 	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
 	"github.com/kolind-am/quran-project/backend/repository"
 )
 
+// progressWeeks is how many weeks of history the progress analytics cover.
+const progressWeeks = 12
+
+// dateLayout is the format used for calendar dates in API responses.
+const dateLayout = "2006-01-02"
+
+// What a student's completion is projected towards.
+const (
+	ProjectionGoal   = "goal"
+	ProjectionKhatma = "khatma"
+	ProjectionQuran  = "quran"
+)
+
 // StudentService defines the interface for student-related business logic.
 type StudentService interface {
 	GetStudentData(ctx context.Context, id int) (*models.StudentData, error)
+	GetProgress(ctx context.Context, id int, filter models.ProgressFilter) ([]models.ProgressEntry, error)
+	GetProgressAnalytics(ctx context.Context, id int) (*models.ProgressAnalytics, error)
+	CanViewStudent(ctx context.Context, viewerID int, viewerRole string, studentID int) (bool, error)
 }
 
 type studentService struct {
-	repo repository.StudentRepository
+	repo     repository.StudentRepository
+	progress repository.ProgressRepository
+	classes  repository.ClassRepository
+	goals    repository.GoalRepository
+	khatmas  repository.KhatmaRepository
 }
 
 // NewStudentService creates a new student service.
-func NewStudentService(repo repository.StudentRepository) StudentService {
-	return &studentService{repo: repo}
+func NewStudentService(repo repository.StudentRepository, progress repository.ProgressRepository, classes repository.ClassRepository, goals repository.GoalRepository, khatmas repository.KhatmaRepository) StudentService {
+	return &studentService{repo: repo, progress: progress, classes: classes, goals: goals, khatmas: khatmas}
 }
 
 func (s *studentService) GetStudentData(ctx context.Context, id int) (*models.StudentData, error) {
 	return s.repo.FindStudentData(ctx, id)
 }
+
+// GetProgress retrieves a student's progress timeline, newest first.
+func (s *studentService) GetProgress(ctx context.Context, id int, filter models.ProgressFilter) ([]models.ProgressEntry, error) {
+	return s.progress.FindEntries(ctx, id, filter)
+}
+
+// GetProgressAnalytics computes a student's weekly pace, streaks and projected
+// completion from their progress history.
+func (s *studentService) GetProgressAnalytics(ctx context.Context, id int) (*models.ProgressAnalytics, error) {
+	// One extra week gives the baseline for the oldest week's advance.
+	pages, err := s.progress.FindWeeklyPages(ctx, id, progressWeeks+1)
+	if err != nil {
+		return nil, err
+	}
+	dates, err := s.progress.FindActiveDates(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	weekly := weeklyAdvances(pages)
+	perWeek := pagesPerWeek(weekly)
+	currentPage := 0
+	if len(pages) > 0 && pages[len(pages)-1].Page != nil {
+		currentPage = *pages[len(pages)-1].Page
+	}
+
+	projection, err := s.project(ctx, id, currentPage, perWeek, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProgressAnalytics{
+		StudentID:    id,
+		Weekly:       weekly,
+		PagesPerWeek: perWeek,
+		Streak:       progressStreak(dates, now),
+		Projection:   projection,
+	}, nil
+}
+
+// project estimates when a student reaches their nearest target: the end page
+// of the memorization goal with the nearest deadline that is under way and not
+// yet reached, else the end of their open khatma, else the end of the Quran.
+func (s *studentService) project(ctx context.Context, id, currentPage int, perWeek float64, now time.Time) (models.ProgressProjection, error) {
+	goals, err := s.goals.FindGoalsForStudent(ctx, id)
+	if err != nil {
+		return models.ProgressProjection{}, err
+	}
+	today := now.Format(dateLayout)
+	for _, goal := range goals {
+		if goal.Type != "memorization" || goal.EndPage == nil || goal.StartDate > today || goal.Deadline < today || currentPage >= *goal.EndPage {
+			continue
+		}
+		projection := projectCompletion(currentPage, *goal.EndPage, perWeek, now)
+		projection.Target = ProjectionGoal
+		projection.Deadline = &goal.Deadline
+		return projection, nil
+	}
+
+	khatma, err := s.khatmas.FindOpenKhatma(ctx, id)
+	if err != nil {
+		return models.ProgressProjection{}, err
+	}
+	if khatma != nil {
+		projection := projectCompletion(khatma.LastPage, quran.Pages, perWeek, now)
+		projection.Target = ProjectionKhatma
+		return projection, nil
+	}
+
+	projection := projectCompletion(currentPage, quran.Pages, perWeek, now)
+	projection.Target = ProjectionQuran
+	return projection, nil
+}
+
+// CanViewStudent reports whether the viewer may see a student's progress:
+// students see their own, teachers the students in their classes and admins everyone.
+func (s *studentService) CanViewStudent(ctx context.Context, viewerID int, viewerRole string, studentID int) (bool, error) {
+	return canViewStudent(ctx, s.classes, viewerID, viewerRole, studentID)
+}
+
+func canViewStudent(ctx context.Context, classes repository.ClassRepository, viewerID int, viewerRole string, studentID int) (bool, error) {
+	switch viewerRole {
+	case "admin", "developer":
+		return true, nil
+	case "teacher":
+		return classes.IsStudentTaughtBy(ctx, studentID, viewerID)
+	default:
+		return viewerID == studentID, nil
+	}
+}
+
+// weeklyAdvances turns the page reached at the end of each week into the pages
+// advanced during it. The first week only serves as the baseline; weeks before
+// any progress was recorded, or in which a student went back, count as zero.
+func weeklyAdvances(pages []models.WeeklyPage) []models.WeeklyProgress {
+	weekly := []models.WeeklyProgress{}
+	for i := 1; i < len(pages); i++ {
+		week := models.WeeklyProgress{WeekStart: pages[i].WeekStart}
+		if prev, cur := pages[i-1].Page, pages[i].Page; prev != nil && cur != nil && *cur > *prev {
+			week.PagesAdvanced = *cur - *prev
+		}
+		weekly = append(weekly, week)
+	}
+	return weekly
+}
+
+// pagesPerWeek averages the weekly advances.
+func pagesPerWeek(weekly []models.WeeklyProgress) float64 {
+	if len(weekly) == 0 {
+		return 0
+	}
+	total := 0
+	for _, week := range weekly {
+		total += week.PagesAdvanced
+	}
+	return math.Round(float64(total)/float64(len(weekly))*100) / 100
+}
+
+// progressStreak counts consecutive active days in dates (YYYY-MM-DD, most
+// recent first). The current streak is still alive if the last active day was
+// today or yesterday.
+func progressStreak(dates []string, now time.Time) models.ProgressStreak {
+	streak := models.ProgressStreak{}
+	if len(dates) == 0 {
+		return streak
+	}
+	last := dates[0]
+	streak.LastActiveDate = &last
+
+	run := 0
+	var prev time.Time
+	for _, date := range dates {
+		day, err := time.Parse(dateLayout, date)
+		if err != nil {
+			continue
+		}
+		if run > 0 && prev.AddDate(0, 0, -1).Equal(day) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = day
+		streak.Longest = max(streak.Longest, run)
+	}
+
+	today := now.Format(dateLayout)
+	yesterday := now.AddDate(0, 0, -1).Format(dateLayout)
+	if last == today || last == yesterday {
+		streak.Current = 1
+		for i := 1; i < len(dates); i++ {
+			prev, errPrev := time.Parse(dateLayout, dates[i-1])
+			day, err := time.Parse(dateLayout, dates[i])
+			if errPrev != nil || err != nil || !prev.AddDate(0, 0, -1).Equal(day) {
+				break
+			}
+			streak.Current++
+		}
+	}
+	return streak
+}
+
+// projectCompletion estimates when currentPage reaches targetPage at the given pace.
+func projectCompletion(currentPage, targetPage int, perWeek float64, now time.Time) models.ProgressProjection {
+	projection := models.ProgressProjection{
+		TargetPage:     targetPage,
+		CurrentPage:    currentPage,
+		RemainingPages: max(targetPage-currentPage, 0),
+	}
+	switch {
+	case projection.RemainingPages == 0:
+		date := now.Format(dateLayout)
+		projection.EstimatedCompletion = &date
+	case perWeek > 0:
+		days := int(math.Ceil(float64(projection.RemainingPages) / perWeek * 7))
+		date := now.AddDate(0, 0, days).Format(dateLayout)
+		projection.EstimatedCompletion = &date
+	}
+	return projection
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestWeeklyAdvances(t *testing.T) {
+	pages := []models.WeeklyPage{
+		{WeekStart: "2025-01-06"},
+		{WeekStart: "2025-01-13", Page: intPtr(10)},
+		{WeekStart: "2025-01-20", Page: intPtr(14)},
+		{WeekStart: "2025-01-27", Page: intPtr(12)},
+		{WeekStart: "2025-02-03", Page: intPtr(20)},
+	}
+	got := weeklyAdvances(pages)
+	want := []int{0, 4, 0, 8}
+	if len(got) != len(want) {
+		t.Fatalf("weeklyAdvances returned %d weeks, want %d", len(got), len(want))
+	}
+	for i, week := range got {
+		if week.WeekStart != pages[i+1].WeekStart || week.PagesAdvanced != want[i] {
+			t.Fatalf("week %d = %+v, want %s advancing %d", i, week, pages[i+1].WeekStart, want[i])
+		}
+	}
+	if perWeek := pagesPerWeek(got); perWeek != 3 {
+		t.Fatalf("pagesPerWeek = %v, want 3", perWeek)
+	}
+}
+
+func TestProgressStreak(t *testing.T) {
+	now := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		dates   []string
+		current int
+		longest int
+	}{
+		{name: "no activity"},
+		{name: "active today", dates: []string{"2025-03-10", "2025-03-09", "2025-03-07"}, current: 2, longest: 2},
+		{name: "active yesterday", dates: []string{"2025-03-09", "2025-03-08"}, current: 2, longest: 2},
+		{name: "broken", dates: []string{"2025-03-07", "2025-03-06", "2025-03-05", "2025-03-04"}, current: 0, longest: 4},
+		{name: "longer past run", dates: []string{"2025-03-10", "2025-02-03", "2025-02-02", "2025-02-01"}, current: 1, longest: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := progressStreak(tt.dates, now)
+			if got.Current != tt.current || got.Longest != tt.longest {
+				t.Fatalf("progressStreak = %+v, want current %d longest %d", got, tt.current, tt.longest)
+			}
+		})
+	}
+}
+
+func TestProjectCompletion(t *testing.T) {
+	now := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+
+	got := projectCompletion(590, quran.Pages, 7, now)
+	if got.RemainingPages != 14 || got.EstimatedCompletion == nil || *got.EstimatedCompletion != "2025-03-24" {
+		t.Fatalf("projectCompletion = %+v, want 14 pages left finishing 2025-03-24", got)
+	}
+	if got := projectCompletion(100, quran.Pages, 0, now); got.EstimatedCompletion != nil {
+		t.Fatalf("projectCompletion without pace = %+v, want no estimate", got)
+	}
+	if got := projectCompletion(quran.Pages, quran.Pages, 0, now); got.RemainingPages != 0 || got.EstimatedCompletion == nil {
+		t.Fatalf("projectCompletion when finished = %+v, want an estimate of today", got)
+	}
+}
+
+// fakeGoalRepo holds the goals of every student, nearest deadline first.
+type fakeGoalRepo struct {
+	repository.GoalRepository
+	goals []models.Goal
+}
+
+func (r *fakeGoalRepo) FindGoalsForStudent(_ context.Context, _ int) ([]models.Goal, error) {
+	return r.goals, nil
+}
+
+func TestProjectTarget(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	memorize := func(endPage int, start, deadline string) models.Goal {
+		return models.Goal{Type: "memorization", EndPage: &endPage, StartDate: start, Deadline: deadline}
+	}
+	openKhatma := []models.Khatma{{ID: 1, StudentID: 7, Status: KhatmaOpen, LastPage: 500}}
+
+	tests := []struct {
+		name       string
+		goals      []models.Goal
+		khatmas    []models.Khatma
+		wantTarget string
+		wantPage   int
+		wantLeft   int
+	}{
+		{name: "active memorization goal", goals: []models.Goal{memorize(150, "2025-03-01", "2025-04-01")}, khatmas: openKhatma, wantTarget: ProjectionGoal, wantPage: 150, wantLeft: 50},
+		{name: "reading goal", goals: []models.Goal{{Type: "reading", EndPage: intPtr(150), StartDate: "2025-03-01", Deadline: "2025-04-01"}}, khatmas: openKhatma, wantTarget: ProjectionKhatma, wantPage: quran.Pages, wantLeft: 104},
+		{name: "goal past its deadline", goals: []models.Goal{memorize(150, "2025-02-01", "2025-03-01")}, khatmas: openKhatma, wantTarget: ProjectionKhatma, wantPage: quran.Pages, wantLeft: 104},
+		{name: "goal not started", goals: []models.Goal{memorize(150, "2025-04-01", "2025-05-01")}, khatmas: openKhatma, wantTarget: ProjectionKhatma, wantPage: quran.Pages, wantLeft: 104},
+		{name: "goal already reached", goals: []models.Goal{memorize(90, "2025-03-01", "2025-04-01")}, wantTarget: ProjectionQuran, wantPage: quran.Pages, wantLeft: 504},
+		{name: "open khatma", khatmas: openKhatma, wantTarget: ProjectionKhatma, wantPage: quran.Pages, wantLeft: 104},
+		{name: "neither", wantTarget: ProjectionQuran, wantPage: quran.Pages, wantLeft: 504},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &studentService{goals: &fakeGoalRepo{goals: tt.goals}, khatmas: &fakeKhatmaRepo{khatmas: tt.khatmas}}
+			got, err := service.project(context.Background(), 7, 100, 7, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Target != tt.wantTarget || got.TargetPage != tt.wantPage || got.RemainingPages != tt.wantLeft {
+				t.Errorf("project = %+v, want %s page %d with %d left", got, tt.wantTarget, tt.wantPage, tt.wantLeft)
+			}
+			if (got.Deadline != nil) != (tt.wantTarget == ProjectionGoal) {
+				t.Errorf("deadline = %v", got.Deadline)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+// recentRecitationsLimit is how many recitations the teacher dashboard lists.
+const recentRecitationsLimit = 10
+
+// TeacherService defines the interface for teacher-related business logic.
+type TeacherService interface {
+	GetDashboard(ctx context.Context, teacherID int, stalledAfterDays int) (*models.TeacherDashboard, error)
+}
+
+type teacherService struct {
+	repo repository.TeacherRepository
+}
+
+// NewTeacherService creates a new teacher service.
+func NewTeacherService(repo repository.TeacherRepository) TeacherService {
+	return &teacherService{repo: repo}
+}
+
+// GetDashboard builds the teacher dashboard for today. Students count as
+// stalled when they have not progressed in stalledAfterDays days.
+func (s *teacherService) GetDashboard(ctx context.Context, teacherID int, stalledAfterDays int) (*models.TeacherDashboard, error) {
+	now := time.Now()
+	dashboard, err := s.repo.FindDashboard(ctx, teacherID, now, now.AddDate(0, 0, -stalledAfterDays), recentRecitationsLimit)
+	if err != nil {
+		return nil, err
+	}
+	dashboard.Date = now.Format("2006-01-02")
+	dashboard.StalledAfterDays = stalledAfterDays
+	return dashboard, nil
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/quran"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// maxImportRows bounds how many users a single roster import may create.
+	maxImportRows = 1000
+	// generatedPasswordLength is the length of initial passwords for imported users.
+	generatedPasswordLength = 12
+	// passwordAlphabet leaves out characters that are easily confused when read aloud.
+	passwordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// ErrInvalidImport is wrapped by errors that make a whole roster file unusable.
+var ErrInvalidImport = errors.New("invalid import")
+
+// importColumns are the columns a roster may contain; only username is required.
+var importColumns = []string{"username", "phone", "role", "class", "progress_surah", "progress_ayah", "progress_page"}
+
+// importRoles are the roles a roster may assign. Privileged accounts are created one by one.
+var importRoles = []string{"student", "teacher", "user"}
+
+// ImportUsers validates a roster (header row first) and, unless dryRun is set
+// and as long as every row is valid, creates its users in one transaction with
+// generated initial passwords. Students imported with a position get a khatma
+// started from it.
+func (s *userService) ImportUsers(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+	columns, err := importHeader(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ImportResult{DryRun: dryRun, Errors: []models.ImportError{}}
+	classes, err := s.repo.FindAllClasses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]int{}
+	var usernames []string
+	for i, row := range rows[1:] {
+		rowNumber := i + 2
+		if isBlankRow(row) {
+			continue
+		}
+		result.Rows++
+		if result.Rows > maxImportRows {
+			return nil, fmt.Errorf("%w: at most %d users can be imported at once", ErrInvalidImport, maxImportRows)
+		}
+
+		user, rowErrors := parseImportRow(rowNumber, columns, row, classes)
+		if first, ok := seen[user.User.Username]; ok && user.User.Username != "" {
+			rowErrors = append(rowErrors, models.ImportError{Row: rowNumber, Field: "username", Message: fmt.Sprintf("duplicates row %d", first)})
+		} else if user.User.Username != "" {
+			seen[user.User.Username] = rowNumber
+			usernames = append(usernames, user.User.Username)
+		}
+		result.Errors = append(result.Errors, rowErrors...)
+		result.Users = append(result.Users, user)
+	}
+
+	if len(usernames) > 0 {
+		existing, err := s.repo.FindExistingUsernames(ctx, usernames)
+		if err != nil {
+			return nil, err
+		}
+		for _, username := range existing {
+			result.Errors = append(result.Errors, models.ImportError{Row: seen[username], Field: "username", Message: "is already taken"})
+		}
+	}
+	slices.SortStableFunc(result.Errors, func(a, b models.ImportError) int { return a.Row - b.Row })
+
+	result.Valid = len(result.Errors) == 0
+	if !result.Valid || dryRun {
+		result.Users = nil
+		return result, nil
+	}
+
+	passwords, err := s.hashGeneratedPasswords(result.Users)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ImportUsers(ctx, result.Users); err != nil {
+			return err
+		}
+		for i := range result.Users {
+			user := &result.Users[i].User
+			if user.Role == "student" && user.ProgressPage != nil {
+				if err := s.khatmas.StartKhatmaAt(ctx, user.ID, *user.ProgressPage); err != nil {
+					return err
+				}
+			}
+			if err := s.events.Publish(ctx, EventUserCreated, userCreatedEvent(user)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Users {
+		result.Users[i].User.Password = passwords[i]
+	}
+
+	s.audit.Record(ctx, AuditUserImport, "user", nil, nil, map[string]interface{}{"imported": len(usernames), "usernames": usernames})
+
+	enrolled := map[int][]int{}
+	var classIDs []int
+	for _, imported := range result.Users {
+		if imported.ClassID == nil {
+			continue
+		}
+		if _, seen := enrolled[*imported.ClassID]; !seen {
+			classIDs = append(classIDs, *imported.ClassID)
+		}
+		enrolled[*imported.ClassID] = append(enrolled[*imported.ClassID], imported.User.ID)
+	}
+	for _, classID := range classIDs {
+		s.live.PublishClassEvent(ctx, classID, LiveMembershipChanged, membershipChange("added", enrolled[classID]...))
+	}
+	return result, nil
+}
+
+// hashGeneratedPasswords gives every user a random password, storing its hash
+// on the user and returning the clear-text passwords in the same order. Hashing
+// is spread over all CPUs since bcrypt is slow by design.
+func (s *userService) hashGeneratedPasswords(users []models.ImportedUser) ([]string, error) {
+	passwords := make([]string, len(users))
+	for i := range passwords {
+		password, err := generatePassword()
+		if err != nil {
+			return nil, err
+		}
+		passwords[i] = password
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(users))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i := range users {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			hash, err := bcrypt.GenerateFromPassword([]byte(passwords[i]), s.bcryptCost)
+			users[i].User.Password = string(hash)
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	return passwords, errors.Join(errs...)
+}
+
+func generatePassword() (string, error) {
+	password := make([]byte, generatedPasswordLength)
+	alphabetSize := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// importHeader maps each known column to its index in the header row.
+func importHeader(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(importColumns, name) {
+			return nil, fmt.Errorf("%w: unknown column %q, expected %s", ErrInvalidImport, name, strings.Join(importColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidImport, name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("%w: the username column is required", ErrInvalidImport)
+	}
+	return columns, nil
+}
+
+// parseImportRow validates a roster row. Fields that fail validation are left
+// empty on the returned user.
+func parseImportRow(rowNumber int, columns map[string]int, row []string, classes []models.Class) (models.ImportedUser, []models.ImportError) {
+	var errs []models.ImportError
+	fail := func(field, message string) {
+		errs = append(errs, models.ImportError{Row: rowNumber, Field: field, Message: message})
+	}
+	cell := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	imported := models.ImportedUser{Row: rowNumber}
+	user := &imported.User
+
+	user.Username = cell("username")
+	if user.Username == "" {
+		fail("username", "is required")
+	} else if strings.ContainsAny(user.Username, " \t") {
+		fail("username", "must not contain spaces")
+	}
+
+	if phone := cell("phone"); phone != "" {
+		user.Phone = &phone
+	}
+
+	user.Role = strings.ToLower(cell("role"))
+	if user.Role == "" {
+		user.Role = "student"
+	}
+	if !slices.Contains(importRoles, user.Role) {
+		fail("role", fmt.Sprintf("must be one of %s", strings.Join(importRoles, ", ")))
+	}
+
+	if class := cell("class"); class != "" {
+		imported.Class = class
+		classID, message := resolveClass(class, classes)
+		switch {
+		case message != "":
+			fail("class", message)
+		case user.Role != "student":
+			fail("class", "only students can be enrolled in a class")
+		default:
+			imported.ClassID = &classID
+		}
+	}
+
+	surah, surahErr := optionalInt(cell("progress_surah"), 1, quran.SurahCount)
+	ayah, ayahErr := optionalInt(cell("progress_ayah"), 1, 286)
+	if surahErr == nil && ayahErr == nil && surah != nil && ayah != nil {
+		if info, _ := quran.SurahByNumber(*surah); *ayah > info.Ayahs {
+			ayahErr = fmt.Errorf("surah %d has only %d ayahs", *surah, info.Ayahs)
+		}
+	}
+	page, pageErr := optionalInt(cell("progress_page"), 1, quran.Pages)
+	if surahErr != nil {
+		fail("progress_surah", surahErr.Error())
+	}
+	if ayahErr != nil {
+		fail("progress_ayah", ayahErr.Error())
+	}
+	if pageErr != nil {
+		fail("progress_page", pageErr.Error())
+	}
+	if surahErr == nil && ayahErr == nil && pageErr == nil && (surah != nil || ayah != nil || page != nil) {
+		switch {
+		case surah == nil || ayah == nil || page == nil:
+			fail("progress", "progress_surah, progress_ayah and progress_page must be given together")
+		case user.Role != "student":
+			fail("progress", "only students have progress")
+		default:
+			user.ProgressSurah, user.ProgressAyah, user.ProgressPage = surah, ayah, page
+		}
+	}
+	return imported, errs
+}
+
+// resolveClass finds a class by ID or case-insensitive name, returning a
+// validation message if it cannot be identified.
+func resolveClass(value string, classes []models.Class) (int, string) {
+	if id, err := strconv.Atoi(value); err == nil {
+		for _, class := range classes {
+			if class.ID == id {
+				return id, ""
+			}
+		}
+	}
+
+	var matches []int
+	for _, class := range classes {
+		if strings.EqualFold(class.Name, value) {
+			matches = append(matches, class.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Sprintf("no class named %q", value)
+	case 1:
+		return matches[0], ""
+	default:
+		return 0, fmt.Sprintf("several classes are named %q, use the class ID instead", value)
+	}
+}
+
+func optionalInt(value string, lowest, highest int) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errors.New("must be a whole number")
+	}
+	if n < lowest || n > highest {
+		return nil, fmt.Errorf("must be between %d and %d", lowest, highest)
+	}
+	return &n, nil
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+func TestImportHeader(t *testing.T) {
+	columns, err := importHeader([]string{"Username", " Progress Page ", "", "class"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns["username"] != 0 || columns["progress_page"] != 1 || columns["class"] != 3 {
+		t.Fatalf("importHeader = %v", columns)
+	}
+
+	for _, header := range [][]string{{"phone"}, {"username", "email"}, {"username", "Username"}} {
+		if _, err := importHeader(header); !errors.Is(err, ErrInvalidImport) {
+			t.Fatalf("importHeader(%q) error = %v, want ErrInvalidImport", header, err)
+		}
+	}
+}
+
+func TestParseImportRow(t *testing.T) {
+	columns := map[string]int{"username": 0, "role": 1, "class": 2, "progress_surah": 3, "progress_ayah": 4, "progress_page": 5}
+	classes := []models.Class{{ID: 1, Name: "Hifz A"}, {ID: 2, Name: "Tajweed"}, {ID: 3, Name: "Tajweed"}}
+
+	tests := []struct {
+		name   string
+		row    []string
+		fields []string
+	}{
+		{name: "student with progress", row: []string{"amina", "", "hifz a", "78", "1", "582"}},
+		{name: "short row", row: []string{"yusuf"}},
+		{name: "class by ID", row: []string{"zaid", "student", "3"}},
+		{name: "missing username", row: []string{"", "student"}, fields: []string{"username"}},
+		{name: "admin role", row: []string{"eve", "admin"}, fields: []string{"role"}},
+		{name: "unknown class", row: []string{"omar", "", "Hifz B"}, fields: []string{"class"}},
+		{name: "ambiguous class", row: []string{"omar", "", "tajweed"}, fields: []string{"class"}},
+		{name: "teacher in class", row: []string{"ustadh", "teacher", "Hifz A"}, fields: []string{"class"}},
+		{name: "partial progress", row: []string{"omar", "", "", "2", "", ""}, fields: []string{"progress"}},
+		{name: "page out of range", row: []string{"omar", "", "", "2", "1", "700"}, fields: []string{"progress_page"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imported, errs := parseImportRow(2, columns, tt.row, classes)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("parseImportRow errors = %+v, want errors for %v", errs, tt.fields)
+			}
+			for i, err := range errs {
+				if err.Field != tt.fields[i] || err.Row != 2 {
+					t.Fatalf("error %d = %+v, want row 2 field %s", i, err, tt.fields[i])
+				}
+			}
+			if len(errs) == 0 && imported.User.Role == "" {
+				t.Fatalf("valid row has no role: %+v", imported)
+			}
+		})
+	}
+
+	imported, _ := parseImportRow(2, columns, []string{"amina", "", "hifz a", "78", "1", "582"}, classes)
+	if imported.User.Role != "student" || imported.ClassID == nil || *imported.ClassID != 1 || imported.User.ProgressPage == nil || *imported.User.ProgressPage != 582 {
+		t.Fatalf("parseImportRow = %+v, want a student in class 1 at page 582", imported)
+	}
+}
+
+func TestImportUsersStartsKhatmas(t *testing.T) {
+	rows := [][]string{
+		{"username", "role", "progress_surah", "progress_ayah", "progress_page"},
+		{"amina", "student", "2", "10", "3"},
+		{"bilal", "student", "", "", ""},
+		{"yusuf", "teacher", "", "", ""},
+	}
+	khatmas := &fakeKhatmas{}
+	events := &fakeEvents{}
+	service := &userService{repo: &fakeUserRepo{}, khatmas: khatmas, audit: &fakeAudit{}, tx: fakeTx{}, events: events, live: &fakeLive{}, bcryptCost: 4}
+
+	result, err := service.ImportUsers(asActor("admin"), rows, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || len(result.Users) != 3 {
+		t.Fatalf("ImportUsers = %+v", result)
+	}
+	if want := []string{"100:3"}; !slices.Equal(khatmas.started, want) {
+		t.Errorf("started khatmas %v, want %v", khatmas.started, want)
+	}
+	if len(events.types) != 3 {
+		t.Errorf("published %v", events.types)
+	}
+}
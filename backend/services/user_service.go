@@ -2,8 +2,12 @@ package services
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/notify"
+	"github.com/kolind-am/quran-project/backend/quran"
 	"github.com/kolind-am/quran-project/backend/repository"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -14,16 +18,30 @@ type UserService interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	UpdateUser(ctx context.Context, id int, user *models.User) (*models.User, error)
 	DeleteUser(ctx context.Context, id int) error
+	GetDeletedUsers(ctx context.Context) ([]models.User, error)
+	RestoreUser(ctx context.Context, id int) (*models.User, error)
+	GetDeletionPreflight(ctx context.Context, id int) (*models.DeletionPreflight, error)
+	PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error)
+	ImportUsers(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportResult, error)
 }
 
 // userService is an implementation of UserService.
 type userService struct {
-	repo repository.UserRepository
+	repo       repository.UserRepository
+	progress   repository.ProgressRepository
+	classes    repository.ClassRepository
+	khatmas    KhatmaService
+	audit      AuditService
+	tx         repository.Transactor
+	events     EventPublisher
+	notifier   Notifier
+	live       LivePublisher
+	bcryptCost int
 }
 
-// NewUserService creates a new user service.
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+// NewUserService creates a new user service that hashes passwords with the given bcrypt cost.
+func NewUserService(repo repository.UserRepository, progress repository.ProgressRepository, classes repository.ClassRepository, khatmas KhatmaService, audit AuditService, tx repository.Transactor, events EventPublisher, notifier Notifier, live LivePublisher, bcryptCost int) UserService {
+	return &userService{repo: repo, progress: progress, classes: classes, khatmas: khatmas, audit: audit, tx: tx, events: events, notifier: notifier, live: live, bcryptCost: bcryptCost}
 }
 
 // GetUsers retrieves users, applying any business rules.
@@ -35,30 +53,320 @@ func (s *userService) GetUsers(ctx context.Context, role string) ([]models.User,
 
 // CreateUser handles the business logic for creating a new user.
 func (s *userService) CreateUser(ctx context.Context, user *models.User) error {
+	if err := authorizeRoles(ctx, user.Role); err != nil {
+		return err
+	}
+
 	// Hash the password before storing it
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), s.bcryptCost)
 	if err != nil {
 		return err
 	}
 	user.Password = string(hashedPassword)
 
-	return s.repo.CreateUser(ctx, user)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, EventUserCreated, userCreatedEvent(user))
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditUserCreate, "user", &user.ID, nil, user)
+	return nil
 }
 
-// UpdateUser handles the business logic for updating a user.
+// UpdateUser handles the business logic for updating a user. Admins may change
+// any field; teachers may only move the position of a student they teach.
 func (s *userService) UpdateUser(ctx context.Context, id int, user *models.User) (*models.User, error) {
+	actor, _ := ActorFromContext(ctx)
+	if actor.Role == "teacher" {
+		if user.Username != "" || user.Password != "" || user.Role != "" || user.Phone != nil {
+			return nil, ErrForbidden
+		}
+	} else if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
 	if user.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), s.bcryptCost)
 		if err != nil {
 			return nil, err
 		}
 		user.Password = string(hashedPassword)
 	}
-	return s.repo.UpdateUser(ctx, id, user)
+
+	var before, updatedUser *models.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.repo.FindUserByID(ctx, id); err != nil {
+			return err
+		}
+		if err := s.authorizeUpdate(ctx, before, user); err != nil {
+			return err
+		}
+		if updatedUser, err = s.repo.UpdateUser(ctx, id, user); err != nil {
+			return err
+		}
+		return s.recordProgress(ctx, before, updatedUser)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The returned user never carries the password, so note a change explicitly.
+	after := *updatedUser
+	after.Password = user.Password
+	s.audit.Record(ctx, AuditUserUpdate, "user", &id, before, &after)
+	if progressMoved(before, updatedUser) {
+		s.audit.Record(ctx, AuditProgressRecord, "user", &id, progressPosition(before), progressPosition(updatedUser))
+		s.live.PublishStudentEvent(ctx, id, LiveProgressChanged, map[string]interface{}{
+			"student_id": id,
+			"username":   updatedUser.Username,
+			"surah":      *updatedUser.ProgressSurah,
+			"ayah":       *updatedUser.ProgressAyah,
+			"page":       *updatedUser.ProgressPage,
+		})
+	}
+	return updatedUser, nil
+}
+
+// authorizeUpdate checks that the caller may update the user. A teacher's
+// update only carries a position, which they may record for a student in one
+// of their classes.
+func (s *userService) authorizeUpdate(ctx context.Context, before, user *models.User) error {
+	actor, _ := ActorFromContext(ctx)
+	if actor.Role != "teacher" {
+		return authorizeRoles(ctx, before.Role, user.Role)
+	}
+	if before.Role != "student" {
+		return ErrForbidden
+	}
+	taught, err := s.classes.IsStudentTaughtBy(ctx, before.ID, actor.ID)
+	if err != nil {
+		return err
+	}
+	if !taught {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// recordProgress appends a student's position to their progress history when an
+// update moved it, advances their khatma and publishes the progress, along with
+// any juz it completed. Positions are only recorded once surah, ayah and page
+// are all set.
+func (s *userService) recordProgress(ctx context.Context, before, after *models.User) error {
+	if !progressMoved(before, after) {
+		return nil
+	}
+	err := s.progress.CreateEntry(ctx, &models.ProgressEntry{
+		StudentID: after.ID,
+		Surah:     *after.ProgressSurah,
+		Ayah:      *after.ProgressAyah,
+		Page:      *after.ProgressPage,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.khatmas.TrackPage(ctx, after.ID, *after.ProgressPage); err != nil {
+		return err
+	}
+
+	err = s.events.Publish(ctx, EventStudentProgressed, map[string]interface{}{
+		"student_id":    after.ID,
+		"username":      after.Username,
+		"surah":         *after.ProgressSurah,
+		"ayah":          *after.ProgressAyah,
+		"page":          *after.ProgressPage,
+		"previous_page": before.ProgressPage,
+	})
+	if err != nil {
+		return err
+	}
+	for _, juz := range completedJuzs(before.ProgressPage, *after.ProgressPage) {
+		err := s.events.Publish(ctx, EventStudentJuzComplete, map[string]interface{}{
+			"student_id": after.ID,
+			"username":   after.Username,
+			"juz":        juz,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, surah := range completedSurahs(before.ProgressSurah, before.ProgressAyah, *after.ProgressSurah, *after.ProgressAyah) {
+		err := s.notifier.Notify(ctx, after.ID, notify.SurahCompleted, map[string]interface{}{
+			"Surah":     surah,
+			"SurahName": quran.SurahName(surah),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// progressMoved reports whether an update moved a student to a new, complete
+// position.
+func progressMoved(before, after *models.User) bool {
+	if after.Role != "student" || after.ProgressSurah == nil || after.ProgressAyah == nil || after.ProgressPage == nil {
+		return false
+	}
+	return !equalInt(before.ProgressSurah, after.ProgressSurah) || !equalInt(before.ProgressAyah, after.ProgressAyah) || !equalInt(before.ProgressPage, after.ProgressPage)
+}
+
+// progressPosition is the part of a user the progress audit event shows.
+func progressPosition(user *models.User) map[string]*int {
+	return map[string]*int{"surah": user.ProgressSurah, "ayah": user.ProgressAyah, "page": user.ProgressPage}
+}
+
+// completedSurahs returns the surahs whose last ayah a student reached by
+// moving from the previous position to the current one. As with completedJuzs,
+// nothing is completed without a previous position.
+func completedSurahs(previousSurah, previousAyah *int, surah, ayah int) []int {
+	if previousSurah == nil || previousAyah == nil {
+		return nil
+	}
+	before := func(s1, a1, s2, a2 int) bool { return s1 < s2 || (s1 == s2 && a1 < a2) }
+
+	var surahs []int
+	for number := max(*previousSurah, 1); number <= min(surah, quran.SurahCount); number++ {
+		info, ok := quran.SurahByNumber(number)
+		if !ok {
+			continue
+		}
+		if before(*previousSurah, *previousAyah, number, info.Ayahs) && !before(surah, ayah, number, info.Ayahs) {
+			surahs = append(surahs, number)
+		}
+	}
+	return surahs
+}
+
+// completedJuzs returns the juz whose last page a student reached by moving
+// from the previous page to the current one. Nothing is completed without a
+// previous page, since a first position says nothing about what was read.
+func completedJuzs(previous *int, current int) []int {
+	if previous == nil {
+		return nil
+	}
+	var juzs []int
+	for juz := 1; juz <= quran.JuzCount; juz++ {
+		_, last, _ := quran.JuzPages(juz)
+		if *previous < last && last <= current {
+			juzs = append(juzs, juz)
+		}
+	}
+	return juzs
+}
+
+// userCreatedEvent is the payload of a user.created event.
+func userCreatedEvent(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+	}
+}
+
+// authorizeRoles checks that the caller may manage accounts with the given
+// roles. Only admins and developers manage accounts; developers work across
+// every organization, so only a developer may create, change or delete a
+// developer account.
+func authorizeRoles(ctx context.Context, roles ...string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if !slices.Contains(roles, "developer") {
+		return nil
+	}
+	if actor, _ := ActorFromContext(ctx); actor.Role == "developer" {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// requireAdmin checks that the caller is an admin of their organization or a
+// developer.
+func requireAdmin(ctx context.Context) error {
+	if actor, ok := ActorFromContext(ctx); ok && (actor.Role == "admin" || actor.Role == "developer") {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func equalInt(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
 }
 
 // DeleteUser handles the business logic for deleting a user.
+// Users are soft-deleted; PurgeDeletedUsers removes them for good later on.
 func (s *userService) DeleteUser(ctx context.Context, id int) error {
 	// You might want to add checks here, like preventing deletion of the last admin.
-	return s.repo.DeleteUser(ctx, id)
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	before, err := s.repo.FindUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := authorizeRoles(ctx, before.Role); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditUserDelete, "user", &id, before, nil)
+	return nil
+}
+
+// GetDeletedUsers retrieves soft-deleted users that can still be restored.
+// Only admins may see them.
+func (s *userService) GetDeletedUsers(ctx context.Context) ([]models.User, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeletedUsers(ctx)
+}
+
+// RestoreUser brings a soft-deleted user back. Only admins may restore users,
+// and only developers may restore developers.
+func (s *userService) RestoreUser(ctx context.Context, id int) (*models.User, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	var user *models.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.repo.RestoreUser(ctx, id); err != nil {
+			return err
+		}
+		return authorizeRoles(ctx, user.Role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, AuditUserRestore, "user", &id, nil, user)
+	return user, nil
+}
+
+// GetDeletionPreflight reports what deleting a user would affect. Only admins
+// may ask.
+func (s *userService) GetDeletionPreflight(ctx context.Context, id int) (*models.DeletionPreflight, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeletionPreflight(ctx, id)
+}
+
+// PurgeDeletedUsers permanently removes users that have been soft-deleted for
+// longer than the retention period.
+func (s *userService) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.repo.PurgeDeletedUsers(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if purged > 0 {
+		s.audit.Record(ctx, AuditUserPurge, "user", nil, nil, map[string]int64{"purged": purged})
+	}
+	return purged, nil
 }
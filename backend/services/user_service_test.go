@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+)
+
+func TestCompletedJuzs(t *testing.T) {
+	page := func(p int) *int { return &p }
+
+	tests := []struct {
+		previous *int
+		current  int
+		want     []int
+	}{
+		{nil, 604, nil},
+		{page(20), 22, []int{1}},
+		{page(21), 22, nil},
+		{page(40), 62, []int{2, 3}},
+		{page(300), 200, nil},
+		{page(600), 604, []int{30}},
+	}
+	for _, tt := range tests {
+		if got := completedJuzs(tt.previous, tt.current); !slices.Equal(got, tt.want) {
+			t.Errorf("completedJuzs(%v, %d) = %v, want %v", tt.previous, tt.current, got, tt.want)
+		}
+	}
+	if got := completedJuzs(page(1), 604); len(got) != 30 {
+		t.Errorf("a whole khatma completed %d juz, want 30", len(got))
+	}
+}
+
+func TestCompletedSurahs(t *testing.T) {
+	n := func(v int) *int { return &v }
+
+	tests := []struct {
+		previousSurah, previousAyah *int
+		surah, ayah                 int
+		want                        []int
+	}{
+		{nil, nil, 2, 10, nil},
+		{n(1), n(6), 1, 7, []int{1}},
+		{n(1), n(7), 2, 1, nil},
+		{n(1), n(3), 3, 5, []int{1, 2}},
+		{n(2), n(286), 2, 286, nil},
+		{n(112), n(4), 114, 6, []int{113, 114}},
+		{n(5), n(1), 3, 1, nil},
+	}
+	for _, tt := range tests {
+		if got := completedSurahs(tt.previousSurah, tt.previousAyah, tt.surah, tt.ayah); !slices.Equal(got, tt.want) {
+			t.Errorf("completedSurahs(%v:%v, %d:%d) = %v, want %v", tt.previousSurah, tt.previousAyah, tt.surah, tt.ayah, got, tt.want)
+		}
+	}
+}
+
+// fakeUserRepo keeps active and soft-deleted users in memory. Methods the
+// tests do not use are left to the embedded interface.
+type fakeUserRepo struct {
+	repository.UserRepository
+	active       map[int]*models.User
+	deleted      map[int]*models.User
+	purgedBefore time.Time
+	purged       int64
+}
+
+func (r *fakeUserRepo) FindUserByID(_ context.Context, id int) (*models.User, error) {
+	user, ok := r.active[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepo) UpdateUser(_ context.Context, id int, user *models.User) (*models.User, error) {
+	current, ok := r.active[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	updated := *current
+	if user.Role != "" {
+		updated.Role = user.Role
+	}
+	if user.ProgressSurah != nil {
+		updated.ProgressSurah, updated.ProgressAyah, updated.ProgressPage = user.ProgressSurah, user.ProgressAyah, user.ProgressPage
+	}
+	r.active[id] = &updated
+	return &updated, nil
+}
+
+func (r *fakeUserRepo) FindAllClasses(_ context.Context) ([]models.Class, error) {
+	return nil, nil
+}
+
+func (r *fakeUserRepo) FindExistingUsernames(_ context.Context, _ []string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeUserRepo) ImportUsers(_ context.Context, users []models.ImportedUser) error {
+	if r.active == nil {
+		r.active = map[int]*models.User{}
+	}
+	for i := range users {
+		users[i].User.ID = 100 + i
+		r.active[users[i].User.ID] = &users[i].User
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) DeleteUser(_ context.Context, id int) error {
+	if r.deleted == nil {
+		r.deleted = map[int]*models.User{}
+	}
+	r.deleted[id] = r.active[id]
+	delete(r.active, id)
+	return nil
+}
+
+func (r *fakeUserRepo) RestoreUser(_ context.Context, id int) (*models.User, error) {
+	user, ok := r.deleted[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	delete(r.deleted, id)
+	return user, nil
+}
+
+func (r *fakeUserRepo) FindDeletionPreflight(_ context.Context, id int) (*models.DeletionPreflight, error) {
+	return &models.DeletionPreflight{UserID: id}, nil
+}
+
+func (r *fakeUserRepo) PurgeDeletedUsers(_ context.Context, deletedBefore time.Time) (int64, error) {
+	r.purgedBefore = deletedBefore
+	return r.purged, nil
+}
+
+// fakeProgressRepo remembers the progress entries it was asked to create.
+type fakeProgressRepo struct {
+	repository.ProgressRepository
+	entries []models.ProgressEntry
+}
+
+func (r *fakeProgressRepo) CreateEntry(_ context.Context, entry *models.ProgressEntry) error {
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+// fakeKhatmas remembers the pages it was asked to track, and the khatmas it
+// was asked to start as "student:page".
+type fakeKhatmas struct {
+	KhatmaService
+	pages   []int
+	started []string
+}
+
+func (k *fakeKhatmas) TrackPage(_ context.Context, _ int, page int) error {
+	k.pages = append(k.pages, page)
+	return nil
+}
+
+func (k *fakeKhatmas) StartKhatmaAt(_ context.Context, studentID, page int) error {
+	k.started = append(k.started, fmt.Sprintf("%d:%d", studentID, page))
+	return nil
+}
+
+func newTestUserService(repo *fakeUserRepo, audit *fakeAudit) *userService {
+	return &userService{repo: repo, audit: audit, tx: fakeTx{}}
+}
+
+func TestRestoreUser(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor string
+		id    int
+		want  error
+	}{
+		{name: "admin restores a student", actor: "admin", id: 7},
+		{name: "developer restores a developer", actor: "developer", id: 8},
+		{name: "admin cannot restore a developer", actor: "admin", id: 8, want: ErrForbidden},
+		{name: "teacher cannot restore", actor: "teacher", id: 7, want: ErrForbidden},
+		{name: "never deleted", actor: "admin", id: 9, want: repository.ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepo{deleted: map[int]*models.User{
+				7: {ID: 7, Username: "student", Role: "student"},
+				8: {ID: 8, Username: "developer", Role: "developer"},
+			}}
+			audit := &fakeAudit{}
+			user, err := newTestUserService(repo, audit).RestoreUser(asActor(tt.actor), tt.id)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("RestoreUser = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if len(audit.actions) != 0 {
+					t.Errorf("audited %v for a refused restore", audit.actions)
+				}
+				return
+			}
+			if user.ID != tt.id || !slices.Equal(audit.actions, []string{AuditUserRestore}) {
+				t.Errorf("restored %+v and audited %v", user, audit.actions)
+			}
+		})
+	}
+}
+
+func TestManagingUsersRequiresAnAdmin(t *testing.T) {
+	for _, role := range []string{"student", "teacher", "user", ""} {
+		ctx := asActor(role)
+		if role == "" {
+			ctx = context.Background()
+		}
+		service := newTestUserService(&fakeUserRepo{active: map[int]*models.User{7: {ID: 7, Role: "student"}}}, &fakeAudit{})
+
+		if err := service.CreateUser(ctx, &models.User{Username: "new-admin", Password: "secret", Role: "admin"}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%q creating a user = %v, want ErrForbidden", role, err)
+		}
+		if _, err := service.UpdateUser(ctx, 7, &models.User{Role: "admin"}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%q updating a user = %v, want ErrForbidden", role, err)
+		}
+		if err := service.DeleteUser(ctx, 7); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%q deleting a user = %v, want ErrForbidden", role, err)
+		}
+		if _, err := service.ImportUsers(ctx, [][]string{{"username"}, {"amina"}}, true); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%q importing users = %v, want ErrForbidden", role, err)
+		}
+		if _, err := service.GetDeletedUsers(ctx); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%q listing deleted users = %v, want ErrForbidden", role, err)
+		}
+	}
+}
+
+func TestTeacherRecordsProgress(t *testing.T) {
+	position := func(surah, ayah, page int) *models.User {
+		return &models.User{ProgressSurah: &surah, ProgressAyah: &ayah, ProgressPage: &page}
+	}
+	tests := []struct {
+		name   string
+		target int
+		update *models.User
+		want   error
+	}{
+		{name: "own student", target: 7, update: position(2, 10, 3)},
+		{name: "another teacher's student", target: 8, update: position(2, 10, 3), want: ErrForbidden},
+		{name: "a teacher", target: 9, update: position(2, 10, 3), want: ErrForbidden},
+		{name: "own student's username", target: 7, update: &models.User{Username: "renamed"}, want: ErrForbidden},
+		{name: "own student's role", target: 7, update: &models.User{Role: "admin"}, want: ErrForbidden},
+		{name: "own student's password", target: 7, update: &models.User{Password: "secret"}, want: ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepo{active: map[int]*models.User{
+				7: {ID: 7, Role: "student"},
+				8: {ID: 8, Role: "student"},
+				9: {ID: 9, Role: "teacher"},
+			}}
+			progress := &fakeProgressRepo{}
+			khatmas := &fakeKhatmas{}
+			audit := &fakeAudit{}
+			service := &userService{
+				repo:     repo,
+				progress: progress,
+				classes:  &fakeClassRepo{teachers: map[int]int{3: 1, 4: 2}, students: map[int][]int{3: {7}, 4: {8}}},
+				khatmas:  khatmas,
+				audit:    audit,
+				tx:       fakeTx{},
+				events:   &fakeEvents{},
+				notifier: &fakeNotifier{},
+				live:     &fakeLive{},
+			}
+
+			before := repo.active[tt.target]
+			_, err := service.UpdateUser(asActor("teacher"), tt.target, tt.update)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("UpdateUser = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if repo.active[tt.target] != before || len(progress.entries) != 0 || len(audit.actions) != 0 {
+					t.Errorf("refused update had effects: user %+v, progress %v, audit %v", repo.active[tt.target], progress.entries, audit.actions)
+				}
+				return
+			}
+			if len(progress.entries) != 1 || !slices.Equal(khatmas.pages, []int{3}) {
+				t.Errorf("recorded %v and tracked pages %v", progress.entries, khatmas.pages)
+			}
+			if !slices.Equal(audit.actions, []string{AuditUserUpdate, AuditProgressRecord}) {
+				t.Errorf("audited %v", audit.actions)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		actor  string
+		target string
+		want   error
+	}{
+		{name: "admin deletes a student", actor: "admin", target: "student"},
+		{name: "admin deletes an admin", actor: "admin", target: "admin"},
+		{name: "admin cannot delete a developer", actor: "admin", target: "developer", want: ErrForbidden},
+		{name: "developer deletes a developer", actor: "developer", target: "developer"},
+		{name: "teacher cannot delete a student", actor: "teacher", target: "student", want: ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepo{active: map[int]*models.User{7: {ID: 7, Role: tt.target}}}
+			audit := &fakeAudit{}
+			err := newTestUserService(repo, audit).DeleteUser(asActor(tt.actor), 7)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("DeleteUser = %v, want %v", err, tt.want)
+			}
+			deleted := repo.deleted[7] != nil
+			if deleted != (tt.want == nil) {
+				t.Errorf("user deleted = %v", deleted)
+			}
+			if tt.want == nil && !slices.Equal(audit.actions, []string{AuditUserDelete}) {
+				t.Errorf("audited %v", audit.actions)
+			}
+		})
+	}
+}
+
+func TestGetDeletionPreflight(t *testing.T) {
+	service := newTestUserService(&fakeUserRepo{}, &fakeAudit{})
+	if _, err := service.GetDeletionPreflight(asActor("student"), 7); !errors.Is(err, ErrForbidden) {
+		t.Errorf("a student's preflight = %v, want ErrForbidden", err)
+	}
+	preflight, err := service.GetDeletionPreflight(asActor("admin"), 7)
+	if err != nil || preflight.UserID != 7 {
+		t.Errorf("an admin's preflight = %+v, %v", preflight, err)
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	for _, purged := range []int64{0, 3} {
+		repo := &fakeUserRepo{purged: purged}
+		audit := &fakeAudit{}
+		got, err := newTestUserService(repo, audit).PurgeDeletedUsers(context.Background(), 30*24*time.Hour)
+		if err != nil || got != purged {
+			t.Fatalf("PurgeDeletedUsers = %d, %v, want %d", got, err, purged)
+		}
+		if age := time.Since(repo.purgedBefore); age < 30*24*time.Hour || age > 30*24*time.Hour+time.Minute {
+			t.Errorf("purged users deleted before %v, want 30 days ago", repo.purgedBefore)
+		}
+		if wantAudit := purged > 0; (len(audit.actions) == 1) != wantAudit {
+			t.Errorf("purging %d users audited %v", purged, audit.actions)
+		}
+	}
+}
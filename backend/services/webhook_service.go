@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/kolind-am/quran-project/backend/logging"
+	"github.com/kolind-am/quran-project/backend/models"
+	"github.com/kolind-am/quran-project/backend/repository"
+	"github.com/kolind-am/quran-project/backend/webhook"
+)
+
+const (
+	// webhookBatchSize is how many events are fanned out, and how many
+	// deliveries are attempted, per dispatch.
+	webhookBatchSize = 100
+	// maxWebhookError is the longest error message kept in the delivery log.
+	maxWebhookError = 1000
+)
+
+// ErrInvalidWebhook is wrapped by the validation errors of CreateWebhook.
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// WebhookService defines the interface for managing webhook subscriptions and
+// delivering events to them.
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, creatorID int, subscription *models.WebhookSubscription) error
+	GetWebhooks(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, id, limit, offset int) ([]models.WebhookDelivery, error)
+	Dispatch(ctx context.Context) (int, error)
+	PruneEvents(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type webhookService struct {
+	repo        repository.WebhookRepository
+	sender      *webhook.Sender
+	audit       AuditService
+	maxAttempts int
+	lease       time.Duration
+}
+
+// NewWebhookService creates a new webhook service. A delivery is given up on
+// after maxAttempts failed attempts; lease is how long a claimed delivery is
+// hidden from other dispatchers and must outlast a request.
+func NewWebhookService(repo repository.WebhookRepository, sender *webhook.Sender, audit AuditService, maxAttempts int, lease time.Duration) WebhookService {
+	return &webhookService{repo: repo, sender: sender, audit: audit, maxAttempts: maxAttempts, lease: lease}
+}
+
+// CreateWebhook validates and stores a subscription, generating its signing
+// secret. The secret is only returned here.
+func (s *webhookService) CreateWebhook(ctx context.Context, creatorID int, subscription *models.WebhookSubscription) error {
+	if err := validateWebhook(ctx, subscription); err != nil {
+		return err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	subscription.Secret = secret
+	subscription.Active = true
+	subscription.CreatedBy = &creatorID
+	if subscription.Events == nil {
+		subscription.Events = []string{}
+	}
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditWebhookCreate, "webhook", &subscription.ID, nil, subscription)
+	return nil
+}
+
+// GetWebhooks lists every subscription, without their secrets.
+func (s *webhookService) GetWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.FindSubscriptions(ctx)
+}
+
+// DeleteWebhook removes a subscription along with its delivery log.
+func (s *webhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, AuditWebhookDelete, "webhook", &id, nil, nil)
+	return nil
+}
+
+// GetDeliveries pages through a subscription's delivery log, newest first.
+func (s *webhookService) GetDeliveries(ctx context.Context, id, limit, offset int) ([]models.WebhookDelivery, error) {
+	return s.repo.FindDeliveries(ctx, id, limit, offset)
+}
+
+// Dispatch turns new outbox events into deliveries and attempts the deliveries
+// that are due, returning how many were attempted. Failed attempts are retried
+// with exponential backoff until maxAttempts is reached.
+func (s *webhookService) Dispatch(ctx context.Context) (int, error) {
+	for {
+		fanned, err := s.repo.FanOutEvents(ctx, webhookBatchSize)
+		if err != nil {
+			return 0, err
+		}
+		if fanned < webhookBatchSize {
+			break
+		}
+	}
+
+	deliveries, err := s.repo.ClaimDeliveries(ctx, webhookBatchSize, s.lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, delivery := range deliveries {
+		if err := s.deliver(ctx, delivery); err != nil {
+			return 0, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt at a delivery and records its outcome. Only a
+// failure to record the outcome is returned.
+func (s *webhookService) deliver(ctx context.Context, delivery models.PendingDelivery) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         delivery.EventID,
+		"type":       delivery.EventType,
+		"created_at": delivery.EventCreatedAt,
+		"data":       json.RawMessage(delivery.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	statusCode, sendErr := s.sender.Send(ctx, delivery.URL, delivery.Secret, webhook.Message{
+		DeliveryID: delivery.ID,
+		EventType:  delivery.EventType,
+		Body:       body,
+	})
+	if sendErr == nil {
+		return s.repo.MarkDelivered(ctx, delivery.ID, statusCode)
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	var retryAt *time.Time
+	if attempts := delivery.Attempts + 1; attempts < s.maxAttempts {
+		next := time.Now().Add(webhook.Backoff(attempts))
+		retryAt = &next
+	}
+	message := sendErr.Error()
+	if len(message) > maxWebhookError {
+		message = message[:maxWebhookError]
+	}
+	logging.FromContext(ctx).Warn("webhook delivery failed",
+		slog.Int64("delivery_id", delivery.ID), slog.String("event_type", delivery.EventType),
+		slog.Int("attempt", delivery.Attempts+1), slog.String("error", message))
+	return s.repo.MarkAttemptFailed(ctx, delivery.ID, code, message, retryAt)
+}
+
+// PruneEvents deletes dispatched events, and their deliveries, that are older
+// than the retention period.
+func (s *webhookService) PruneEvents(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PruneEvents(ctx, time.Now().Add(-retention))
+}
+
+// validateWebhook checks the subscription's events and its URL, whose host must
+// only resolve to public addresses.
+func validateWebhook(ctx context.Context, subscription *models.WebhookSubscription) error {
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if err := webhook.CheckHost(ctx, target.Hostname()); err != nil {
+		return fmt.Errorf("%w: url must point to a public address", ErrInvalidWebhook)
+	}
+	for _, event := range subscription.Events {
+		if !slices.Contains(EventTypes, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+	return nil
+}
+
+// newWebhookSecret returns a random signing secret such as "whsec_3f9a…".
+func newWebhookSecret() (string, error) {
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(random), nil
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kolind-am/quran-project/backend/models"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		events []string
+		want   error
+	}{
+		{name: "public address", url: "https://93.184.216.34/hooks", events: []string{EventUserCreated}},
+		{name: "public IPv6 address", url: "https://[2606:4700:4700::1111]:8443/hooks"},
+		{name: "relative URL", url: "/hooks", want: ErrInvalidWebhook},
+		{name: "other scheme", url: "ftp://93.184.216.34/hooks", want: ErrInvalidWebhook},
+		{name: "missing host", url: "https://:443/hooks", want: ErrInvalidWebhook},
+		{name: "loopback", url: "http://127.0.0.1:8080/hooks", want: ErrInvalidWebhook},
+		{name: "localhost", url: "http://localhost/hooks", want: ErrInvalidWebhook},
+		{name: "IPv6 loopback", url: "http://[::1]/hooks", want: ErrInvalidWebhook},
+		{name: "cloud metadata service", url: "http://169.254.169.254/latest/meta-data", want: ErrInvalidWebhook},
+		{name: "private network", url: "https://10.0.0.12/hooks", want: ErrInvalidWebhook},
+		{name: "unspecified address", url: "http://0.0.0.0/hooks", want: ErrInvalidWebhook},
+		{name: "unknown event", url: "https://93.184.216.34/hooks", events: []string{"user.teleported"}, want: ErrInvalidWebhook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhook(context.Background(), &models.WebhookSubscription{URL: tt.url, Events: tt.events})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("validateWebhook(%s) = %v, want %v", tt.url, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package spreadsheet reads and writes tabular files as CSV or XLSX.
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Supported formats.
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for formats other than CSV and XLSX.
+var ErrUnsupportedFormat = errors.New("unsupported format: expected csv or xlsx")
+
+// sheetName is the worksheet written to, and preferred when reading, XLSX files.
+const sheetName = "Sheet1"
+
+// FormatFromFilename returns the format implied by a file's extension.
+func FormatFromFilename(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Read returns every row of the file. XLSX files are read from their first sheet.
+// Rows may have fewer cells than the widest row.
+func Read(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case CSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("reading csv: %w", err)
+		}
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			// Spreadsheet programs often prefix UTF-8 exports with a byte order mark.
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	case XLSX:
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading xlsx: %w", err)
+		}
+		defer file.Close()
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil
+		}
+		rows, err := file.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("reading xlsx: %w", err)
+		}
+		return rows, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Write writes rows to w in the given format.
+func Write(w io.Writer, format string, rows [][]string) error {
+	writer, err := NewWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// RowWriter writes rows one at a time. Close must be called to finish the file.
+type RowWriter interface {
+	WriteRow(row []string) error
+	Close() error
+}
+
+// NewWriter returns a RowWriter producing the given format. CSV rows are
+// written to w as they come; XLSX rows are buffered by the XLSX stream writer,
+// which spills to a temporary file once they no longer fit in memory, and the
+// file is written to w on Close.
+func NewWriter(w io.Writer, format string) (RowWriter, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case XLSX:
+		file := excelize.NewFile()
+		stream, err := file.NewStreamWriter(sheetName)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("writing xlsx: %w", err)
+		}
+		return &xlsxWriter{out: w, file: file, stream: stream}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// csvFlushInterval is how many rows are buffered before they are flushed.
+const csvFlushInterval = 100
+
+type csvWriter struct {
+	writer *csv.Writer
+	rows   int
+}
+
+func (c *csvWriter) WriteRow(row []string) error {
+	if err := c.writer.Write(row); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	c.rows++
+	if c.rows%csvFlushInterval == 0 {
+		c.writer.Flush()
+		return c.writer.Error()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	rows   int
+}
+
+func (x *xlsxWriter) WriteRow(row []string) error {
+	x.rows++
+	cell, err := excelize.CoordinatesToCellName(1, x.rows)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		values[i] = v
+	}
+	if err := x.stream.SetRow(cell, values); err != nil {
+		return fmt.Errorf("writing xlsx: %w", err)
+	}
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return fmt.Errorf("writing xlsx: %w", err)
+	}
+	if _, err := x.file.WriteTo(x.out); err != nil {
+		return fmt.Errorf("writing xlsx: %w", err)
+	}
+	return nil
+}
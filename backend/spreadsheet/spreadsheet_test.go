@@ -0,0 +1,51 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	rows := [][]string{{"username", "class"}, {"amina", "Juz' Amma, boys"}, {"yusuf", ""}}
+	for _, format := range []string{CSV, XLSX} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, rows); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// XLSX drops trailing empty cells.
+			want := rows
+			if format == XLSX {
+				want = [][]string{rows[0], rows[1], {"yusuf"}}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Read(Write(rows)) = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestReadCSVStripsByteOrderMark(t *testing.T) {
+	rows, err := Read(strings.NewReader("\ufeffusername,phone\namina,555\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0][0] != "username" {
+		t.Fatalf("first header = %q, want %q", rows[0][0], "username")
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	if format, err := FormatFromFilename("Roster.XLSX"); err != nil || format != XLSX {
+		t.Fatalf("FormatFromFilename(Roster.XLSX) = %q, %v", format, err)
+	}
+	if _, err := FormatFromFilename("roster.xls"); err != ErrUnsupportedFormat {
+		t.Fatalf("FormatFromFilename(roster.xls) error = %v, want ErrUnsupportedFormat", err)
+	}
+}
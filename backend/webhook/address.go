@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrNonPublicAddress is returned when a webhook host is, or resolves to, an
+// address that is not reachable on the public internet: loopback, private,
+// link-local (such as the 169.254.169.254 metadata service) and the like.
+var ErrNonPublicAddress = errors.New("webhook: destination is not a public address")
+
+// nonPublicPrefixes are special-purpose ranges that netip does not classify
+// as private, loopback, link-local or multicast.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments
+	netip.MustParsePrefix("2002::/16"),       // 6to4, which can embed any IPv4 address
+	netip.MustParsePrefix("fec0::/10"),       // deprecated site-local
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("3fff::/20"),       // documentation
+	netip.MustParsePrefix("5f00::/16"),       // segment routing SIDs
+	netip.MustParsePrefix("::ffff:0:0:0/96"), // IPv4-translated
+}
+
+// IsPublicAddr reports whether webhooks may be sent to addr.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host and returns ErrNonPublicAddress unless every
+// address it resolves to is public. The check is repeated when connecting, as
+// the host may resolve differently by then.
+func CheckHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("webhook: resolving %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAddr(addr netip.Addr) error {
+	if !IsPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, addr)
+	}
+	return nil
+}
+
+// dialControl refuses connections to addresses that allowed rejects. It runs
+// on the resolved address about to be dialed, so a host that passed CheckHost
+// cannot be pointed at an internal address afterwards.
+func dialControl(allowed func(netip.Addr) bool) func(network, address string, _ syscall.RawConn) error {
+	return func(_, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		if !allowed(addrPort.Addr()) {
+			return fmt.Errorf("%w: %s", ErrNonPublicAddress, addrPort.Addr())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// allowAll lets the tests reach their loopback receivers.
+func allowAll(netip.Addr) bool { return true }
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"::ffff:93.184.216.34", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.10", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"2002:a9fe:a9fe::1", false},
+		{"2001:db8::1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{host: "93.184.216.34"},
+		{host: "2606:4700:4700::1111"},
+		{host: "127.0.0.1", wantErr: true},
+		{host: "169.254.169.254", wantErr: true},
+		{host: "::1", wantErr: true},
+		{host: "localhost", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := CheckHost(context.Background(), tt.host)
+		if tt.wantErr && !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("CheckHost(%s) = %v, want %v", tt.host, err, ErrNonPublicAddress)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("CheckHost(%s) = %v", tt.host, err)
+		}
+	}
+}
+
+func TestSendRefusesNonPublicAddresses(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// The receiver is on loopback, which a real Sender must never dial, whether
+	// the URL names the address or a host resolving to it.
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, receiver := range []string{server.URL, "http://localhost:" + target.Port()} {
+		_, err := NewSender(time.Second).Send(context.Background(), receiver, "secret", Message{Body: []byte(`{}`)})
+		if !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("Send(%s) error = %v, want %v", receiver, err, ErrNonPublicAddress)
+		}
+	}
+	if called {
+		t.Error("the loopback receiver was reached")
+	}
+}
@@ -0,0 +1,182 @@
+// Package webhook signs and sends webhook requests.
+//
+// Every request is a JSON POST carrying a signature header of the form
+//
+//	X-Webhook-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256>
+//
+// where the HMAC is keyed with the subscription's secret and computed over the
+// timestamp, a dot and the request body. Receivers should recompute it with
+// Verify and reject stale timestamps to stop replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	// firstRetryDelay is the wait before the first retry; it doubles after each failure.
+	firstRetryDelay = 30 * time.Second
+	// maxRetryDelay caps the wait between two attempts.
+	maxRetryDelay = 6 * time.Hour
+	// maxErrorBody is how much of a failed response's body is kept for the delivery log.
+	maxErrorBody = 512
+)
+
+var (
+	// ErrInvalidSignature is returned by Verify for a signature that does not match.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrStaleSignature is returned by Verify for a signature outside the tolerance.
+	ErrStaleSignature = errors.New("webhook: signature timestamp outside tolerance")
+)
+
+// Sign returns the signature header value for a body sent at the given time.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + mac(secret, t, body)
+}
+
+// Verify checks a signature header against the body, accepting timestamps up
+// to tolerance away from now.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signature = value
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || signature == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(mac(secret, t, body))) {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrStaleSignature
+	}
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Backoff returns how long to wait before retrying after the given number of
+// failed attempts.
+func Backoff(failedAttempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < failedAttempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// Message is one webhook request.
+type Message struct {
+	DeliveryID int64
+	EventType  string
+	Body       []byte
+}
+
+// StatusError is returned by Send when the receiver answers with anything but
+// a 2xx status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("webhook: receiver answered %d", e.StatusCode)
+	}
+	return fmt.Sprintf("webhook: receiver answered %d: %s", e.StatusCode, e.Body)
+}
+
+// Sender posts signed webhook requests. Redirects are not followed, and only
+// public addresses are dialed.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender creates a Sender whose requests give up after timeout.
+func NewSender(timeout time.Duration) *Sender {
+	return newSender(timeout, IsPublicAddr)
+}
+
+// newSender creates a Sender that only connects to addresses allowed accepts.
+func newSender(timeout time.Duration, allowed func(netip.Addr) bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl(allowed)}
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			// No proxy: the dialed address must be the receiver's for the check to hold.
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: timeout,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send posts the message to url, signed with secret, and returns the response
+// status. Any status outside 2xx is reported as a *StatusError.
+func (s *Sender) Send(ctx context.Context, url, secret string, msg Message) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quran-project-webhooks/1")
+	req.Header.Set(EventHeader, msg.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(msg.DeliveryID, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, s.now(), msg.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
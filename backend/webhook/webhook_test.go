@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"user.created"}`)
+	header := Sign("secret", now, body)
+
+	if err := Verify("secret", header, body, 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if err := Verify("other", header, body, 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with the wrong secret = %v", err)
+	}
+	if err := Verify("secret", header, []byte(`{}`), 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of a changed body = %v", err)
+	}
+	if err := Verify("secret", header, body, 5*time.Minute, now.Add(time.Hour)); !errors.Is(err, ErrStaleSignature) {
+		t.Errorf("Verify of an old signature = %v", err)
+	}
+	if err := Verify("secret", "garbage", body, 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of a malformed header = %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: 4*time.Hour + 16*time.Minute,
+		30: 6 * time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	body := []byte(`{"id":1,"type":"attendance.marked"}`)
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		if err := Verify("secret", r.Header.Get(SignatureHeader), got, time.Minute, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		received = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := newSender(time.Second, allowAll)
+	status, err := sender.Send(context.Background(), server.URL, "secret", Message{DeliveryID: 42, EventType: "attendance.marked", Body: body})
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Send = %d, %v", status, err)
+	}
+	if received.Header.Get(EventHeader) != "attendance.marked" || received.Header.Get(DeliveryHeader) != "42" {
+		t.Errorf("headers = %v", received.Header)
+	}
+
+	status, err = sender.Send(context.Background(), server.URL, "wrong", Message{DeliveryID: 43, EventType: "attendance.marked", Body: body})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || status != http.StatusUnauthorized {
+		t.Fatalf("Send with the wrong secret = %d, %v", status, err)
+	}
+	if statusErr.Body != ErrInvalidSignature.Error() {
+		t.Errorf("error body = %q", statusErr.Body)
+	}
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer server.Close()
+
+	status, err := newSender(time.Second, allowAll).Send(context.Background(), server.URL, "secret", Message{Body: []byte(`{}`)})
+	if status != http.StatusFound || err == nil {
+		t.Errorf("Send = %d, %v; want the redirect reported as a failure", status, err)
+	}
+}